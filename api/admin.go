@@ -2,12 +2,14 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/token"
 	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
 )
 
@@ -20,14 +22,16 @@ type createAdminRequest struct {
 }
 
 type adminResponse struct {
-	AdminID           int32     `json:"admin_id"`
-	Name              string    `json:"name"`
-	Email             string    `json:"email"`
-	Phone             string    `json:"phone"`
-	Address           string    `json:"address"`
-	TotalOrders       int32     `json:"total_orders"`
-	CreatedAt         time.Time `json:"created_at"`
-	PasswordChangedAt time.Time `json:"password_changed_at"`
+	AdminID           int32      `json:"admin_id"`
+	Name              string     `json:"name"`
+	Email             string     `json:"email"`
+	Phone             string     `json:"phone"`
+	Address           string     `json:"address"`
+	TotalOrders       int32      `json:"total_orders"`
+	CreatedAt         time.Time  `json:"created_at"`
+	PasswordChangedAt time.Time  `json:"password_changed_at"`
+	ArchivedAt        *time.Time `json:"archived_at,omitempty"`
+	ProfileImage      string     `json:"profile_image"`
 }
 
 func newAdminResponse(admin db.Admin) adminResponse {
@@ -39,6 +43,8 @@ func newAdminResponse(admin db.Admin) adminResponse {
 		Address:           admin.Address,
 		PasswordChangedAt: admin.PasswordChangedAt,
 		CreatedAt:         admin.CreatedAt,
+		ArchivedAt:        optionalTime(admin.ArchivedAt),
+		ProfileImage:      admin.ProfileImage,
 	}
 }
 
@@ -71,12 +77,16 @@ func (server *Server) createAdmin(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+// addAdmin adds a staff member, only staff that are not limited to branches can add staff
 func (server *Server) addAdmin(ctx *gin.Context) {
 	var req createAdminRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
 	hashedPassword, err := util.HashPassword(req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -101,8 +111,11 @@ func (server *Server) addAdmin(ctx *gin.Context) {
 }
 
 type listAdminsRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	PageID          int32 `form:"page_id" binding:"required,min=1"`
+	PageSize        int32 `form:"page_size" binding:"required,min=5,max=10"`
+	IncludeArchived bool  `form:"include_archived"`
+	// BranchID lists the staff that work at the branch, including staff not limited to branches
+	BranchID *int32 `form:"branch_id" binding:"omitempty,min=1"`
 }
 
 func (server *Server) listAdmins(ctx *gin.Context) {
@@ -111,10 +124,16 @@ func (server *Server) listAdmins(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	if _, err := server.currentAdmin(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
 
 	arg := db.ListAdminsParams{
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		IncludeArchived: req.IncludeArchived,
+		BranchID:        nullInt32(req.BranchID),
+		Limit:           req.PageSize,
+		Offset:          (req.PageID - 1) * req.PageSize,
 	}
 	admins, err := server.store.ListAdmins(ctx, arg)
 	if err != nil {
@@ -122,7 +141,12 @@ func (server *Server) listAdmins(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, admins)
+
+	res := make([]adminResponse, len(admins))
+	for i, admin := range admins {
+		res[i] = newAdminResponse(admin)
+	}
+	ctx.JSON(http.StatusOK, res)
 
 }
 
@@ -165,6 +189,8 @@ func (server *Server) loginAdmin(ctx *gin.Context) {
 
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		admin.Email,
+		token.AccountAdmin,
+		admin.AdminID,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
@@ -174,6 +200,8 @@ func (server *Server) loginAdmin(ctx *gin.Context) {
 
 	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
 		admin.Email,
+		token.AccountAdmin,
+		admin.AdminID,
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
@@ -206,3 +234,64 @@ func (server *Server) loginAdmin(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, res)
 }
+
+type adminURI struct {
+	AdminID int32 `uri:"admin_id" binding:"required,min=1"`
+}
+
+// archiveAdmin archives a staff member, orders assigned to them keep the assignment.
+// Only staff that are not limited to branches can archive or restore staff.
+func (server *Server) archiveAdmin(ctx *gin.Context) {
+	var uri adminURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	caller, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if caller.AdminID == uri.AdminID {
+		err := errors.New("you cannot archive your own account")
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	admin, err := server.store.ArchiveAdminTx(ctx, uri.AdminID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newAdminResponse(admin))
+}
+
+func (server *Server) restoreAdmin(ctx *gin.Context) {
+	var uri adminURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	admin, err := server.store.RestoreAdmin(ctx, uri.AdminID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newAdminResponse(admin))
+}
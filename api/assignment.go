@@ -0,0 +1,199 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+)
+
+type assignOrderRequest struct {
+	AdminID  int32  `json:"admin_id" binding:"omitempty,min=1"`
+	Strategy string `json:"strategy" binding:"omitempty,oneof=round_robin least_loaded"`
+}
+
+func (server *Server) assignOrder(ctx *gin.Context) {
+	server.changeAssignment(ctx, false)
+}
+
+func (server *Server) reassignOrder(ctx *gin.Context) {
+	server.changeAssignment(ctx, true)
+}
+
+func (server *Server) changeAssignment(ctx *gin.Context, reassign bool) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req assignOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if (req.AdminID == 0) == (req.Strategy == "") {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "either admin_id or strategy is required"})
+		return
+	}
+
+	server.assignOrderTo(ctx, db.AssignOrderTxParams{
+		OrderID:  uri.OrderID,
+		AdminID:  sql.NullInt32{Int32: req.AdminID, Valid: req.AdminID != 0},
+		Strategy: req.Strategy,
+		Reassign: reassign,
+	})
+}
+
+func (server *Server) unassignOrder(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	server.assignOrderTo(ctx, db.AssignOrderTxParams{
+		OrderID:  uri.OrderID,
+		Reassign: true,
+	})
+}
+
+func (server *Server) assignOrderTo(ctx *gin.Context, arg db.AssignOrderTxParams) {
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if !server.checkOrderBranch(ctx, arg.OrderID) {
+		return
+	}
+	arg.ChangedBy = sql.NullInt32{Int32: admin.AdminID, Valid: true}
+
+	result, err := server.store.AssignOrderTx(ctx, arg)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrOrderAlreadyAssigned):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrOrderNotAssigned), errors.Is(err, db.ErrStaffNotFound), errors.Is(err, db.ErrOrderDelivered),
+			errors.Is(err, db.ErrStaffNotInBranch):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+type updateOrderPriorityRequest struct {
+	Priority int32 `json:"priority"`
+}
+
+func (server *Server) updateOrderPriority(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateOrderPriorityRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if !server.checkOrderBranch(ctx, uri.OrderID) {
+		return
+	}
+
+	orders, err := server.store.UpdateOrderPriority(ctx, db.UpdateOrderPriorityParams{
+		OrderID:    uri.OrderID,
+		Priority:   req.Priority,
+		ModifiedBy: sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if len(orders) == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+	ctx.JSON(http.StatusOK, orders)
+}
+
+type queuedOrderResponse struct {
+	OrderID           int64        `json:"order_id"`
+	UserID            int32        `json:"user_id"`
+	OrderStatus       string       `json:"order_status"`
+	Priority          int32        `json:"priority"`
+	OrderStarted      time.Time    `json:"order_started"`
+	OrderDeliveryTime time.Time    `json:"order_delivery_time"`
+	AssignedAt        sql.NullTime `json:"assigned_at"`
+	ServiceIDs        []int32      `json:"service_ids"`
+}
+
+type listMyQueueRequest struct {
+	BranchID *int32 `form:"branch_id" binding:"omitempty,min=1"`
+}
+
+// listMyQueue lists the open orders assigned to the current staff member, highest priority
+// first and then by the time they are due
+func (server *Server) listMyQueue(ctx *gin.Context) {
+	var req listMyQueueRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	branchIDs, ok := server.orderBranches(ctx, req.BranchID)
+	if !ok {
+		return
+	}
+
+	orders, err := server.store.ListAssignedOrders(ctx, db.ListAssignedOrdersParams{
+		AssignedTo: sql.NullInt32{Int32: admin.AdminID, Valid: true},
+		BranchIds:  branchIDs,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Rows come sorted, so the first row of every order keeps its place in the queue
+	response := []queuedOrderResponse{}
+	index := make(map[int64]int)
+	for _, order := range orders {
+		if i, exists := index[order.OrderID]; exists {
+			response[i].ServiceIDs = append(response[i].ServiceIDs, order.ServiceIds)
+			continue
+		}
+		index[order.OrderID] = len(response)
+		response = append(response, queuedOrderResponse{
+			OrderID:           order.OrderID,
+			UserID:            order.UserID,
+			OrderStatus:       order.OrderStatus,
+			Priority:          order.Priority,
+			OrderStarted:      order.OrderStarted,
+			OrderDeliveryTime: order.OrderDeliveryTime,
+			AssignedAt:        order.AssignedAt,
+			ServiceIDs:        []int32{order.ServiceIds},
+		})
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+// attachmentTypes are the content types that can be attached, with the extension they are stored under
+var attachmentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// attachmentPrefix is where the attachments of an order are stored, they are private and only
+// served to the callers who can see them through downloadOrderAttachment
+func attachmentPrefix(orderID int64) string {
+	return fmt.Sprintf("%sorders/%d", privatePrefix, orderID)
+}
+
+// attachmentFilePath is the API path an attachment is downloaded from
+func attachmentFilePath(orderID int64, attachmentID int64) string {
+	return fmt.Sprintf("/orders/%d/attachments/%d/file", orderID, attachmentID)
+}
+
+// newAttachmentResponse points the file URL of an attachment to the API, which checks the caller can see it
+func newAttachmentResponse(attachment db.OrderAttachment) db.OrderAttachment {
+	attachment.FileUrl = attachmentFilePath(attachment.OrderID, attachment.AttachmentID)
+	return attachment
+}
+
+// checkAttachmentNote checks an attachment is added to a note on the same order that the caller can see,
+// customers cannot see internal notes. It writes the error response and returns false when the note does not fit.
+func (server *Server) checkAttachmentNote(ctx *gin.Context, c caller, orderID int64, noteID int64) bool {
+	note, err := server.store.GetOrderNote(ctx, noteID)
+	if err == nil && (note.OrderID != orderID || (!c.isStaff() && note.Visibility == util.VisibilityInternal)) {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+func (server *Server) createOrderAttachment(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	c, ok := server.orderCaller(ctx, uri.OrderID)
+	if !ok {
+		return
+	}
+
+	maxSize := server.config.UploadMaxAttachmentSize
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+1<<20)
+	_, fileHeader, err := ctx.Request.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	file, err := readUpload(fileHeader, attachmentTypes, maxSize, attachmentPrefix(uri.OrderID))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	visibility, err := noteVisibility(c, ctx.Request.FormValue("visibility"))
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	// An attachment can illustrate a note on the same order, such as a photo of a stain
+	var noteID sql.NullInt64
+	if noteIDStr := ctx.Request.FormValue("note_id"); noteIDStr != "" {
+		noteID.Int64, err = strconv.ParseInt(noteIDStr, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		noteID.Valid = true
+
+		if !server.checkAttachmentNote(ctx, c, uri.OrderID, noteID.Int64) {
+			return
+		}
+	}
+
+	fileURL, err := server.storeUpload(ctx, file)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	adminID, userID := c.authorID()
+	attachment, err := server.store.CreateOrderAttachment(ctx, db.CreateOrderAttachmentParams{
+		OrderID:     uri.OrderID,
+		NoteID:      noteID,
+		FileUrl:     fileURL,
+		FileName:    file.FileName,
+		ContentType: file.ContentType,
+		SizeBytes:   int64(len(file.Data)),
+		Visibility:  visibility,
+		AdminID:     adminID,
+		UserID:      userID,
+	})
+	if err != nil {
+		server.removeUpload(file.Key)
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newAttachmentResponse(attachment))
+}
+
+// listOrderAttachments lists every attachment for staff and only the customer visible ones for customers
+func (server *Server) listOrderAttachments(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	c, ok := server.orderCaller(ctx, uri.OrderID)
+	if !ok {
+		return
+	}
+
+	attachments, err := server.store.ListOrderAttachments(ctx, db.ListOrderAttachmentsParams{
+		OrderID:         uri.OrderID,
+		IncludeInternal: c.isStaff(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	for i := range attachments {
+		attachments[i] = newAttachmentResponse(attachments[i])
+	}
+	ctx.JSON(http.StatusOK, attachments)
+}
+
+type orderAttachmentURI struct {
+	OrderID      int64 `uri:"order_id" binding:"required"`
+	AttachmentID int64 `uri:"attachment_id" binding:"required,min=1"`
+}
+
+// orderAttachment loads the attachment in the URI and checks the caller can see it, customers cannot see
+// internal attachments. It writes the error response and returns false when the request cannot go on.
+func (server *Server) orderAttachment(ctx *gin.Context) (caller, db.OrderAttachment, bool) {
+	var uri orderAttachmentURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return caller{}, db.OrderAttachment{}, false
+	}
+
+	c, ok := server.orderCaller(ctx, uri.OrderID)
+	if !ok {
+		return caller{}, db.OrderAttachment{}, false
+	}
+
+	attachment, err := server.store.GetOrderAttachment(ctx, uri.AttachmentID)
+	if err == nil && (attachment.OrderID != uri.OrderID || (!c.isStaff() && attachment.Visibility == util.VisibilityInternal)) {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return caller{}, db.OrderAttachment{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return caller{}, db.OrderAttachment{}, false
+	}
+	return c, attachment, true
+}
+
+// downloadOrderAttachment streams the file of an attachment to a caller who can see it
+func (server *Server) downloadOrderAttachment(ctx *gin.Context) {
+	_, attachment, ok := server.orderAttachment(ctx)
+	if !ok {
+		return
+	}
+
+	key, ok := server.storage.Key(attachment.FileUrl)
+	if !ok {
+		err := errors.New("attachment is not kept in the storage")
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+	server.servePrivate(ctx, key, attachment.ContentType, attachment.FileName)
+}
+
+// deleteOrderAttachment lets staff delete any attachment and customers only the ones they uploaded.
+// The uploaded file is removed from the bucket along with it.
+func (server *Server) deleteOrderAttachment(ctx *gin.Context) {
+	c, attachment, ok := server.orderAttachment(ctx)
+	if !ok {
+		return
+	}
+
+	if !c.isStaff() && !c.isAuthor(attachment.AdminID, attachment.UserID) {
+		err := errors.New("customers can only delete their own attachments")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	err := server.store.DeleteOrderAttachment(ctx, attachment.AttachmentID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if key, ok := server.storage.Key(attachment.FileUrl); ok {
+		server.removeUpload(key)
+	}
+	ctx.JSON(http.StatusOK, "Attachment Deleted Successfully")
+}
+
+// attachmentMoveBatch is how many attachments MovePublicAttachments moves at a time
+const attachmentMoveBatch = 100
+
+// MovePublicAttachments moves the files of attachments uploaded before attachments were private from
+// orders/ to private/orders/, removing the public copy once the attachment points to the private one.
+// It is safe to run on every start, attachments that are already private are left alone.
+func (server *Server) MovePublicAttachments(ctx context.Context) {
+	moved := 0
+	var afterID int64
+	for {
+		attachments, err := server.store.ListPublicOrderAttachments(ctx, db.ListPublicOrderAttachmentsParams{
+			AfterID: afterID,
+			Limit:   attachmentMoveBatch,
+		})
+		if err != nil {
+			log.Printf("cannot list public attachments: %v", err)
+			return
+		}
+
+		for _, attachment := range attachments {
+			afterID = attachment.AttachmentID
+			if err := server.moveAttachment(ctx, attachment); err != nil {
+				log.Printf("cannot move attachment %d: %v", attachment.AttachmentID, err)
+				continue
+			}
+			moved++
+		}
+		if len(attachments) < attachmentMoveBatch {
+			break
+		}
+	}
+	if moved > 0 {
+		log.Printf("moved %d attachments to private storage", moved)
+	}
+}
+
+// moveAttachment copies the file of an attachment under privatePrefix and points the attachment to the copy
+func (server *Server) moveAttachment(ctx context.Context, attachment db.OrderAttachment) error {
+	key, ok := server.storage.Key(attachment.FileUrl)
+	if !ok || strings.HasPrefix(key, privatePrefix) {
+		return fmt.Errorf("file %s is not a public file of the storage", attachment.FileUrl)
+	}
+
+	body, err := server.storage.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	privateKey := privatePrefix + key
+	err = server.storage.Put(ctx, privateKey, attachment.ContentType, body)
+	if err != nil {
+		return err
+	}
+
+	err = server.store.SetOrderAttachmentFileURL(ctx, db.SetOrderAttachmentFileURLParams{
+		AttachmentID: attachment.AttachmentID,
+		FileUrl:      server.storage.URL(privateKey),
+	})
+	if err != nil {
+		server.removeUpload(privateKey)
+		return err
+	}
+	server.removeUpload(key)
+	return nil
+}
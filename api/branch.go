@@ -0,0 +1,389 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+)
+
+var (
+	errBranchForbidden = errors.New("you do not work at this branch")
+	errHeadOfficeOnly  = errors.New("only staff that are not limited to branches can do this")
+)
+
+// branchScope holds the branches an admin works at, an empty scope covers every branch
+type branchScope []int32
+
+func (s branchScope) covers(branchID int32) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, id := range s {
+		if id == branchID {
+			return true
+		}
+	}
+	return false
+}
+
+// currentScope returns the branches the authenticated admin works at.
+// It writes the error response and returns false when the request cannot go on.
+func (server *Server) currentScope(ctx *gin.Context) (branchScope, bool) {
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+	scope, err := server.store.ListAdminBranchIDs(ctx, admin.AdminID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+	return scope, true
+}
+
+// orderBranches returns the branches to list orders of, the requested branch or else every branch the admin works at.
+// An empty list stands for every branch. It writes the error response and returns false when the admin does not work
+// at the requested branch.
+func (server *Server) orderBranches(ctx *gin.Context, branchID *int32) ([]int32, bool) {
+	scope, ok := server.currentScope(ctx)
+	if !ok {
+		return nil, false
+	}
+	if branchID == nil {
+		return scope, true
+	}
+	if !scope.covers(*branchID) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errBranchForbidden))
+		return nil, false
+	}
+	return []int32{*branchID}, true
+}
+
+// checkOrderBranch makes sure the caller is an admin working at the branch of the order, handlers that customers
+// can reach use orderCaller instead. It writes the error response and returns false when the request cannot go on.
+func (server *Server) checkOrderBranch(ctx *gin.Context, orderID int64) bool {
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errNotAdmin))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	scope, err := server.store.ListAdminBranchIDs(ctx, admin.AdminID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if len(scope) == 0 {
+		return true
+	}
+
+	orders, err := server.store.GetOrder(ctx, orderID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	// Unknown orders are left to the handler to report
+	if len(orders) > 0 && !branchScope(scope).covers(orders[0].BranchID) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errBranchForbidden))
+		return false
+	}
+	return true
+}
+
+// requireHeadOffice lets only admins that are not limited to branches through.
+// It writes the error response and returns false otherwise.
+func (server *Server) requireHeadOffice(ctx *gin.Context) bool {
+	scope, ok := server.currentScope(ctx)
+	if !ok {
+		return false
+	}
+	if len(scope) > 0 {
+		ctx.JSON(http.StatusForbidden, errorResponse(errHeadOfficeOnly))
+		return false
+	}
+	return true
+}
+
+// orderBranch picks the branch an order goes to: the requested one, else the branch of the customer, else the default branch
+func (server *Server) orderBranch(ctx *gin.Context, requested *int32, user db.User) (int32, error) {
+	if requested != nil {
+		return *requested, nil
+	}
+	if user.BranchID.Valid {
+		return user.BranchID.Int32, nil
+	}
+	branch, err := server.store.GetDefaultBranch(ctx)
+	return branch.BranchID, err
+}
+
+type createBranchRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+}
+
+func (server *Server) createBranch(ctx *gin.Context) {
+	var req createBranchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	branch, err := server.store.CreateBranch(ctx, db.CreateBranchParams{
+		Name:    req.Name,
+		Address: req.Address,
+		Phone:   req.Phone,
+	})
+	if err != nil {
+		branchError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, branch)
+}
+
+// listBranches lists every branch, customers pick the branch of an order from it
+func (server *Server) listBranches(ctx *gin.Context) {
+	branches, err := server.store.ListBranches(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, branches)
+}
+
+type branchURI struct {
+	BranchID int32 `uri:"branch_id" binding:"required,min=1"`
+}
+
+type updateBranchRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+	// Inactive branches keep their orders but take no new ones
+	IsActive bool `json:"is_active"`
+}
+
+func (server *Server) updateBranch(ctx *gin.Context) {
+	var uri branchURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateBranchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	branch, err := server.store.GetBranch(ctx, uri.BranchID)
+	if err != nil {
+		branchError(ctx, err)
+		return
+	}
+	if branch.IsDefault && !req.IsActive {
+		err := errors.New("the default branch cannot be closed")
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	branch, err = server.store.UpdateBranch(ctx, db.UpdateBranchParams{
+		BranchID: uri.BranchID,
+		Name:     req.Name,
+		Address:  req.Address,
+		Phone:    req.Phone,
+		IsActive: req.IsActive,
+	})
+	if err != nil {
+		branchError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, branch)
+}
+
+// listServiceBranches lists the services whose price or availability is changed at a branch
+func (server *Server) listServiceBranches(ctx *gin.Context) {
+	var uri branchURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if _, ok := server.orderBranches(ctx, &uri.BranchID); !ok {
+		return
+	}
+
+	overrides, err := server.store.ListServiceBranches(ctx, uri.BranchID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, overrides)
+}
+
+type serviceBranchURI struct {
+	BranchID  int32 `uri:"branch_id" binding:"required,min=1"`
+	ServiceID int32 `uri:"service_id" binding:"required,min=1"`
+}
+
+type setServiceBranchRequest struct {
+	// Price replaces the price of the service at the branch, leave it out to keep the price of the service
+	Price *int64 `json:"price" binding:"omitempty,min=0"`
+	// IsAvailable defaults to true, unavailable services cannot be ordered at the branch
+	IsAvailable *bool `json:"is_available"`
+}
+
+// setServiceBranch changes the price or availability of a service at one branch
+func (server *Server) setServiceBranch(ctx *gin.Context) {
+	var uri serviceBranchURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req setServiceBranchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if _, ok := server.orderBranches(ctx, &uri.BranchID); !ok {
+		return
+	}
+
+	isAvailable := true
+	if req.IsAvailable != nil {
+		isAvailable = *req.IsAvailable
+	}
+
+	override, err := server.store.UpsertServiceBranch(ctx, db.UpsertServiceBranchParams{
+		ServiceID:   uri.ServiceID,
+		BranchID:    uri.BranchID,
+		Price:       nullInt64(req.Price),
+		IsAvailable: isAvailable,
+	})
+	if err != nil {
+		branchError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, override)
+}
+
+// deleteServiceBranch puts a service back to its own price and availability at a branch
+func (server *Server) deleteServiceBranch(ctx *gin.Context) {
+	var uri serviceBranchURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if _, ok := server.orderBranches(ctx, &uri.BranchID); !ok {
+		return
+	}
+
+	rows, err := server.store.DeleteServiceBranch(ctx, db.DeleteServiceBranchParams{
+		ServiceID: uri.ServiceID,
+		BranchID:  uri.BranchID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+type adminBranchesURI struct {
+	AdminID int32 `uri:"admin_id" binding:"required,min=1"`
+}
+
+type setAdminBranchesRequest struct {
+	// BranchIDs limits the admin to these branches, an empty list lets them see every branch
+	BranchIDs []int32 `json:"branch_ids" binding:"dive,min=1"`
+}
+
+type adminBranchesResponse struct {
+	AdminID   int32   `json:"admin_id"`
+	BranchIDs []int32 `json:"branch_ids"`
+}
+
+// listAdminBranches lists the branches of a staff member, staff limited to branches can only see their own
+func (server *Server) listAdminBranches(ctx *gin.Context) {
+	var uri adminBranchesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if admin.AdminID != uri.AdminID && !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	branchIDs, err := server.store.ListAdminBranchIDs(ctx, uri.AdminID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, adminBranchesResponse{AdminID: uri.AdminID, BranchIDs: branchIDs})
+}
+
+func (server *Server) setAdminBranches(ctx *gin.Context) {
+	var uri adminBranchesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req setAdminBranchesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	branchIDs, err := server.store.SetAdminBranchesTx(ctx, uri.AdminID, req.BranchIDs)
+	if err != nil {
+		branchError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, adminBranchesResponse{AdminID: uri.AdminID, BranchIDs: branchIDs})
+}
+
+// branchError maps the errors of writing a branch or one of its links to a response
+func branchError(ctx *gin.Context, err error) {
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			err := errors.New("a branch with this name already exists")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		case "foreign_key_violation":
+			err := errors.New("branch or service does not exist")
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+	}
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+type bulkUpdateOrdersRequest struct {
+	OrderIDs []int64 `json:"order_ids" binding:"required,min=1,max=100,dive,min=1"`
+	Action   string  `json:"action" binding:"required,oneof=status deliver assign cancel"`
+	// Status is required by the status action
+	Status string `json:"status"`
+	// AdminID or Strategy is required by the assign action
+	AdminID  int32  `json:"admin_id" binding:"omitempty,min=1"`
+	Strategy string `json:"strategy" binding:"omitempty,oneof=round_robin least_loaded"`
+}
+
+// bulkUpdateOrders applies one action to a list of orders. The batch is all or nothing,
+// when any order fails nothing is changed and the response tells which orders failed and why.
+func (server *Server) bulkUpdateOrders(ctx *gin.Context) {
+	var req bulkUpdateOrdersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	switch req.Action {
+	case db.BulkActionStatus:
+		if !util.IsOrderStatus(req.Status) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "a valid status is required"})
+			return
+		}
+		if req.Status == util.OrderStatusDelivered || req.Status == util.OrderStatusCancelled {
+			ctx.JSON(http.StatusBadRequest, errorResponse(db.ErrBulkStatusAction))
+			return
+		}
+	case db.BulkActionAssign:
+		if (req.AdminID == 0) == (req.Strategy == "") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "either admin_id or strategy is required"})
+			return
+		}
+	}
+
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	// Orders of other branches are reported in the results like any other order that cannot be changed
+	scope, ok := server.currentScope(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.BulkUpdateOrdersTx(ctx, db.BulkUpdateOrdersTxParams{
+		OrderIDs:  req.OrderIDs,
+		Action:    req.Action,
+		Status:    req.Status,
+		AdminID:   sql.NullInt32{Int32: req.AdminID, Valid: req.AdminID != 0},
+		Strategy:  req.Strategy,
+		ChangedBy: sql.NullInt32{Int32: admin.AdminID, Valid: true},
+		BranchIDs: scope,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrBatchRejected) {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "results": result.Results})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Delivered orders are invoiced and the customers asked for feedback as with a single delivery.
+	// The orders are already committed, so failures are logged instead of failing the batch.
+	// An invoice that cannot be issued now is issued when it is first requested.
+	if req.Action == db.BulkActionDeliver {
+		for _, res := range result.Results {
+			if _, err := server.issueInvoice(ctx, res.OrderID); err != nil {
+				log.Printf("cannot invoice order %d: %v", res.OrderID, err)
+			}
+			server.requestFeedback(ctx, res.Orders[0])
+		}
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+)
+
+type bundleItemRequest struct {
+	ServiceID int32 `json:"service_id" binding:"required,min=1"`
+	Quantity  int32 `json:"quantity" binding:"required,min=1"`
+}
+
+type bundleRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	// Price is charged for the whole bundle and spread over its services when ordered
+	Price    int64               `json:"price" binding:"min=0"`
+	IsActive *bool               `json:"is_active"`
+	Items    []bundleItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+func (req bundleRequest) params(bundleID int32) db.BundleTxParams {
+	arg := db.BundleTxParams{
+		BundleID:    bundleID,
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		Items:       make([]db.BundleItem, len(req.Items)),
+	}
+	for i, item := range req.Items {
+		arg.Items[i] = db.BundleItem{ServiceID: item.ServiceID, Quantity: item.Quantity}
+	}
+	return arg
+}
+
+type bundleURI struct {
+	BundleID int32 `uri:"bundle_id" binding:"required,min=1"`
+}
+
+func (server *Server) createBundle(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var req bundleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.CreateBundleTx(ctx, req.params(0))
+	if err != nil {
+		bundleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// listBundles lists every bundle with its services for staff, including inactive ones
+func (server *Server) listBundles(ctx *gin.Context) {
+	if _, err := server.currentAdmin(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	bundles, err := server.store.ListBundles(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	items, err := server.store.ListAllBundleItems(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	bundleItems := groupBundleItems(items)
+	res := make([]db.BundleTxResult, len(bundles))
+	for i, bundle := range bundles {
+		res[i] = db.BundleTxResult{Bundle: bundle, Items: bundleItems[bundle.BundleID]}
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (server *Server) getBundle(ctx *gin.Context) {
+	var uri bundleURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	bundle, err := server.store.GetBundle(ctx, uri.BundleID)
+	if err != nil {
+		bundleError(ctx, err)
+		return
+	}
+
+	items, err := server.store.ListBundleItems(ctx, uri.BundleID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, db.BundleTxResult{Bundle: bundle, Items: items})
+}
+
+// updateBundle replaces a bundle and its services, orders already placed keep their prices
+func (server *Server) updateBundle(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var uri bundleURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req bundleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.UpdateBundleTx(ctx, req.params(uri.BundleID))
+	if err != nil {
+		bundleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// deleteBundle deletes a bundle that was never ordered, ordered ones can be deactivated instead
+func (server *Server) deleteBundle(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var uri bundleURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetBundle(ctx, uri.BundleID); err != nil {
+		bundleError(ctx, err)
+		return
+	}
+
+	err := server.store.DeleteBundle(ctx, uri.BundleID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			err := errors.New("bundle has been ordered, deactivate it instead")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// bundleError maps the errors of writing a bundle to a response
+func bundleError(ctx *gin.Context, err error) {
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+	if err == db.ErrDuplicateBundleItem {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			err := errors.New("a bundle with this name already exists")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		case "foreign_key_violation":
+			err := errors.New("one of the services does not exist")
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+	}
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+}
+
+func groupBundleItems(items []db.BundleItem) map[int32][]db.BundleItem {
+	grouped := make(map[int32][]db.BundleItem)
+	for _, item := range items {
+		grouped[item.BundleID] = append(grouped[item.BundleID], item)
+	}
+	return grouped
+}
@@ -0,0 +1,425 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+)
+
+type createCategoryRequest struct {
+	ParentID     *int32 `json:"parent_id" binding:"omitempty,min=1"`
+	Name         string `json:"name" binding:"required"`
+	Icon         string `json:"icon"`
+	DisplayOrder int32  `json:"display_order"`
+	// IsActive defaults to true, inactive categories are hidden from the catalog with everything below them
+	IsActive *bool `json:"is_active"`
+}
+
+func (server *Server) createCategory(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var req createCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	category, err := server.store.CreateCategory(ctx, db.CreateCategoryParams{
+		ParentID:     nullInt32(req.ParentID),
+		Name:         req.Name,
+		Icon:         req.Icon,
+		DisplayOrder: req.DisplayOrder,
+		IsActive:     isActive,
+	})
+	if err != nil {
+		categoryError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, category)
+}
+
+// listCategories lists every category for staff, including inactive ones
+func (server *Server) listCategories(ctx *gin.Context) {
+	if _, err := server.currentAdmin(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	categories, err := server.store.ListCategories(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, categories)
+}
+
+type categoryURI struct {
+	CategoryID int32 `uri:"category_id" binding:"required,min=1"`
+}
+
+type updateCategoryRequest struct {
+	ParentID     *int32 `json:"parent_id" binding:"omitempty,min=1"`
+	Name         string `json:"name" binding:"required"`
+	Icon         string `json:"icon"`
+	DisplayOrder int32  `json:"display_order"`
+	IsActive     *bool  `json:"is_active" binding:"required"`
+}
+
+func (server *Server) updateCategory(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var uri categoryURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.ParentID != nil {
+		categories, err := server.store.ListCategories(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if isDescendant(categories, *req.ParentID, uri.CategoryID) {
+			err := errors.New("a category cannot be moved below itself")
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+	}
+
+	category, err := server.store.UpdateCategory(ctx, db.UpdateCategoryParams{
+		CategoryID:   uri.CategoryID,
+		ParentID:     nullInt32(req.ParentID),
+		Name:         req.Name,
+		Icon:         req.Icon,
+		DisplayOrder: req.DisplayOrder,
+		IsActive:     *req.IsActive,
+	})
+	if err != nil {
+		categoryError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, category)
+}
+
+// isDescendant reports whether the category id is ancestor itself or one of the categories below it
+func isDescendant(categories []db.Category, id, ancestor int32) bool {
+	parents := make(map[int32]sql.NullInt32, len(categories))
+	for _, category := range categories {
+		parents[category.CategoryID] = category.ParentID
+	}
+
+	// The walk is bounded in case the stored tree already has a cycle
+	for i := 0; i <= len(categories); i++ {
+		if id == ancestor {
+			return true
+		}
+		parent, ok := parents[id]
+		if !ok || !parent.Valid {
+			return false
+		}
+		id = parent.Int32
+	}
+	return true
+}
+
+// deleteCategory deletes a category without subcategories, its services become uncategorized
+func (server *Server) deleteCategory(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var uri categoryURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetCategory(ctx, uri.CategoryID); err != nil {
+		categoryError(ctx, err)
+		return
+	}
+
+	err := server.store.DeleteCategory(ctx, uri.CategoryID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			err := errors.New("category has subcategories, move or delete them first")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// categoryError maps the errors of writing a category to a response
+func categoryError(ctx *gin.Context, err error) {
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			err := errors.New("a category with this name already exists under the same parent")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		case "foreign_key_violation":
+			err := errors.New("parent category does not exist")
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+	}
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+}
+
+type setServiceCategoryURI struct {
+	ServiceID int32 `uri:"service_id" binding:"required,min=1"`
+}
+
+type setServiceCategoryRequest struct {
+	// A null category_id removes the service from its category
+	CategoryID *int32 `json:"category_id" binding:"omitempty,min=1"`
+}
+
+func (server *Server) setServiceCategory(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var uri setServiceCategoryURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setServiceCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	service, err := server.store.SetServiceCategory(ctx, db.SetServiceCategoryParams{
+		ServiceID:  uri.ServiceID,
+		CategoryID: nullInt32(req.CategoryID),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			err := errors.New("category does not exist")
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, service)
+}
+
+type catalogService struct {
+	db.Service
+	Variants []db.ServiceVariant `json:"variants"`
+}
+
+type catalogCategory struct {
+	CategoryID int32              `json:"category_id"`
+	Name       string             `json:"name"`
+	Icon       string             `json:"icon"`
+	Services   []catalogService   `json:"services"`
+	Children   []*catalogCategory `json:"children"`
+}
+
+type catalogBundle struct {
+	db.Bundle
+	Items []db.BundleItem `json:"items"`
+	// ListPrice is what the services of the bundle cost on their own
+	ListPrice int64 `json:"list_price"`
+}
+
+type catalogResponse struct {
+	Categories    []*catalogCategory `json:"categories"`
+	Uncategorized []catalogService   `json:"uncategorized"`
+	Bundles       []catalogBundle    `json:"bundles"`
+}
+
+type getCatalogRequest struct {
+	// BranchID prices the catalog for a branch and leaves out what cannot be ordered there
+	BranchID *int32 `form:"branch_id" binding:"omitempty,min=1"`
+}
+
+// getCatalog returns the active categories as a tree with their services and variants, and the active bundles
+func (server *Server) getCatalog(ctx *gin.Context) {
+	var req getCatalogRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	categories, err := server.store.ListCategories(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	services, err := server.store.ListAllServices(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if req.BranchID != nil {
+		overrides, err := server.store.ListServiceBranches(ctx, *req.BranchID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		services = branchServices(services, overrides)
+	}
+
+	variants, err := server.store.ListAllServiceVariants(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	catalog := buildCatalog(categories, services, variants)
+
+	bundles, err := server.store.ListBundles(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	items, err := server.store.ListAllBundleItems(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	catalog.Bundles = catalogBundles(bundles, items, services)
+	ctx.JSON(http.StatusOK, catalog)
+}
+
+// branchServices applies the prices of a branch to the services and drops the ones it does not offer.
+// Bundles with a dropped service are left out of the catalog with it.
+func branchServices(services []db.Service, overrides []db.ServiceBranch) []db.Service {
+	byService := make(map[int32]db.ServiceBranch, len(overrides))
+	for _, override := range overrides {
+		byService[override.ServiceID] = override
+	}
+
+	res := make([]db.Service, 0, len(services))
+	for _, service := range services {
+		override, ok := byService[service.ServiceID]
+		if ok && !override.IsAvailable {
+			continue
+		}
+		if ok && override.Price.Valid {
+			service.ServicePrice = override.Price.Int64
+		}
+		res = append(res, service)
+	}
+	return res
+}
+
+// buildCatalog arranges categories sorted by display order into a tree. A category that is
+// inactive hides everything below it, services in hidden categories are left out.
+func buildCatalog(categories []db.Category, services []db.Service, variants []db.ServiceVariant) catalogResponse {
+	nodes := make(map[int32]*catalogCategory)
+	for _, category := range categories {
+		if category.IsActive {
+			nodes[category.CategoryID] = &catalogCategory{
+				CategoryID: category.CategoryID,
+				Name:       category.Name,
+				Icon:       category.Icon,
+				Services:   []catalogService{},
+				Children:   []*catalogCategory{},
+			}
+		}
+	}
+
+	// Categories under an inactive parent are attached to nothing and so never reached from the roots
+	catalog := catalogResponse{Categories: []*catalogCategory{}, Uncategorized: []catalogService{}}
+	for _, category := range categories {
+		node, ok := nodes[category.CategoryID]
+		if !ok {
+			continue
+		}
+		if !category.ParentID.Valid {
+			catalog.Categories = append(catalog.Categories, node)
+		} else if parent, ok := nodes[category.ParentID.Int32]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	serviceVariants := make(map[int32][]db.ServiceVariant)
+	for _, variant := range variants {
+		serviceVariants[variant.ServiceID] = append(serviceVariants[variant.ServiceID], variant)
+	}
+
+	for _, service := range services {
+		item := catalogService{Service: service, Variants: serviceVariants[service.ServiceID]}
+		if item.Variants == nil {
+			item.Variants = []db.ServiceVariant{}
+		}
+		if !service.CategoryID.Valid {
+			catalog.Uncategorized = append(catalog.Uncategorized, item)
+		} else if node, ok := nodes[service.CategoryID.Int32]; ok {
+			node.Services = append(node.Services, item)
+		}
+	}
+	return catalog
+}
+
+// catalogBundles keeps the active bundles whose services can all be ordered
+func catalogBundles(bundles []db.Bundle, items []db.BundleItem, services []db.Service) []catalogBundle {
+	prices := make(map[int32]int64, len(services))
+	for _, service := range services {
+		prices[service.ServiceID] = service.ServicePrice
+	}
+
+	bundleItems := groupBundleItems(items)
+	res := []catalogBundle{}
+	for _, bundle := range bundles {
+		if !bundle.IsActive || len(bundleItems[bundle.BundleID]) == 0 {
+			continue
+		}
+
+		entry := catalogBundle{Bundle: bundle, Items: bundleItems[bundle.BundleID]}
+		orderable := true
+		for _, item := range entry.Items {
+			price, ok := prices[item.ServiceID]
+			if !ok {
+				orderable = false
+				break
+			}
+			entry.ListPrice += price * int64(item.Quantity)
+		}
+		if orderable {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
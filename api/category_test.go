@@ -0,0 +1,120 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func testCategory(id int32, parentID int32, active bool) db.Category {
+	return db.Category{
+		CategoryID: id,
+		ParentID:   sql.NullInt32{Int32: parentID, Valid: parentID != 0},
+		Name:       "category",
+		IsActive:   active,
+	}
+}
+
+func testCatalogService(id int32, categoryID int32) db.Service {
+	return db.Service{
+		ServiceID:  id,
+		CategoryID: sql.NullInt32{Int32: categoryID, Valid: categoryID != 0},
+	}
+}
+
+func TestIsDescendant(t *testing.T) {
+	// 1 -> 2 -> 3 and 4 on its own
+	categories := []db.Category{
+		testCategory(1, 0, true),
+		testCategory(2, 1, true),
+		testCategory(3, 2, true),
+		testCategory(4, 0, true),
+	}
+
+	require.True(t, isDescendant(categories, 3, 1))
+	require.True(t, isDescendant(categories, 2, 1))
+	require.True(t, isDescendant(categories, 1, 1))
+	require.False(t, isDescendant(categories, 1, 3))
+	require.False(t, isDescendant(categories, 4, 1))
+	// A category that is not stored has no ancestors
+	require.False(t, isDescendant(categories, 9, 1))
+}
+
+func TestIsDescendantCycle(t *testing.T) {
+	// 1 -> 2 -> 3 -> 1 is already broken, the walk must end and refuse the move
+	categories := []db.Category{
+		testCategory(1, 3, true),
+		testCategory(2, 1, true),
+		testCategory(3, 2, true),
+		testCategory(4, 0, true),
+	}
+
+	require.True(t, isDescendant(categories, 1, 2))
+	require.True(t, isDescendant(categories, 3, 4))
+	require.False(t, isDescendant(categories, 4, 1))
+}
+
+func TestBuildCatalog(t *testing.T) {
+	categories := []db.Category{
+		testCategory(1, 0, true),
+		testCategory(2, 1, true),
+		// 3 is inactive, so 4 below it is hidden even though it is active
+		testCategory(3, 1, false),
+		testCategory(4, 3, true),
+		testCategory(5, 0, true),
+	}
+	services := []db.Service{
+		testCatalogService(10, 1),
+		testCatalogService(11, 2),
+		testCatalogService(12, 4),
+		testCatalogService(13, 0),
+	}
+	variants := []db.ServiceVariant{
+		{VariantID: 100, ServiceID: 11},
+	}
+
+	catalog := buildCatalog(categories, services, variants)
+
+	require.Len(t, catalog.Categories, 2)
+	root := catalog.Categories[0]
+	require.Equal(t, int32(1), root.CategoryID)
+	require.Len(t, root.Services, 1)
+	require.Equal(t, int32(10), root.Services[0].ServiceID)
+	require.Empty(t, root.Services[0].Variants)
+	require.NotNil(t, root.Services[0].Variants)
+
+	require.Len(t, root.Children, 1)
+	child := root.Children[0]
+	require.Equal(t, int32(2), child.CategoryID)
+	require.Len(t, child.Services, 1)
+	require.Len(t, child.Services[0].Variants, 1)
+
+	require.Equal(t, int32(5), catalog.Categories[1].CategoryID)
+	require.Empty(t, catalog.Categories[1].Services)
+	require.Empty(t, catalog.Categories[1].Children)
+
+	// Services of hidden categories are left out rather than shown as uncategorized
+	require.Len(t, catalog.Uncategorized, 1)
+	require.Equal(t, int32(13), catalog.Uncategorized[0].ServiceID)
+}
+
+func TestBuildCatalogCycle(t *testing.T) {
+	// 2 and 3 are each other's parent, so they cannot be reached from a root
+	categories := []db.Category{
+		testCategory(1, 0, true),
+		testCategory(2, 3, true),
+		testCategory(3, 2, true),
+	}
+	services := []db.Service{
+		testCatalogService(10, 2),
+	}
+
+	catalog := buildCatalog(categories, services, nil)
+
+	require.Len(t, catalog.Categories, 1)
+	require.Equal(t, int32(1), catalog.Categories[0].CategoryID)
+	require.Empty(t, catalog.Categories[0].Children)
+	require.Empty(t, catalog.Uncategorized)
+}
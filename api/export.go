@@ -0,0 +1,452 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/export"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+// exportBatchSize is how many rows are read from the database at a time while exporting
+const exportBatchSize = 1000
+
+// exportTimeLayout is how times are written in exports
+const exportTimeLayout = "2006-01-02 15:04:05"
+
+// exportFilters are the filters of the matching list endpoint, stored with background exports
+type exportFilters struct {
+	// SLAState only applies to orders
+	SLAState string `json:"sla_state,omitempty" form:"sla_state" binding:"omitempty,oneof=on_track at_risk overdue"`
+	// BranchID applies to orders and customers
+	BranchID *int32 `json:"-" form:"branch_id" binding:"omitempty,min=1"`
+	// BranchIDs are the branches the export is limited to, resolved from BranchID and the branches of the admin
+	BranchIDs []int32 `json:"branch_ids,omitempty" form:"-"`
+}
+
+type exportRequest struct {
+	exportFilters
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+}
+
+func (server *Server) exportOrders(ctx *gin.Context) {
+	server.startExport(ctx, util.ExportOrders)
+}
+
+func (server *Server) exportUsers(ctx *gin.Context) {
+	server.startExport(ctx, util.ExportUsers)
+}
+
+func (server *Server) exportServices(ctx *gin.Context) {
+	server.startExport(ctx, util.ExportServices)
+}
+
+// startExport streams small exports straight to the response. Exports with more rows than
+// EXPORT_ASYNC_THRESHOLD run in the background and are kept privately in object storage, the
+// response is the job to poll until it can be downloaded.
+// Orders and customers are limited to the branches of the admin, like their list endpoints.
+func (server *Server) startExport(ctx *gin.Context, kind string) {
+	var req exportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Format == "" {
+		req.Format = export.FormatCSV
+	}
+
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if kind == util.ExportOrders || kind == util.ExportUsers {
+		branchIDs, ok := server.orderBranches(ctx, req.BranchID)
+		if !ok {
+			return
+		}
+		req.BranchIDs = branchIDs
+	}
+
+	count, err := server.countExportRows(ctx, kind, req.exportFilters)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if count > server.config.ExportAsyncThreshold {
+		server.createExportJob(ctx, admin, kind, req, count)
+		return
+	}
+
+	ctx.Header("Content-Type", export.ContentType(req.Format))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFileName(kind, req.Format, time.Now())))
+	ctx.Status(http.StatusOK)
+
+	w, err := export.NewWriter(req.Format, ctx.Writer)
+	if err == nil {
+		err = server.writeExport(ctx, kind, req.exportFilters, w)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		// The headers are already sent, all that is left is to cut the download short
+		log.Printf("cannot export %s: %v", kind, err)
+		ctx.Abort()
+	}
+}
+
+func (server *Server) createExportJob(ctx *gin.Context, admin db.Admin, kind string, req exportRequest, count int64) {
+	filters, err := json.Marshal(req.exportFilters)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	job, err := server.store.CreateExportJob(ctx, db.CreateExportJobParams{
+		Kind:        kind,
+		Format:      req.Format,
+		Filters:     filters,
+		RowCount:    count,
+		RequestedBy: admin.AdminID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The job outlives the request, so it must not use the request context
+	go server.runExportJob(context.Background(), job, req.exportFilters)
+
+	ctx.JSON(http.StatusAccepted, newExportJobResponse(job))
+}
+
+type exportJobResponse struct {
+	db.ExportJob
+	// DownloadURL downloads the finished export through the API, exports are never public
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+func newExportJobResponse(job db.ExportJob) exportJobResponse {
+	res := exportJobResponse{ExportJob: job}
+	if job.Status == util.ExportDone && job.FileKey != "" {
+		res.DownloadURL = fmt.Sprintf("/exports/%d/download", job.JobID)
+	}
+	return res
+}
+
+// runExportJob streams the export into private object storage while it is being written
+func (server *Server) runExportJob(ctx context.Context, job db.ExportJob, filters exportFilters) {
+	started, err := server.store.StartExportJob(ctx, job.JobID)
+	if err != nil {
+		log.Printf("cannot start export job %d: %v", job.JobID, err)
+		return
+	}
+	job = started
+
+	pr, pw := io.Pipe()
+	go func() {
+		w, err := export.NewWriter(job.Format, pw)
+		if err == nil {
+			err = server.writeExport(ctx, job.Kind, filters, w)
+		}
+		if err == nil {
+			err = w.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	key := fmt.Sprintf("%sexports/%d/%s", privatePrefix, job.JobID, exportFileName(job.Kind, job.Format, job.CreatedAt))
+	err = server.storage.Put(ctx, key, export.ContentType(job.Format), pr)
+	// Unblock the writer in case the upload gave up before reading everything
+	pr.CloseWithError(err)
+
+	arg := db.FinishExportJobParams{
+		JobID:   job.JobID,
+		Status:  util.ExportDone,
+		FileKey: key,
+	}
+	if err != nil {
+		log.Printf("export job %d failed: %v", job.JobID, err)
+		arg.Status = util.ExportFailed
+		arg.FileKey = ""
+		arg.Error = err.Error()
+	}
+	if _, err := server.store.FinishExportJob(ctx, arg); err != nil {
+		log.Printf("cannot finish export job %d: %v", job.JobID, err)
+	}
+}
+
+func exportFileName(kind, format string, at time.Time) string {
+	return fmt.Sprintf("%s-%s.%s", kind, at.Format("20060102-150405"), format)
+}
+
+func (server *Server) countExportRows(ctx context.Context, kind string, filters exportFilters) (int64, error) {
+	switch kind {
+	case util.ExportOrders:
+		return server.store.CountOrderExportRows(ctx, db.CountOrderExportRowsParams{
+			SlaState:  sql.NullString{String: filters.SLAState, Valid: filters.SLAState != ""},
+			BranchIds: filters.BranchIDs,
+		})
+	case util.ExportUsers:
+		return server.store.CountUsers(ctx, filters.BranchIDs)
+	case util.ExportServices:
+		return server.store.CountServices(ctx)
+	default:
+		return 0, fmt.Errorf("unknown export %q", kind)
+	}
+}
+
+// writeExport writes the header and every row of an export, reading the rows in batches
+func (server *Server) writeExport(ctx context.Context, kind string, filters exportFilters, w export.Writer) error {
+	switch kind {
+	case util.ExportOrders:
+		return server.writeOrderExport(ctx, filters, w)
+	case util.ExportUsers:
+		return server.writeUserExport(ctx, filters, w)
+	case util.ExportServices:
+		return server.writeServiceExport(ctx, w)
+	default:
+		return fmt.Errorf("unknown export %q", kind)
+	}
+}
+
+// writeOrderExport writes one row per service of every order, with the customer and service details
+func (server *Server) writeOrderExport(ctx context.Context, filters exportFilters, w export.Writer) error {
+	err := w.Write([]string{
+		"order_id", "order_status", "order_started", "order_delivered", "order_delivery_time", "due_at", "sla_state",
+		"branch_id", "customer_id", "customer_name", "customer_email", "customer_phone",
+		"service_id", "service_name", "service_price",
+	})
+	if err != nil {
+		return err
+	}
+
+	arg := db.ListOrderExportRowsParams{
+		SlaState:  sql.NullString{String: filters.SLAState, Valid: filters.SLAState != ""},
+		BranchIds: filters.BranchIDs,
+		Limit:     exportBatchSize,
+	}
+	for {
+		rows, err := server.store.ListOrderExportRows(ctx, arg)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			dueAt := ""
+			if row.DueAt.Valid {
+				dueAt = server.exportTime(row.DueAt.Time)
+			}
+			err := w.Write([]string{
+				strconv.FormatInt(row.OrderID, 10),
+				row.OrderStatus,
+				server.exportTime(row.OrderStarted),
+				strconv.FormatBool(row.OrderDelivered),
+				server.exportTime(row.OrderDeliveryTime),
+				dueAt,
+				row.SlaState,
+				strconv.Itoa(int(row.BranchID)),
+				strconv.Itoa(int(row.UserID)),
+				row.CustomerName,
+				row.CustomerEmail,
+				row.CustomerPhone,
+				strconv.Itoa(int(row.ServiceID)),
+				row.ServiceName,
+				strconv.FormatInt(row.ServicePrice, 10),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(rows) < exportBatchSize {
+			return nil
+		}
+		arg.AfterID = rows[len(rows)-1].ID
+	}
+}
+
+func (server *Server) writeUserExport(ctx context.Context, filters exportFilters, w export.Writer) error {
+	err := w.Write([]string{"user_id", "name", "email", "phone", "address", "total_orders", "created_at"})
+	if err != nil {
+		return err
+	}
+
+	arg := db.ListUsersAfterParams{
+		BranchIds: filters.BranchIDs,
+		Limit:     exportBatchSize,
+	}
+	for {
+		users, err := server.store.ListUsersAfter(ctx, arg)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			err := w.Write([]string{
+				strconv.Itoa(int(user.UserID)),
+				user.Name,
+				user.Email,
+				user.Phone,
+				user.Address,
+				strconv.Itoa(int(user.TotalOrders)),
+				server.exportTime(user.CreatedAt),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(users) < exportBatchSize {
+			return nil
+		}
+		arg.UserID = users[len(users)-1].UserID
+	}
+}
+
+func (server *Server) writeServiceExport(ctx context.Context, w export.Writer) error {
+	err := w.Write([]string{"service_id", "service_name", "service_price", "turnaround_hours", "service_image"})
+	if err != nil {
+		return err
+	}
+
+	arg := db.ListServicesAfterParams{Limit: exportBatchSize}
+	for {
+		services, err := server.store.ListServicesAfter(ctx, arg)
+		if err != nil {
+			return err
+		}
+		for _, service := range services {
+			err := w.Write([]string{
+				strconv.Itoa(int(service.ServiceID)),
+				service.ServiceName,
+				strconv.FormatInt(service.ServicePrice, 10),
+				strconv.Itoa(int(service.TurnaroundHours)),
+				service.ServiceImage,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(services) < exportBatchSize {
+			return nil
+		}
+		arg.ServiceID = services[len(services)-1].ServiceID
+	}
+}
+
+// exportTime writes times in the server time zone, which is what accounting works in
+func (server *Server) exportTime(t time.Time) string {
+	return t.In(server.location).Format(exportTimeLayout)
+}
+
+type exportJobURI struct {
+	JobID int64 `uri:"job_id" binding:"required,min=1"`
+}
+
+// exportJob loads the job in the URI. Only the admin that requested it and staff that are not
+// limited to branches can see it. It writes the error response and returns false otherwise.
+func (server *Server) exportJob(ctx *gin.Context) (db.ExportJob, bool) {
+	var uri exportJobURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.ExportJob{}, false
+	}
+
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return db.ExportJob{}, false
+	}
+
+	job, err := server.store.GetExportJob(ctx, uri.JobID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return db.ExportJob{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.ExportJob{}, false
+	}
+
+	if job.RequestedBy != admin.AdminID && !server.requireHeadOffice(ctx) {
+		return db.ExportJob{}, false
+	}
+	return job, true
+}
+
+func (server *Server) getExportJob(ctx *gin.Context) {
+	job, ok := server.exportJob(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, newExportJobResponse(job))
+}
+
+// downloadExportJob downloads a finished export
+func (server *Server) downloadExportJob(ctx *gin.Context) {
+	job, ok := server.exportJob(ctx)
+	if !ok {
+		return
+	}
+
+	if job.Status != util.ExportDone || job.FileKey == "" {
+		err := errors.New("export is not ready to download, run it again if it failed")
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+	server.servePrivate(ctx, job.FileKey, export.ContentType(job.Format), exportFileName(job.Kind, job.Format, job.CreatedAt))
+}
+
+type listExportJobsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+// listExportJobs lists the exports of the admin, staff that are not limited to branches see every export
+func (server *Server) listExportJobs(ctx *gin.Context) {
+	var req listExportJobsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	scope, err := server.store.ListAdminBranchIDs(ctx, admin.AdminID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	var requestedBy sql.NullInt32
+	if len(scope) > 0 {
+		requestedBy = sql.NullInt32{Int32: admin.AdminID, Valid: true}
+	}
+
+	jobs, err := server.store.ListExportJobs(ctx, db.ListExportJobsParams{
+		RequestedBy: requestedBy,
+		Limit:       req.PageSize,
+		Offset:      (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := make([]exportJobResponse, len(jobs))
+	for i, job := range jobs {
+		response[i] = newExportJobResponse(job)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
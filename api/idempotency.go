@@ -0,0 +1,201 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/token"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// idempotencyCleanupInterval is how often keys older than IDEMPOTENCY_KEY_TTL are deleted
+	idempotencyCleanupInterval = time.Hour
+)
+
+// responseRecorder keeps a copy of everything written to the client so it can be replayed
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// idempotencyMiddleWare makes mutating requests that carry an Idempotency-Key header safe to retry.
+// The first request with a key is processed normally and its response is stored; a retry with the
+// same key and body gets the stored response back. Reusing a key with a different body is rejected.
+// Keys expire after ttl and can then be used again, a ttl of zero keeps them forever.
+// It has to run after the auth middleware because keys are scoped to the caller.
+// Bodies are read into memory to be hashed, so those larger than maxBodySize are rejected.
+func idempotencyMiddleWare(store *db.Store, ttl time.Duration, maxBodySize int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(idempotencyKeyHeader)
+		if key == "" || !isMutatingMethod(ctx.Request.Method) {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			err := errors.New("idempotency key is too long")
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		scope := fmt.Sprintf("%s:%d %s %s", authPayload.AccountKind, authPayload.AccountID, ctx.Request.Method, ctx.Request.URL.Path)
+		hash := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(hash[:])
+
+		var expiredBefore time.Time
+		if ttl > 0 {
+			expiredBefore = time.Now().Add(-ttl)
+		}
+		record, err := store.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+			IdempotencyKey: key,
+			Scope:          scope,
+			RequestHash:    requestHash,
+			ExpiredBefore:  expiredBefore,
+		})
+		if err == sql.ErrNoRows {
+			// The key was used before and has not expired yet
+			replayIdempotentResponse(ctx, store, scope, key, requestHash)
+			return
+		}
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		// A panicking handler never stores a response, so the key is released
+		// before the recovery middleware turns the panic into a server error
+		defer func() {
+			if r := recover(); r != nil {
+				releaseIdempotencyKey(store, record)
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Next()
+
+		// Server errors are not stored so the client can retry with the same key
+		if recorder.Status() >= http.StatusInternalServerError {
+			releaseIdempotencyKey(store, record)
+			return
+		}
+
+		// The response is already sent, so the save outlives a client that went away meanwhile
+		err = store.SaveIdempotencyResponse(context.Background(), db.SaveIdempotencyResponseParams{
+			ID:             record.ID,
+			ResponseStatus: sql.NullInt32{Int32: int32(recorder.Status()), Valid: true},
+			ResponseBody:   recorder.body.Bytes(),
+			ContentType:    recorder.Header().Get("Content-Type"),
+		})
+		if err != nil {
+			log.Printf("cannot save response for idempotency key %q: %v", key, err)
+		}
+	}
+}
+
+// releaseIdempotencyKey deletes a key whose request did not complete so the client can retry with it.
+// It does not use the request context, which is already canceled when the client went away.
+func releaseIdempotencyKey(store *db.Store, record db.IdempotencyKey) {
+	if err := store.DeleteIdempotencyKey(context.Background(), record.ID); err != nil {
+		log.Printf("cannot release idempotency key %q: %v", record.IdempotencyKey, err)
+	}
+}
+
+func replayIdempotentResponse(ctx *gin.Context, store *db.Store, scope, key, requestHash string) {
+	record, err := store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Scope:          scope,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if record.RequestHash != requestHash {
+		err := errors.New("idempotency key was already used with a different request body")
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	if !record.ResponseStatus.Valid {
+		err := errors.New("a request with this idempotency key is still being processed")
+		ctx.AbortWithStatusJSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	// Responses stored before the content type was recorded were all JSON
+	contentType := record.ContentType
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+	}
+
+	ctx.Header(idempotentReplayedHeader, "true")
+	ctx.Data(int(record.ResponseStatus.Int32), contentType, record.ResponseBody)
+	ctx.Abort()
+}
+
+// RunIdempotencyKeyCleaner deletes idempotency keys older than IDEMPOTENCY_KEY_TTL until ctx is done
+func (server *Server) RunIdempotencyKeyCleaner(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := server.store.DeleteExpiredIdempotencyKeys(ctx, time.Now().Add(-server.config.IdempotencyKeyTTL))
+		if err != nil {
+			log.Printf("cannot delete expired idempotency keys: %v", err)
+		} else if deleted > 0 {
+			log.Printf("deleted %d expired idempotency keys", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/token"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+// testMaxBodySize is the largest body the test router accepts
+const testMaxBodySize = 1 << 10
+
+// newIdempotencyRouter serves POST /test behind the idempotency middleware for a random caller,
+// the handler runs the given function and counts how often it was called
+func newIdempotencyRouter(t *testing.T, handler func(ctx *gin.Context)) (*gin.Engine, *int) {
+	payload, err := token.NewPayload(util.RandomEmail(), token.AccountUser, int32(util.RandomOrder()), time.Minute)
+	require.NoError(t, err)
+
+	calls := 0
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.POST("/test", func(ctx *gin.Context) {
+		ctx.Set(authorizationPayloadKey, payload)
+	}, idempotencyMiddleWare(testStore, time.Hour, testMaxBodySize), func(ctx *gin.Context) {
+		calls++
+		handler(ctx)
+	})
+	return router, &calls
+}
+
+func sendIdempotent(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	request.Header.Set(idempotencyKeyHeader, key)
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	router, calls := newIdempotencyRouter(t, func(ctx *gin.Context) {
+		ctx.String(http.StatusCreated, "created")
+	})
+	key := util.RandomString(16)
+
+	first := sendIdempotent(router, key, `{"a":1}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Empty(t, first.Header().Get(idempotentReplayedHeader))
+
+	// The retry gets the stored response, including its content type, without running the handler
+	second := sendIdempotent(router, key, `{"a":1}`)
+	require.Equal(t, http.StatusCreated, second.Code)
+	require.Equal(t, "true", second.Header().Get(idempotentReplayedHeader))
+	require.Equal(t, first.Body.String(), second.Body.String())
+	require.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+	require.Equal(t, 1, *calls)
+}
+
+func TestIdempotencyDifferentBody(t *testing.T) {
+	router, calls := newIdempotencyRouter(t, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	key := util.RandomString(16)
+
+	require.Equal(t, http.StatusOK, sendIdempotent(router, key, `{"a":1}`).Code)
+	require.Equal(t, http.StatusUnprocessableEntity, sendIdempotent(router, key, `{"a":2}`).Code)
+	require.Equal(t, 1, *calls)
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	router, calls := newIdempotencyRouter(t, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	key := util.RandomString(maxIdempotencyKeyLength + 1)
+	require.Equal(t, http.StatusBadRequest, sendIdempotent(router, key, `{}`).Code)
+	require.Zero(t, *calls)
+}
+
+func TestIdempotencyServerErrorReleasesKey(t *testing.T) {
+	router, calls := newIdempotencyRouter(t, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+	})
+	key := util.RandomString(16)
+
+	require.Equal(t, http.StatusInternalServerError, sendIdempotent(router, key, `{}`).Code)
+	require.Equal(t, http.StatusInternalServerError, sendIdempotent(router, key, `{}`).Code)
+	require.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyPanicReleasesKey(t *testing.T) {
+	router, calls := newIdempotencyRouter(t, func(ctx *gin.Context) {
+		panic("handler failed")
+	})
+	key := util.RandomString(16)
+
+	// Without releasing the key the retry would be rejected as still being processed
+	require.Equal(t, http.StatusInternalServerError, sendIdempotent(router, key, `{}`).Code)
+	require.Equal(t, http.StatusInternalServerError, sendIdempotent(router, key, `{}`).Code)
+	require.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyExpiredKey(t *testing.T) {
+	router, calls := newIdempotencyRouter(t, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	key := util.RandomString(16)
+
+	require.Equal(t, http.StatusOK, sendIdempotent(router, key, `{"a":1}`).Code)
+
+	_, err := testDB.ExecContext(context.Background(),
+		"UPDATE idempotency_keys SET created_at = now() - interval '2 hours' WHERE idempotency_key = $1", key)
+	require.NoError(t, err)
+
+	// Once expired the key starts over, even with another body
+	response := sendIdempotent(router, key, `{"a":2}`)
+	require.Equal(t, http.StatusOK, response.Code)
+	require.Empty(t, response.Header().Get(idempotentReplayedHeader))
+	require.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyBodyTooLarge(t *testing.T) {
+	router, calls := newIdempotencyRouter(t, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	body := strings.Repeat("a", testMaxBodySize+1)
+	require.Equal(t, http.StatusRequestEntityTooLarge, sendIdempotent(router, util.RandomString(16), body).Code)
+	require.Zero(t, *calls)
+}
@@ -0,0 +1,361 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+const (
+	maxImportSize = 5 << 20
+	maxImportRows = 5000
+)
+
+type importRequest struct {
+	// DryRun validates the file and reports what would change without saving anything
+	DryRun bool `form:"dry_run"`
+}
+
+type importRowResponse struct {
+	// Row is the line of the row in the file, the header being line 1
+	Row    int    `json:"row"`
+	Key    string `json:"key"`
+	Action string `json:"action,omitempty"`
+	ID     int32  `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type importResponse struct {
+	DryRun  bool                `json:"dry_run"`
+	Created int                 `json:"created"`
+	Updated int                 `json:"updated"`
+	Rows    []importRowResponse `json:"rows"`
+}
+
+// importServiceRequest holds the same rules as createServiceRequest for a CSV row.
+// The image is given as a URL, an empty one keeps the image of an existing service.
+type importServiceRequest struct {
+	ServiceName     string `binding:"required"`
+	ServicePrice    int64  `binding:"required"`
+	ServiceImage    string `binding:"omitempty,url"`
+	TurnaroundHours int32  `binding:"min=1"`
+}
+
+// csvTable is an uploaded CSV file with its columns looked up by the header row
+type csvTable struct {
+	columns map[string]int
+	rows    []csvRow
+}
+
+type csvRow struct {
+	line   int
+	fields []string
+	// err is set when the row cannot be read, such as when it has the wrong number of fields
+	err error
+}
+
+func (t csvTable) value(row csvRow, column string) string {
+	i, ok := t.columns[column]
+	if !ok || i >= len(row.fields) {
+		return ""
+	}
+	return strings.TrimSpace(row.fields[i])
+}
+
+// readImportFile reads the CSV in the file form field and checks it has the required columns.
+// It writes the error response and returns false when the file cannot be imported.
+func readImportFile(ctx *gin.Context, required []string) (csvTable, bool) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return csvTable{}, false
+	}
+	if fileHeader.Size > maxImportSize {
+		err := fmt.Errorf("file cannot be larger than %d MB", maxImportSize>>20)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return csvTable{}, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return csvTable{}, false
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "cannot read the header row: " + err.Error()})
+		return csvTable{}, false
+	}
+
+	table := csvTable{columns: make(map[string]int)}
+	for i, column := range header {
+		// Spreadsheets often save CSV files with a byte order mark
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		table.columns[column] = i
+	}
+	for _, column := range required {
+		if _, ok := table.columns[column]; !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("missing column %q", column)})
+			return csvTable{}, false
+		}
+	}
+
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var row csvRow
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			// A malformed row is reported with the others instead of failing the whole file
+			row = csvRow{line: parseErr.StartLine, err: parseErr.Err}
+		} else if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return csvTable{}, false
+		} else {
+			row.line, _ = reader.FieldPos(0)
+			row.fields = fields
+			if len(fields) != len(header) {
+				row.err = fmt.Errorf("row has %d fields, the header has %d", len(fields), len(header))
+			}
+		}
+		table.rows = append(table.rows, row)
+
+		if len(table.rows) > maxImportRows {
+			err := fmt.Errorf("file cannot have more than %d rows", maxImportRows)
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return csvTable{}, false
+		}
+	}
+
+	if len(table.rows) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "file has no rows to import"})
+		return csvTable{}, false
+	}
+	return table, true
+}
+
+// importUsers creates customers from a CSV file with name, email, phone, address and password
+// columns, or updates the customer with the same email. The file is only imported when every
+// row is valid, otherwise the response lists the errors of each row.
+// Customers of any branch can be updated, so only staff that are not limited to branches can import.
+func (server *Server) importUsers(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var req importRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	table, ok := readImportFile(ctx, []string{"name", "email", "phone", "address", "password"})
+	if !ok {
+		return
+	}
+
+	rows := make([]importRowResponse, len(table.rows))
+	users := make([]createUserRequest, len(table.rows))
+	seen := make(map[string]int)
+	valid := true
+	for i, record := range table.rows {
+		users[i] = createUserRequest{
+			Name:     table.value(record, "name"),
+			Email:    table.value(record, "email"),
+			Phone:    table.value(record, "phone"),
+			Address:  table.value(record, "address"),
+			Password: table.value(record, "password"),
+		}
+		rows[i] = importRowResponse{Row: record.line, Key: users[i].Email}
+
+		err := record.err
+		if err == nil {
+			err = binding.Validator.ValidateStruct(&users[i])
+		}
+		if line, exists := seen[users[i].Email]; err == nil && exists {
+			err = fmt.Errorf("email is already used on row %d", line)
+		}
+		if err != nil {
+			rows[i].Error = err.Error()
+			valid = false
+			continue
+		}
+		seen[users[i].Email] = record.line
+	}
+	if !valid {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "some rows are invalid, nothing was imported", "rows": rows})
+		return
+	}
+
+	arg := db.ImportUsersTxParams{
+		Users:  make([]db.UpsertUserParams, len(users)),
+		DryRun: req.DryRun,
+	}
+	for i, user := range users {
+		arg.Users[i] = db.UpsertUserParams{
+			Name:    user.Name,
+			Email:   user.Email,
+			Phone:   user.Phone,
+			Address: user.Address,
+		}
+		// Hashing is slow and a dry run saves nothing
+		if !req.DryRun {
+			hashedPassword, err := util.HashPassword(user.Password)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			arg.Users[i].HashedPassword = hashedPassword
+		}
+	}
+
+	results, err := server.store.ImportUsersTx(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newImportResponse(req.DryRun, rows, results))
+}
+
+// importServices creates services from a CSV file with service_name and service_price columns
+// and optional turnaround_hours and service_image columns, or updates the service with the same
+// name. The file is only imported when every row is valid. Services are shared by every branch,
+// so only staff that are not limited to branches can import them.
+func (server *Server) importServices(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var req importRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	table, ok := readImportFile(ctx, []string{"service_name", "service_price"})
+	if !ok {
+		return
+	}
+
+	rows := make([]importRowResponse, len(table.rows))
+	services := make([]db.UpsertServiceParams, len(table.rows))
+	seen := make(map[string]int)
+	valid := true
+	for i, record := range table.rows {
+		service := importServiceRequest{
+			ServiceName:     table.value(record, "service_name"),
+			ServiceImage:    table.value(record, "service_image"),
+			TurnaroundHours: util.DefaultTurnaroundHours,
+		}
+		rows[i] = importRowResponse{Row: record.line, Key: service.ServiceName}
+
+		err := record.err
+		if err == nil {
+			service.ServicePrice, err = parseImportInt(table.value(record, "service_price"), "service_price", 64)
+		}
+		if turnaround := table.value(record, "turnaround_hours"); err == nil && turnaround != "" {
+			var hours int64
+			hours, err = parseImportInt(turnaround, "turnaround_hours", 32)
+			service.TurnaroundHours = int32(hours)
+		}
+		if err == nil {
+			err = binding.Validator.ValidateStruct(&service)
+		}
+		if line, exists := seen[service.ServiceName]; err == nil && exists {
+			err = fmt.Errorf("service_name is already used on row %d", line)
+		}
+		if err != nil {
+			rows[i].Error = err.Error()
+			valid = false
+			continue
+		}
+		seen[service.ServiceName] = record.line
+
+		services[i] = db.UpsertServiceParams{
+			ServiceName:     service.ServiceName,
+			ServicePrice:    service.ServicePrice,
+			ServiceImage:    service.ServiceImage,
+			TurnaroundHours: service.TurnaroundHours,
+		}
+	}
+	if !valid {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "some rows are invalid, nothing was imported", "rows": rows})
+		return
+	}
+
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	results, err := server.store.ImportServicesTx(ctx, db.ImportServicesTxParams{
+		Services:   services,
+		DryRun:     req.DryRun,
+		ImportedBy: sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Imported images kept in the storage get variants like uploaded ones
+	if !req.DryRun {
+		for _, result := range results {
+			service, err := server.store.GetService(ctx, result.ID)
+			if err != nil {
+				log.Printf("cannot load imported service %d: %v", result.ID, err)
+				continue
+			}
+			server.queueServiceImage(ctx, service)
+		}
+	}
+	ctx.JSON(http.StatusOK, newImportResponse(req.DryRun, rows, results))
+}
+
+func parseImportInt(value, column string, bitSize int) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, bitSize)
+	if err != nil {
+		return 0, errors.New(column + " must be a whole number")
+	}
+	return n, nil
+}
+
+func newImportResponse(dryRun bool, rows []importRowResponse, results []db.ImportRowResult) importResponse {
+	res := importResponse{DryRun: dryRun, Rows: rows}
+	for i, result := range results {
+		res.Rows[i].Action = result.Action
+		// Ids of a dry run were rolled back
+		if !dryRun {
+			res.Rows[i].ID = result.ID
+		}
+		if result.Action == util.ImportCreated {
+			res.Created++
+		} else {
+			res.Updated++
+		}
+	}
+	return res
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/token"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+// sendImport posts content as the file of an import request by the given admin
+func sendImport(t *testing.T, server *Server, url string, admin db.Admin, content string) *httptest.ResponseRecorder {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "import.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	request := httptest.NewRequest(http.MethodPost, url, body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	addAuthorization(t, request, server, admin.Email, token.AccountAdmin, admin.AdminID)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+type importErrorResponse struct {
+	Error string              `json:"error"`
+	Rows  []importRowResponse `json:"rows"`
+}
+
+func TestImportUsersMissingColumn(t *testing.T) {
+	server := newTestServer(t)
+	admin := createTestAdmin(t)
+
+	recorder := sendImport(t, server, "/imports/users", admin, "name,email,phone,address\nJohn,john@example.com,123,Street\n")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `missing column \"password\"`)
+}
+
+func TestImportUsersEmptyFile(t *testing.T) {
+	server := newTestServer(t)
+	admin := createTestAdmin(t)
+
+	recorder := sendImport(t, server, "/imports/users", admin, "name,email,phone,address,password\n")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestImportUsersRowErrors(t *testing.T) {
+	server := newTestServer(t)
+	admin := createTestAdmin(t)
+
+	// The byte order mark and the column order of spreadsheet exports are accepted
+	content := "\ufeffEmail,Name,Phone,Address,Password\n" +
+		"a@example.com,Ann,123,Street 1,secret123\n" +
+		"not-an-email,Bob,123,Street 2,secret123\n" +
+		"a@example.com,Ann again,123,Street 3,secret123\n" +
+		"c@example.com,Cid,123,Street 4\n" +
+		"d@example.com,Dan,123,Street 5,short\n" +
+		"e@example.com,\"Eve,123,Street 6,secret123\n"
+
+	recorder := sendImport(t, server, "/imports/users", admin, content)
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	var res importErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	require.Len(t, res.Rows, 6)
+
+	require.Equal(t, 2, res.Rows[0].Row)
+	require.Empty(t, res.Rows[0].Error)
+	require.Equal(t, "a@example.com", res.Rows[0].Key)
+	require.Zero(t, res.Rows[0].ID)
+
+	require.Equal(t, 3, res.Rows[1].Row)
+	require.Contains(t, res.Rows[1].Error, "Email")
+	require.Equal(t, "email is already used on row 2", res.Rows[2].Error)
+	require.Equal(t, "row has 4 fields, the header has 5", res.Rows[3].Error)
+	require.Contains(t, res.Rows[4].Error, "Password")
+	// The unterminated quote runs to the end of the file
+	require.Equal(t, 7, res.Rows[5].Row)
+	require.NotEmpty(t, res.Rows[5].Error)
+}
+
+func TestImportServicesRowErrors(t *testing.T) {
+	server := newTestServer(t)
+	admin := createTestAdmin(t)
+
+	content := "service_name,service_price,turnaround_hours,service_image\n" +
+		"Wash,100,24,\n" +
+		"Iron,cheap,,\n" +
+		"Dry clean,300,0,\n" +
+		",50,,\n" +
+		"Fold,20,,not a url\n"
+
+	recorder := sendImport(t, server, "/imports/services", admin, content)
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	var res importErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	require.Len(t, res.Rows, 5)
+	require.Empty(t, res.Rows[0].Error)
+	require.Equal(t, "service_price must be a whole number", res.Rows[1].Error)
+	require.Contains(t, res.Rows[2].Error, "TurnaroundHours")
+	require.Contains(t, res.Rows[3].Error, "ServiceName")
+	require.Contains(t, res.Rows[4].Error, "ServiceImage")
+}
+
+func TestImportUsersDryRun(t *testing.T) {
+	server := newTestServer(t)
+	admin := createTestAdmin(t)
+	email := util.RandomEmail()
+
+	content := "name,email,phone,address,password\n" +
+		"Ann," + email + ",123,Street 1,secret123\n"
+
+	recorder := sendImport(t, server, "/imports/users?dry_run=true", admin, content)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var res importResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	require.True(t, res.DryRun)
+	require.Equal(t, 1, res.Created)
+	require.Equal(t, util.ImportCreated, res.Rows[0].Action)
+	require.Zero(t, res.Rows[0].ID)
+
+	// Nothing was saved
+	_, err := testStore.GetUserByEmail(context.Background(), email)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	recorder = sendImport(t, server, "/imports/users", admin, content)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	user, err := testStore.GetUserByEmail(context.Background(), email)
+	require.NoError(t, err)
+	require.Equal(t, "Ann", user.Name)
+	require.NoError(t, util.CheckPassword("secret123", user.HashedPassword))
+}
+
+func TestImportServices(t *testing.T) {
+	server := newTestServer(t)
+	admin := createTestAdmin(t)
+
+	name := "Service " + util.RandomString(10)
+	recorder := sendImport(t, server, "/imports/services", admin, "service_name,service_price\n"+name+",150\n")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var res importResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	require.Equal(t, 1, res.Created)
+	require.NotZero(t, res.Rows[0].ID)
+
+	// Importing the same name again updates the service
+	recorder = sendImport(t, server, "/imports/services", admin, "service_name,service_price,turnaround_hours\n"+name+",180,12\n")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	require.Equal(t, 1, res.Updated)
+
+	service, err := testStore.GetService(context.Background(), res.Rows[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(180), service.ServicePrice)
+	require.Equal(t, int32(12), service.TurnaroundHours)
+}
+
+func TestImportUsersBranchAdmin(t *testing.T) {
+	server := newTestServer(t)
+	admin := createTestAdmin(t)
+	branch, err := testStore.CreateBranch(context.Background(), db.CreateBranchParams{
+		Name:    util.RandomUser(),
+		Address: util.RandomAddress(),
+		Phone:   util.RandomPhone(),
+	})
+	require.NoError(t, err)
+	_, err = testStore.SetAdminBranchesTx(context.Background(), admin.AdminID, []int32{branch.BranchID})
+	require.NoError(t, err)
+
+	// Staff limited to branches cannot overwrite the customers of other branches
+	recorder := sendImport(t, server, "/imports/users", admin, "name,email,phone,address,password\nAnn,"+util.RandomEmail()+",123,Street,secret123\n")
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/invoice"
+)
+
+type invoiceResponse struct {
+	db.Invoice
+	Items []db.InvoiceItem `json:"items"`
+	Taxes []db.InvoiceTax  `json:"taxes"`
+	// PdfURL downloads the PDF through the API, it needs the same authorization as the invoice
+	PdfURL string `json:"pdf_url"`
+}
+
+// issueInvoice creates the invoice for a delivered order and attaches the rendered PDF.
+// A failed PDF upload is not fatal, the PDF is rendered again the next time it is downloaded.
+func (server *Server) issueInvoice(ctx context.Context, orderID int64) (invoiceResponse, error) {
+	result, err := server.store.IssueInvoiceTx(ctx, db.IssueInvoiceTxParams{
+		OrderID:  orderID,
+		TaxRates: server.taxRates,
+	})
+	if err != nil {
+		return invoiceResponse{}, err
+	}
+
+	res := invoiceResponse{
+		Invoice: result.Invoice,
+		Items:   result.Items,
+		Taxes:   result.Taxes,
+	}
+	res.Invoice, err = server.attachInvoicePDF(ctx, res)
+	if err != nil {
+		log.Printf("cannot attach pdf to invoice %s: %v", res.InvoiceNumber, err)
+	}
+	res.PdfURL = invoicePDFPath(orderID)
+	return res, nil
+}
+
+func invoicePDFPath(orderID int64) string {
+	return fmt.Sprintf("/orders/%d/invoice/pdf", orderID)
+}
+
+// legacyInvoicePDFKey is the public key the PDF of an invoice was stored under before invoices were private
+func legacyInvoicePDFKey(invoiceNumber string) string {
+	return fmt.Sprintf("invoices/%s.pdf", invoiceNumber)
+}
+
+// attachInvoicePDF renders the PDF of an invoice that has none yet and stores it under a random private key.
+// The public copy an invoice issued before PDFs were private may still have is removed once it is replaced.
+func (server *Server) attachInvoicePDF(ctx context.Context, res invoiceResponse) (db.Invoice, error) {
+	if res.PdfKey != "" {
+		return res.Invoice, nil
+	}
+
+	pdf, err := invoice.RenderPDF(res.Invoice, res.Items, res.Taxes)
+	if err != nil {
+		return res.Invoice, err
+	}
+
+	key := fmt.Sprintf("%sinvoices/%s.pdf", privatePrefix, uuid.New())
+	err = server.storage.Put(ctx, key, "application/pdf", bytes.NewReader(pdf))
+	if err != nil {
+		return res.Invoice, err
+	}
+
+	inv, err := server.store.SetInvoicePDF(ctx, db.SetInvoicePDFParams{
+		InvoiceID: res.InvoiceID,
+		PdfKey:    key,
+	})
+	if err != nil {
+		server.removeUpload(key)
+		return res.Invoice, err
+	}
+	server.removeUpload(legacyInvoicePDFKey(inv.InvoiceNumber))
+	return inv, nil
+}
+
+// invoiceByURI loads the invoice of the order in the URI with its items and taxes, and checks the caller
+// is staff or the customer it belongs to. It writes the error response and returns false when the request cannot go on.
+func (server *Server) invoiceByURI(ctx *gin.Context) (invoiceResponse, bool) {
+	var req getOrderRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return invoiceResponse{}, false
+	}
+
+	orders, err := server.store.GetOrder(ctx, req.OrderID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return invoiceResponse{}, false
+	}
+	if len(orders) == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return invoiceResponse{}, false
+	}
+
+	allowed, err := server.canAccessOrder(ctx, orders[0].UserID, orders[0].BranchID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return invoiceResponse{}, false
+	}
+	if !allowed {
+		err := errors.New("order does not belong to the current customer")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return invoiceResponse{}, false
+	}
+
+	inv, err := server.store.GetInvoiceByOrderID(ctx, req.OrderID)
+	if err == sql.ErrNoRows && orders[0].OrderDelivered {
+		// Issuing the invoice failed when the order was delivered
+		res, err := server.issueInvoice(ctx, req.OrderID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return invoiceResponse{}, false
+		}
+		return res, true
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return invoiceResponse{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return invoiceResponse{}, false
+	}
+
+	res := invoiceResponse{Invoice: inv, PdfURL: invoicePDFPath(inv.OrderID)}
+	res.Items, err = server.store.ListInvoiceItems(ctx, inv.InvoiceID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return invoiceResponse{}, false
+	}
+	res.Taxes, err = server.store.ListInvoiceTaxes(ctx, inv.InvoiceID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return invoiceResponse{}, false
+	}
+	return res, true
+}
+
+func (server *Server) getInvoice(ctx *gin.Context) {
+	res, ok := server.invoiceByURI(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// getInvoicePDF downloads the PDF of an invoice, invoices are private so it is never linked to directly
+func (server *Server) getInvoicePDF(ctx *gin.Context) {
+	res, ok := server.invoiceByURI(ctx)
+	if !ok {
+		return
+	}
+
+	inv, err := server.attachInvoicePDF(ctx, res)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.servePrivate(ctx, inv.PdfKey, "application/pdf", inv.InvoiceNumber+".pdf")
+}
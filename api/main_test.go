@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+var testStore *db.Store
+var testDB *sql.DB
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	config, err := util.LoadConfig("..")
+	if err != nil {
+		log.Fatal("cannot load config:", err)
+	}
+
+	testDB, err = sql.Open(config.DBDriver, config.DBSource)
+	if err != nil {
+		log.Fatal("cannot connect to db: ", err)
+	}
+	testStore = db.NewStore(testDB)
+
+	os.Exit(m.Run())
+}
+
+// newTestServer creates a server on the test database that keeps its files in a temporary directory
+func newTestServer(t *testing.T) *Server {
+	config := util.Config{
+		TokenSymmetricKey:   util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+		StorageBackend:      "local",
+		StorageLocalDir:     t.TempDir(),
+		StoragePublicURL:    "http://localhost:8080/files",
+		TimeZone:            "UTC",
+	}
+
+	server, err := NewServer(config, testStore)
+	require.NoError(t, err)
+	return server
+}
+
+// createTestAdmin adds a head office admin to the test database
+func createTestAdmin(t *testing.T) db.Admin {
+	admin, err := testStore.CreateAdmin(context.Background(), db.CreateAdminParams{
+		Name:    util.RandomUser(),
+		Email:   util.RandomEmail(),
+		Phone:   util.RandomPhone(),
+		Address: util.RandomAddress(),
+	})
+	require.NoError(t, err)
+	return admin
+}
+
+// addAuthorization signs the request with an access token issued to the given account
+func addAuthorization(t *testing.T, request *http.Request, server *Server, email string, accountKind string, accountID int32) {
+	accessToken, _, err := server.tokenMaker.CreateToken(email, accountKind, accountID, time.Minute)
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+}
@@ -1,12 +1,14 @@
 package api
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
 	"github.com/nexpictora-pvt-ltd/cnx-backend/token"
 )
 
@@ -20,7 +22,7 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 
 		if c.Request.Method == "OPTIONS" {
@@ -98,8 +100,143 @@ func adminAuthMiddleWare(tokenMaker token.Maker) gin.HandlerFunc {
 			return
 		}
 
+		if payload.AccountKind != token.AccountAdmin {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errNotAdmin))
+			return
+		}
+
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 
 	}
 }
+
+// errNotAdmin is returned when an access token issued to a customer is used for an admin action
+var errNotAdmin = errors.New("access token was not issued to an admin")
+
+// currentAdmin returns the admin the access token was issued to,
+// it returns sql.ErrNoRows when the token belongs to a customer or the admin has been archived
+func (server *Server) currentAdmin(ctx *gin.Context) (db.Admin, error) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.AccountKind != token.AccountAdmin {
+		return db.Admin{}, sql.ErrNoRows
+	}
+	return server.store.GetActiveAdmin(ctx, authPayload.AccountID)
+}
+
+// currentUser returns the customer the access token was issued to,
+// it returns sql.ErrNoRows when the token belongs to an admin or the customer has been archived
+func (server *Server) currentUser(ctx *gin.Context) (db.User, error) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.AccountKind != token.AccountUser {
+		return db.User{}, sql.ErrNoRows
+	}
+	return server.store.GetActiveUser(ctx, authPayload.AccountID)
+}
+
+// canAccessOrder reports whether the authenticated caller is an admin working at the branch of the order
+// or the customer the order belongs to
+func (server *Server) canAccessOrder(ctx *gin.Context, userID int32, branchID int32) (bool, error) {
+	c, err := server.currentCaller(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if c.isStaff() {
+		scope, err := server.store.ListAdminBranchIDs(ctx, c.admin.AdminID)
+		if err != nil {
+			return false, err
+		}
+		return branchScope(scope).covers(branchID), nil
+	}
+	return c.user.UserID == userID, nil
+}
+
+// caller is the authenticated admin or customer making a request, exactly one of them is set
+type caller struct {
+	admin *db.Admin
+	user  *db.User
+}
+
+func (c caller) isStaff() bool {
+	return c.admin != nil
+}
+
+// authorID returns the author columns for rows written by the caller
+func (c caller) authorID() (adminID, userID sql.NullInt32) {
+	if c.admin != nil {
+		return sql.NullInt32{Int32: c.admin.AdminID, Valid: true}, sql.NullInt32{}
+	}
+	return sql.NullInt32{}, sql.NullInt32{Int32: c.user.UserID, Valid: true}
+}
+
+// isAuthor reports whether the caller wrote a row with the given author columns
+func (c caller) isAuthor(adminID, userID sql.NullInt32) bool {
+	if c.admin != nil {
+		return adminID.Valid && adminID.Int32 == c.admin.AdminID
+	}
+	return userID.Valid && userID.Int32 == c.user.UserID
+}
+
+// orderCaller resolves the caller and checks it is staff working at the branch of the order or the customer the order belongs to.
+// It writes the error response and returns false when the request cannot go on.
+func (server *Server) orderCaller(ctx *gin.Context, orderID int64) (caller, bool) {
+	orders, err := server.store.GetOrder(ctx, orderID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return caller{}, false
+	}
+	if len(orders) == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return caller{}, false
+	}
+
+	c, err := server.currentCaller(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return caller{}, false
+	}
+	if err == nil && c.isStaff() {
+		scope, err := server.store.ListAdminBranchIDs(ctx, c.admin.AdminID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return caller{}, false
+		}
+		if !branchScope(scope).covers(orders[0].BranchID) {
+			ctx.JSON(http.StatusForbidden, errorResponse(errBranchForbidden))
+			return caller{}, false
+		}
+		return c, true
+	}
+	if err == sql.ErrNoRows || c.user.UserID != orders[0].UserID {
+		err := errors.New("order does not belong to the current customer")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return caller{}, false
+	}
+	return c, true
+}
+
+// currentCaller resolves the admin or customer the access token was issued to,
+// it returns sql.ErrNoRows when the account no longer exists or has been archived
+func (server *Server) currentCaller(ctx *gin.Context) (caller, error) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	switch authPayload.AccountKind {
+	case token.AccountAdmin:
+		admin, err := server.currentAdmin(ctx)
+		if err != nil {
+			return caller{}, err
+		}
+		return caller{admin: &admin}, nil
+	case token.AccountUser:
+		user, err := server.currentUser(ctx)
+		if err != nil {
+			return caller{}, err
+		}
+		return caller{user: &user}, nil
+	default:
+		return caller{}, sql.ErrNoRows
+	}
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/token"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminAuthRejectsCustomerToken(t *testing.T) {
+	server := newTestServer(t)
+
+	// A customer token is refused even when the email is the one of an admin
+	request := httptest.NewRequest(http.MethodGet, "/admins?page_id=1&page_size=5", nil)
+	addAuthorization(t, request, server, util.RandomEmail(), token.AccountUser, 1)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+type createOrderNoteRequest struct {
+	Body string `json:"body" binding:"required"`
+	// Visibility defaults to internal for staff, customers can only write customer visible notes
+	Visibility string `json:"visibility" binding:"omitempty,oneof=internal customer"`
+}
+
+func (server *Server) createOrderNote(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req createOrderNoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	c, ok := server.orderCaller(ctx, uri.OrderID)
+	if !ok {
+		return
+	}
+
+	visibility, err := noteVisibility(c, req.Visibility)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	adminID, userID := c.authorID()
+	note, err := server.store.CreateOrderNote(ctx, db.CreateOrderNoteParams{
+		OrderID:    uri.OrderID,
+		Body:       req.Body,
+		Visibility: visibility,
+		AdminID:    adminID,
+		UserID:     userID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, note)
+}
+
+// noteVisibility applies the default visibility for the caller and rejects internal notes from customers
+func noteVisibility(c caller, visibility string) (string, error) {
+	if !c.isStaff() {
+		if visibility == util.VisibilityInternal {
+			return "", errors.New("customers cannot add internal notes")
+		}
+		return util.VisibilityCustomer, nil
+	}
+	if visibility == "" {
+		return util.VisibilityInternal, nil
+	}
+	return visibility, nil
+}
+
+// listOrderNotes lists every note for staff and only the customer visible ones for customers
+func (server *Server) listOrderNotes(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	c, ok := server.orderCaller(ctx, uri.OrderID)
+	if !ok {
+		return
+	}
+
+	notes, err := server.store.ListOrderNotes(ctx, db.ListOrderNotesParams{
+		OrderID:         uri.OrderID,
+		IncludeInternal: c.isStaff(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, notes)
+}
+
+type orderNoteURI struct {
+	OrderID int64 `uri:"order_id" binding:"required"`
+	NoteID  int64 `uri:"note_id" binding:"required,min=1"`
+}
+
+// deleteOrderNote lets staff delete any note and customers only the notes they wrote
+func (server *Server) deleteOrderNote(ctx *gin.Context) {
+	var uri orderNoteURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	c, ok := server.orderCaller(ctx, uri.OrderID)
+	if !ok {
+		return
+	}
+
+	note, err := server.store.GetOrderNote(ctx, uri.NoteID)
+	if err == nil && note.OrderID != uri.OrderID {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !c.isStaff() && !c.isAuthor(note.AdminID, note.UserID) {
+		err := errors.New("customers can only delete their own notes")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	err = server.store.DeleteOrderNote(ctx, note.NoteID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, "Note Deleted Successfully")
+}
@@ -0,0 +1,432 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/payment"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+type paymentSummary struct {
+	PaymentStatus  string `json:"payment_status"`
+	AmountDue      int64  `json:"amount_due"`
+	AmountPaid     int64  `json:"amount_paid"`
+	AmountRefunded int64  `json:"amount_refunded"`
+}
+
+// orderAmountDue is the invoiced total once the order is invoiced, otherwise the
+// current price of its services with the configured taxes applied
+func (server *Server) orderAmountDue(ctx context.Context, orderID int64) (int64, error) {
+	inv, err := server.store.GetInvoiceByOrderID(ctx, orderID)
+	if err == nil {
+		return inv.Total, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	subtotal, err := server.store.GetOrderSubtotal(ctx, orderID)
+	if err != nil {
+		return 0, err
+	}
+	return server.withTaxes(subtotal), nil
+}
+
+// withTaxes adds the configured taxes to the subtotal of an order that is not invoiced yet
+func (server *Server) withTaxes(subtotal int64) int64 {
+	total := subtotal
+	for _, rate := range server.taxRates {
+		total += util.TaxAmount(subtotal, rate.BasisPoints)
+	}
+	return total
+}
+
+func (server *Server) getPaymentSummary(ctx context.Context, orderID int64) (paymentSummary, error) {
+	amountDue, err := server.orderAmountDue(ctx, orderID)
+	if err != nil {
+		return paymentSummary{}, err
+	}
+
+	summary, err := server.store.GetOrderPaymentSummary(ctx, orderID)
+	if err != nil {
+		return paymentSummary{}, err
+	}
+
+	return paymentSummary{
+		PaymentStatus:  util.OrderPaymentStatus(amountDue, summary.Paid, summary.Refunded),
+		AmountDue:      amountDue,
+		AmountPaid:     summary.Paid,
+		AmountRefunded: summary.Refunded,
+	}, nil
+}
+
+// listPaymentSummaries returns the payment summaries of several orders at once, keyed by order id
+func (server *Server) listPaymentSummaries(ctx context.Context, orderIDs []int64) (map[int64]paymentSummary, error) {
+	rows, err := server.store.ListOrderPaymentSummaries(ctx, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[int64]paymentSummary, len(rows))
+	for _, row := range rows {
+		amountDue := server.withTaxes(row.Subtotal)
+		if row.InvoiceTotal.Valid {
+			amountDue = row.InvoiceTotal.Int64
+		}
+		summaries[row.OrderID] = paymentSummary{
+			PaymentStatus:  util.OrderPaymentStatus(amountDue, row.Paid, row.Refunded),
+			AmountDue:      amountDue,
+			AmountPaid:     row.Paid,
+			AmountRefunded: row.Refunded,
+		}
+	}
+	return summaries, nil
+}
+
+type recordPaymentRequest struct {
+	Method    string `json:"method" binding:"required,oneof=cash card upi wallet"`
+	Amount    int64  `json:"amount" binding:"required,min=1"`
+	Reference string `json:"reference"`
+	Status    string `json:"status" binding:"omitempty,oneof=pending captured failed"`
+}
+
+func (server *Server) recordPayment(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req recordPaymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Payments entered by staff at the counter have already been collected
+	if req.Status == "" {
+		req.Status = util.PaymentCaptured
+	}
+
+	if !server.checkOrderBranch(ctx, uri.OrderID) {
+		return
+	}
+
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	payment, err := server.store.RecordPaymentTx(ctx, db.RecordPaymentTxParams{
+		OrderID:    uri.OrderID,
+		Method:     req.Method,
+		Amount:     req.Amount,
+		Reference:  req.Reference,
+		Status:     req.Status,
+		RecordedBy: sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, payment)
+}
+
+type refundPaymentURI struct {
+	PaymentID int64 `uri:"payment_id" binding:"required,min=1"`
+}
+
+type refundPaymentRequest struct {
+	Amount    int64  `json:"amount" binding:"required,min=1"`
+	Reference string `json:"reference"`
+}
+
+func (server *Server) refundPayment(ctx *gin.Context) {
+	var uri refundPaymentURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req refundPaymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payment, err := server.store.GetPayment(ctx, uri.PaymentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !server.checkOrderBranch(ctx, payment.OrderID) {
+		return
+	}
+
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	refund, err := server.store.RefundPaymentTx(ctx, db.RefundPaymentTxParams{
+		PaymentID:  uri.PaymentID,
+		Amount:     req.Amount,
+		Reference:  req.Reference,
+		RecordedBy: sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrPaymentNotRefundable), errors.Is(err, db.ErrRefundTooLarge):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, refund)
+}
+
+type orderPaymentsResponse struct {
+	paymentSummary
+	Payments []db.Payment `json:"payments"`
+}
+
+func (server *Server) listOrderPayments(ctx *gin.Context) {
+	var req getOrderRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	orders, err := server.store.GetOrder(ctx, req.OrderID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if len(orders) == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+
+	allowed, err := server.canAccessOrder(ctx, orders[0].UserID, orders[0].BranchID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !allowed {
+		err := errors.New("order does not belong to the current customer")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	summary, err := server.getPaymentSummary(ctx, req.OrderID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	payments, err := server.store.ListPaymentsByOrder(ctx, req.OrderID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, orderPaymentsResponse{
+		paymentSummary: summary,
+		Payments:       payments,
+	})
+}
+
+// errNothingDue is returned when an online payment is started for an order that is already paid
+var errNothingDue = errors.New("nothing is due on this order")
+
+// createOrderPaymentIntent starts an online payment of what is still due on an order,
+// customers use it when the payment could not be started together with the order
+func (server *Server) createOrderPaymentIntent(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if server.paymentProvider == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "online payments are not enabled"})
+		return
+	}
+	if _, ok := server.orderCaller(ctx, uri.OrderID); !ok {
+		return
+	}
+
+	intent, err := server.createPaymentIntent(ctx, uri.OrderID)
+	if err != nil {
+		if errors.Is(err, errNothingDue) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, intent)
+}
+
+// createPaymentIntent asks the gateway to collect what is still due on an order and records
+// the pending payment in the ledger so the webhook can settle it later. A payment already
+// started for the same amount is continued instead of asking the gateway for another one.
+func (server *Server) createPaymentIntent(ctx *gin.Context, orderID int64) (*payment.Intent, error) {
+	summary, err := server.getPaymentSummary(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	// Only what is still owed is charged, earlier payments of the order count towards it
+	amountDue := summary.AmountDue - summary.AmountPaid + summary.AmountRefunded
+	if amountDue <= 0 {
+		return nil, errNothingDue
+	}
+
+	var intent *payment.Intent
+	result, err := server.store.StartOnlinePaymentTx(ctx, db.StartOnlinePaymentTxParams{
+		OrderID:  orderID,
+		Provider: server.paymentProvider.Name(),
+		Amount:   amountDue,
+		CreateIntent: func() (string, error) {
+			intent, err = server.paymentProvider.CreateIntent(ctx, payment.IntentParams{
+				OrderID:  orderID,
+				Amount:   amountDue,
+				Currency: server.config.PaymentCurrency,
+			})
+			if err != nil {
+				return "", err
+			}
+			return intent.ID, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Created {
+		intent = server.paymentProvider.ResumeIntent(result.Payment.Reference, result.Payment.Amount, server.config.PaymentCurrency)
+	}
+	return intent, nil
+}
+
+// maxWebhookBodySize is the largest webhook body read, the route is public and the body is read before it is verified
+const maxWebhookBodySize = 1 << 20
+
+type paymentEvent struct {
+	Event     string `json:"event"`
+	PaymentID int64  `json:"payment_id"`
+	OrderID   int64  `json:"order_id"`
+	Amount    int64  `json:"amount"`
+	Method    string `json:"method"`
+	Status    string `json:"status"`
+}
+
+func (server *Server) handlePaymentWebhook(ctx *gin.Context) {
+	if server.paymentProvider == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "online payments are not enabled"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	event, err := server.paymentProvider.ParseWebhook(ctx.Request.Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, payment.ErrInvalidSignature):
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		case errors.Is(err, payment.ErrUnhandledEvent):
+			// Acknowledge events we do not care about so the gateway stops retrying them
+			ctx.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		default:
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		}
+		return
+	}
+
+	pending, err := server.store.GetPaymentByProviderReference(ctx, db.GetPaymentByProviderReferenceParams{
+		Provider:  server.paymentProvider.Name(),
+		Reference: event.IntentID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The intent was created for the amount recorded in the ledger, any other amount
+	// means the event does not belong to this payment and must not settle it
+	if event.Amount != pending.Amount {
+		err := fmt.Errorf("event amount %d does not match payment %d amount %d", event.Amount, pending.PaymentID, pending.Amount)
+		log.Printf("rejected payment webhook: %v", err)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	method := pending.Method
+	if util.IsPaymentMethod(event.Method) {
+		method = event.Method
+	}
+
+	// Gateways deliver webhooks at least once. The update only matches when the status
+	// actually changes, so a repeated delivery is acknowledged without side effects.
+	updated, err := server.store.UpdatePaymentFromGateway(ctx, db.UpdatePaymentFromGatewayParams{
+		PaymentID:        pending.PaymentID,
+		Status:           event.Status,
+		Method:           method,
+		GatewayPaymentID: event.PaymentID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusOK, gin.H{"status": "already processed"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.publishEvent(ctx, paymentEventsQueue, paymentEvent{
+		Event:     "payment." + updated.Status,
+		PaymentID: updated.PaymentID,
+		OrderID:   updated.OrderID,
+		Amount:    updated.Amount,
+		Method:    updated.Method,
+		Status:    updated.Status,
+	})
+	if err != nil {
+		// The payment is already settled, a retried webhook would not publish again
+		log.Printf("cannot publish event for payment %d: %v", updated.PaymentID, err)
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/payment"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentWebhookTooLarge(t *testing.T) {
+	server := newTestServer(t)
+	server.paymentProvider = payment.NewFakeProvider(util.RandomString(32))
+
+	// The body is refused before its signature is checked
+	body := strings.NewReader(strings.Repeat("a", maxWebhookBodySize+1))
+	request := httptest.NewRequest(http.MethodPost, "/payments/webhook", body)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+)
+
+type feedbackRequestEvent struct {
+	Event   string `json:"event"`
+	OrderID int64  `json:"order_id"`
+	UserID  int32  `json:"user_id"`
+}
+
+// requestFeedback asks the customer to rate a delivered order. The order is already
+// delivered when this runs, so a failure is logged instead of failing the request.
+func (server *Server) requestFeedback(ctx *gin.Context, order db.Order) {
+	err := server.publishEvent(ctx, feedbackRequestsQueue, feedbackRequestEvent{
+		Event:   "order.feedback_requested",
+		OrderID: order.OrderID,
+		UserID:  order.UserID,
+	})
+	if err != nil {
+		log.Printf("cannot request feedback for order %d: %v", order.OrderID, err)
+	}
+}
+
+// ratedOrder loads a delivered order of the current customer, writing the error response
+// and returning false when it cannot be rated by them
+func (server *Server) ratedOrder(ctx *gin.Context, orderID int64) ([]db.Order, bool) {
+	orders, err := server.store.GetOrder(ctx, orderID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+	if len(orders) == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return nil, false
+	}
+
+	user, err := server.currentUser(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+	if err == sql.ErrNoRows || user.UserID != orders[0].UserID {
+		err := errors.New("only the customer who placed the order can rate it")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	if !orders[0].OrderDelivered {
+		err := errors.New("only delivered orders can be rated")
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return nil, false
+	}
+	return orders, true
+}
+
+type createOrderRatingRequest struct {
+	// ServiceID rates a single service of the order, without it the order as a whole is rated
+	ServiceID int32  `json:"service_id" binding:"omitempty,min=1"`
+	Rating    int32  `json:"rating" binding:"required,min=1,max=5"`
+	Comment   string `json:"comment"`
+}
+
+func (server *Server) createOrderRating(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req createOrderRatingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	orders, ok := server.ratedOrder(ctx, uri.OrderID)
+	if !ok {
+		return
+	}
+
+	serviceID := sql.NullInt32{Int32: req.ServiceID, Valid: req.ServiceID != 0}
+	if serviceID.Valid && !orderHasService(orders, req.ServiceID) {
+		err := errors.New("service is not part of the order")
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	rating, err := server.store.CreateOrderRating(ctx, db.CreateOrderRatingParams{
+		OrderID:   uri.OrderID,
+		UserID:    orders[0].UserID,
+		ServiceID: serviceID,
+		Rating:    req.Rating,
+		Comment:   req.Comment,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			err := errors.New("already rated, update the existing rating instead")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, rating)
+}
+
+func orderHasService(orders []db.Order, serviceID int32) bool {
+	for _, order := range orders {
+		if order.ServiceIds == serviceID {
+			return true
+		}
+	}
+	return false
+}
+
+type orderRatingURI struct {
+	OrderID  int64 `uri:"order_id" binding:"required"`
+	RatingID int64 `uri:"rating_id" binding:"required,min=1"`
+}
+
+type updateOrderRatingRequest struct {
+	Rating  int32  `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment"`
+}
+
+// updateOrderRating lets the customer change a rating within RATING_EDIT_WINDOW of giving it
+func (server *Server) updateOrderRating(ctx *gin.Context) {
+	var uri orderRatingURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateOrderRatingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.ratedOrder(ctx, uri.OrderID); !ok {
+		return
+	}
+
+	rating, err := server.store.GetOrderRating(ctx, uri.RatingID)
+	if err == nil && rating.OrderID != uri.OrderID {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if time.Since(rating.CreatedAt) > server.config.RatingEditWindow {
+		err := errors.New("the rating can no longer be changed")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	rating, err = server.store.UpdateOrderRating(ctx, db.UpdateOrderRatingParams{
+		RatingID: rating.RatingID,
+		Rating:   req.Rating,
+		Comment:  req.Comment,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, rating)
+}
+
+func (server *Server) listOrderRatings(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.orderCaller(ctx, uri.OrderID); !ok {
+		return
+	}
+
+	ratings, err := server.store.ListOrderRatings(ctx, uri.OrderID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, ratings)
+}
+
+type listRatingsRequest struct {
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=50"`
+	BranchID *int32 `form:"branch_id" binding:"omitempty,min=1"`
+}
+
+func (server *Server) listRatings(ctx *gin.Context) {
+	var req listRatingsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	branchIDs, ok := server.orderBranches(ctx, req.BranchID)
+	if !ok {
+		return
+	}
+
+	ratings, err := server.store.ListRatings(ctx, db.ListRatingsParams{
+		BranchIds: branchIDs,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, ratings)
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+const defaultSearchLimit = 10
+
+type searchRequest struct {
+	Query string `form:"q" binding:"required,min=2,max=100"`
+	Limit int32  `form:"limit" binding:"omitempty,min=1,max=50"`
+	// BranchID narrows customers to their home branch, services to the ones offered there at the branch price
+	// and orders to the ones taken there
+	BranchID *int32 `form:"branch_id" binding:"omitempty,min=1"`
+}
+
+func (req *searchRequest) normalize() {
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Limit == 0 {
+		req.Limit = defaultSearchLimit
+	}
+}
+
+// Highlights hold the matching fields with the matches wrapped in <mark> tags, HTML escaped
+type userSearchResult struct {
+	UserID     int32             `json:"user_id"`
+	Name       string            `json:"name"`
+	Email      string            `json:"email"`
+	Phone      string            `json:"phone"`
+	Rank       float32           `json:"rank"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+type serviceSearchResult struct {
+	db.SearchServicesRow
+	Highlights map[string]string `json:"highlights"`
+}
+
+type orderSearchResult struct {
+	OrderID      int64             `json:"order_id"`
+	UserID       int32             `json:"user_id"`
+	CustomerName string            `json:"customer_name"`
+	OrderStatus  string            `json:"order_status"`
+	OrderStarted time.Time         `json:"order_started"`
+	Highlights   map[string]string `json:"highlights"`
+}
+
+type searchResponse struct {
+	Query    string                `json:"query"`
+	Users    []userSearchResult    `json:"users"`
+	Services []serviceSearchResult `json:"services"`
+	Orders   []orderSearchResult   `json:"orders"`
+}
+
+// search looks for customers by name, email or phone, services by name and orders by number.
+// Each list is ranked on its own, best match first.
+func (server *Server) search(ctx *gin.Context) {
+	var req searchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	req.normalize()
+	digits := util.SearchDigits(req.Query)
+	branchIDs, ok := server.orderBranches(ctx, req.BranchID)
+	if !ok {
+		return
+	}
+
+	res := searchResponse{
+		Query:    req.Query,
+		Users:    []userSearchResult{},
+		Services: []serviceSearchResult{},
+		Orders:   []orderSearchResult{},
+	}
+
+	users, err := server.store.SearchUsers(ctx, db.SearchUsersParams{
+		Query:    req.Query,
+		Digits:   digits,
+		BranchID: nullInt32(req.BranchID),
+		Limit:    req.Limit,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	for _, user := range users {
+		res.Users = append(res.Users, userSearchResult{
+			UserID: user.UserID,
+			Name:   user.Name,
+			Email:  user.Email,
+			Phone:  user.Phone,
+			Rank:   user.Rank,
+			Highlights: map[string]string{
+				"name":  util.Highlight(user.Name, req.Query),
+				"email": util.Highlight(user.Email, req.Query),
+				"phone": util.Highlight(user.Phone, req.Query),
+			},
+		})
+	}
+
+	res.Services, err = server.searchServices(ctx, req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Order numbers are only looked up for queries that are numbers
+	if digits != "" {
+		orders, err := server.store.SearchOrders(ctx, db.SearchOrdersParams{
+			Digits:    digits,
+			BranchIds: branchIDs,
+			Limit:     req.Limit,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		for _, order := range orders {
+			res.Orders = append(res.Orders, orderSearchResult{
+				OrderID:      order.OrderID,
+				UserID:       order.UserID,
+				CustomerName: order.CustomerName,
+				OrderStatus:  order.OrderStatus,
+				OrderStarted: order.OrderStarted,
+				Highlights: map[string]string{
+					"order_id": util.Highlight(strconv.FormatInt(order.OrderID, 10), digits),
+				},
+			})
+		}
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// searchCatalog lets customers search the services they can order
+func (server *Server) searchCatalog(ctx *gin.Context) {
+	var req searchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	req.normalize()
+
+	services, err := server.searchServices(ctx, req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, services)
+}
+
+func (server *Server) searchServices(ctx *gin.Context, req searchRequest) ([]serviceSearchResult, error) {
+	services, err := server.store.SearchServices(ctx, db.SearchServicesParams{
+		Query:    req.Query,
+		BranchID: nullInt32(req.BranchID),
+		Limit:    req.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]serviceSearchResult, len(services))
+	for i, service := range services {
+		res[i] = serviceSearchResult{
+			SearchServicesRow: service,
+			Highlights: map[string]string{
+				"service_name": util.Highlight(service.ServiceName, req.Query),
+			},
+		}
+	}
+	return res, nil
+}
@@ -1,28 +1,62 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/gin-gonic/gin"
 	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/messaging"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/payment"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/storage"
 	"github.com/nexpictora-pvt-ltd/cnx-backend/token"
 	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// localFilesPath is where files kept by the local storage backend are served
+const localFilesPath = "/files"
+
+// privatePrefix starts the keys of files that are never served publicly, such as invoices and exports.
+// The API streams them to the callers allowed to see them, the bucket must not grant public read on it.
+const privatePrefix = "private/"
+
+// Upload limits used when they are not configured
+const (
+	defaultMaxImageSize      = 5 << 20
+	defaultMaxAttachmentSize = 10 << 20
+	defaultUploadURLExpiry   = 15 * time.Minute
+)
+
+// Queues events are published to
+const (
+	paymentEventsQueue      = "payment-events"
+	slaEventsQueue          = "order-sla-events"
+	feedbackRequestsQueue   = "feedback-requests"
+	subscriptionEventsQueue = "subscription-events"
+	serviceImagesQueue      = "service-images"
+)
+
 type Server struct {
 	config     util.Config
 	store      *db.Store
 	tokenMaker token.Maker
 	router     *gin.Engine
-	s3Uploader *s3manager.Uploader
+	storage    storage.Storage
+	taxRates   []util.TaxRate
+	// paymentProvider is nil when online payments are disabled
+	paymentProvider payment.Provider
+	// location is the time zone slot days are counted in
+	location *time.Location
 }
 
 func NewServer(config util.Config, store *db.Store) (*Server, error) {
@@ -34,71 +68,166 @@ func NewServer(config util.Config, store *db.Store) (*Server, error) {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
-	awsSession, err := session.NewSessionWithOptions(session.Options{
-		Config: aws.Config{
-			Region: aws.String("ap-south-1"), // Replace with your AWS S3 region
-			Credentials: credentials.NewStaticCredentials(
-				config.AWSAccessKey, // Replace with your AWS access key
-				config.AWSSecretKey, // Replace with your AWS secret key
-				"",                  // Optional: Replace with your AWS session token if using temporary credentials
-			),
-		},
-	})
+	// Uploads are limited even when the limits are left out of the configuration
+	if config.UploadMaxImageSize <= 0 {
+		config.UploadMaxImageSize = defaultMaxImageSize
+	}
+	if config.UploadMaxAttachmentSize <= 0 {
+		config.UploadMaxAttachmentSize = defaultMaxAttachmentSize
+	}
+	if config.UploadURLExpiry <= 0 {
+		config.UploadURLExpiry = defaultUploadURLExpiry
+	}
+
+	fileStorage, err := newStorage(config)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create AWS session: %w", err)
+		return nil, fmt.Errorf("cannot create storage: %w", err)
 	}
 
-	s3Uploader := s3manager.NewUploader(awsSession)
+	taxRates, err := util.ParseTaxRates(config.InvoiceTaxRates)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse invoice tax rates: %w", err)
+	}
+
+	paymentProvider, err := newPaymentProvider(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create payment provider: %w", err)
+	}
+
+	location, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load time zone: %w", err)
+	}
 
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
-		s3Uploader: s3Uploader,
+		config:          config,
+		store:           store,
+		tokenMaker:      tokenMaker,
+		storage:         fileStorage,
+		taxRates:        taxRates,
+		paymentProvider: paymentProvider,
+		location:        location,
 	}
 
 	server.setupRouter()
 	return server, nil
 }
 
-func (server *Server) uploadToS3(fileHeader *multipart.FileHeader) (string, error) {
-	file, err := fileHeader.Open()
+// newPaymentProvider creates the gateway selected by PAYMENT_PROVIDER, leaving it empty disables online payments
+func newPaymentProvider(config util.Config) (payment.Provider, error) {
+	switch config.PaymentProvider {
+	case "":
+		return nil, nil
+	case "razorpay":
+		return payment.NewRazorpayProvider(config.RazorpayKeyID, config.RazorpayKeySecret, config.PaymentWebhookSecret)
+	case "fake":
+		if config.PaymentWebhookSecret == "" {
+			return nil, errors.New("payment webhook secret is required")
+		}
+		return payment.NewFakeProvider(config.PaymentWebhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", config.PaymentProvider)
+	}
+}
+
+// newStorage creates the storage selected by STORAGE_BACKEND, S3 unless it is set to local.
+// Setting AWS_S3_ENDPOINT talks to an S3 compatible server such as MinIO instead of AWS.
+func newStorage(config util.Config) (storage.Storage, error) {
+	switch config.StorageBackend {
+	case "", "s3":
+		return storage.NewS3Storage(storage.S3Config{
+			Bucket:          config.AWSS3Bucket,
+			Region:          config.AWSRegion,
+			AccessKey:       config.AWSAccessKey,
+			SecretKey:       config.AWSSecretKey,
+			Endpoint:        config.AWSS3Endpoint,
+			PresignEndpoint: config.AWSS3PresignEndpoint,
+			PublicURL:       config.StoragePublicURL,
+		})
+	case "local":
+		return storage.NewLocalStorage(config.StorageLocalDir, config.StoragePublicURL)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", config.StorageBackend)
+	}
+}
+
+// maxRequestBodySize is the largest body a request can have, an upload of the largest allowed file
+// with room left for the rest of the multipart form
+func (server *Server) maxRequestBodySize() int64 {
+	maxSize := server.config.UploadMaxImageSize
+	if server.config.UploadMaxAttachmentSize > maxSize {
+		maxSize = server.config.UploadMaxAttachmentSize
+	}
+	return maxSize + 1<<20
+}
+
+// publishEvent publishes event as JSON to the given queue
+func (server *Server) publishEvent(ctx context.Context, queueName string, event interface{}) error {
+	message, err := json.Marshal(event)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer file.Close()
 
-	// Upload the file to S3
-	uploadedURL, err := saveFile(file, fileHeader, server.s3Uploader)
+	publisher, err := messaging.NewPublisher(server.config.RabbitMQURL)
 	if err != nil {
-		return "", err
+		return err
 	}
+	defer publisher.Close()
 
-	return uploadedURL, nil
+	return publisher.PublishMessage(queueName, message, ctx)
 }
 
-func saveFile(fileReader io.Reader, fileHeader *multipart.FileHeader, uploader *s3manager.Uploader) (string, error) {
-	S3Bucket := aws.String("ctt-test-001")
-	// Upload the file to S3 using the provided uploader
-	_, err := uploader.Upload(&s3manager.UploadInput{
-		Bucket: S3Bucket, // Replace with your S3 bucket name
-		Key:    aws.String(fileHeader.Filename),
-		Body:   fileReader,
-	})
+// publicFS hides the private files from the files served by the local storage backend
+type publicFS struct {
+	http.FileSystem
+}
+
+func (fs publicFS) Open(name string) (http.File, error) {
+	name = strings.TrimPrefix(path.Clean(name), "/")
+	if name+"/" == privatePrefix || strings.HasPrefix(name, privatePrefix) {
+		return nil, os.ErrNotExist
+	}
+	return fs.FileSystem.Open("/" + name)
+}
+
+// uploadObject stores generated content such as image variants next to the uploaded files and returns its URL
+func (server *Server) uploadObject(ctx context.Context, key string, contentType string, body io.Reader) (string, error) {
+	err := server.storage.Put(ctx, key, contentType, body)
 	if err != nil {
 		return "", err
 	}
 
-	// Get the URL of the uploaded file
-	url := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", *S3Bucket, fileHeader.Filename) // Replace with your S3 bucket name
+	return server.storage.URL(key), nil
+}
 
-	return url, nil
+// servePrivate streams a private file to a caller that has already been checked to be allowed to see it
+func (server *Server) servePrivate(ctx *gin.Context, key string, contentType string, fileName string) {
+	body, err := server.storage.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer body.Close()
+
+	ctx.DataFromReader(http.StatusOK, -1, contentType, body, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", fileName),
+		"Cache-Control":       "private, no-store",
+	})
 }
 
 func (server *Server) setupRouter() {
 
 	router := gin.Default()
 	router.Use(corsMiddleware())
+
+	// Files kept on the local disk are served by the API itself, except for the private ones
+	if local, ok := server.storage.(*storage.LocalStorage); ok {
+		router.StaticFS(localFilesPath, publicFS{gin.Dir(local.Dir(), false)})
+	}
 	// // Apply CORS middleware
 	// config := cors.DefaultConfig()
 	// config.AllowAllOrigins = true
@@ -121,8 +250,9 @@ func (server *Server) setupRouter() {
 	//Here the endpoint is used to renew access token for user session
 	router.POST("/tokens/renew_access", server.renewAccessToken)
 	// Here we are grouping all the routes and making them protected
-	userAuthRoutes := router.Group("/").Use(userAuthMiddleWare(server.tokenMaker))
-	adminAuthRoutes := router.Group("/").Use(adminAuthMiddleWare(server.tokenMaker))
+	// Mutating requests on protected routes can be retried safely by sending an Idempotency-Key header
+	userAuthRoutes := router.Group("/").Use(userAuthMiddleWare(server.tokenMaker), idempotencyMiddleWare(server.store, server.config.IdempotencyKeyTTL, server.maxRequestBodySize()))
+	adminAuthRoutes := router.Group("/").Use(adminAuthMiddleWare(server.tokenMaker), idempotencyMiddleWare(server.store, server.config.IdempotencyKeyTTL, server.maxRequestBodySize()))
 	// These Routes should be protected as not everyone should have access to it
 	userAuthRoutes.GET("/users/:user_id", server.getUser)
 	router.GET("/users", server.listUser)
@@ -131,28 +261,138 @@ func (server *Server) setupRouter() {
 
 	// Admin can add new admins
 	adminAuthRoutes.POST("/admin/new", server.addAdmin)
+	adminAuthRoutes.GET("/admins", server.listAdmins)
+	// Ranked search across customers, services and order numbers
+	adminAuthRoutes.GET("/search", server.search)
+
+	// Customers and staff are archived instead of deleted, archived accounts cannot log in
+	adminAuthRoutes.DELETE("/users/:user_id", server.archiveUser)
+	adminAuthRoutes.POST("/users/:user_id/restore", server.restoreUser)
+	adminAuthRoutes.DELETE("/admins/:admin_id", server.archiveAdmin)
+	adminAuthRoutes.POST("/admins/:admin_id/restore", server.restoreAdmin)
 
 	// Admin can Create/Add service
 	adminAuthRoutes.POST("/services", server.createService)
 
 	// User Endpoints
 	userAuthRoutes.GET("/services/:service_id", server.getService)
-	userAuthRoutes.GET("/services/user/all", server.listServices)
-	// adminAuthRoutes.GET("/services/admin/all", server.listServices)
+	// Customers browse services through the catalog, grouped by category
+	userAuthRoutes.GET("/catalog", server.getCatalog)
+	userAuthRoutes.GET("/services/:service_id/variants", server.listServiceVariants)
+	userAuthRoutes.GET("/catalog/search", server.searchCatalog)
 
 	//Admin Endpoints
-	userAuthRoutes.GET("/services/preview", server.listServices)
 	adminAuthRoutes.GET("/services", server.listLimitedServices)
+	adminAuthRoutes.PUT("/services/:service_id/category", server.setServiceCategory)
+	adminAuthRoutes.POST("/services/:service_id/variants", server.createServiceVariant)
+	adminAuthRoutes.PUT("/services/:service_id/variants/:variant_id", server.updateServiceVariant)
+	adminAuthRoutes.DELETE("/services/:service_id/variants/:variant_id", server.deleteServiceVariant)
+	adminAuthRoutes.POST("/categories", server.createCategory)
+	adminAuthRoutes.GET("/categories", server.listCategories)
+	adminAuthRoutes.PUT("/categories/:category_id", server.updateCategory)
+	adminAuthRoutes.DELETE("/categories/:category_id", server.deleteCategory)
+	// Updates are partial, PUT is kept for clients that have not moved to PATCH yet
+	adminAuthRoutes.PATCH("/services/:service_id", server.updateService)
 	adminAuthRoutes.PUT("/services/:service_id", server.updateService)
+	// Deleting a service archives it, past orders still resolve it
 	adminAuthRoutes.DELETE("/services/:service_id", server.deleteService)
+	adminAuthRoutes.POST("/services/:service_id/restore", server.restoreService)
+	// Price changes can be scheduled ahead, orders are priced at the price in effect when they are placed
+	adminAuthRoutes.POST("/services/:service_id/prices", server.scheduleServicePrice)
+	adminAuthRoutes.GET("/services/:service_id/prices", server.listServicePrices)
+	adminAuthRoutes.DELETE("/services/:service_id/prices/:price_id", server.cancelServicePrice)
+
+	// Bundles sell several services together for one price and are ordered like services
+	userAuthRoutes.GET("/bundles/:bundle_id", server.getBundle)
+	adminAuthRoutes.POST("/bundles", server.createBundle)
+	adminAuthRoutes.GET("/bundles", server.listBundles)
+	adminAuthRoutes.PUT("/bundles/:bundle_id", server.updateBundle)
+	adminAuthRoutes.DELETE("/bundles/:bundle_id", server.deleteBundle)
 
 	userAuthRoutes.POST("/orders", server.createOrder)
 	adminAuthRoutes.PUT("/orders/status", server.updateOrderStatus)
 	adminAuthRoutes.PUT("/orders/delivery", server.updateOrderDelivered)
 	adminAuthRoutes.GET("/orders/:order_id", server.getOrder)
 	userAuthRoutes.GET("/orders/users/:order_id", server.getOrder)
+	userAuthRoutes.GET("/orders/:order_id/invoice", server.getInvoice)
+	userAuthRoutes.GET("/orders/:order_id/invoice/pdf", server.getInvoicePDF)
 	adminAuthRoutes.GET("/orders", server.listOrders)
 	adminAuthRoutes.GET("/orders/all", server.listAllOrders)
+	// Bulk changes are checked against the order status flow and applied all or nothing
+	adminAuthRoutes.POST("/orders/bulk", server.bulkUpdateOrders)
+	adminAuthRoutes.PUT("/orders/:order_id/schedule", server.rescheduleOrder)
+	userAuthRoutes.GET("/orders/:order_id/history", server.listOrderHistory)
+
+	// Staff assignment, every change is recorded in the order history
+	adminAuthRoutes.POST("/orders/:order_id/assignment", server.assignOrder)
+	adminAuthRoutes.PUT("/orders/:order_id/assignment", server.reassignOrder)
+	adminAuthRoutes.DELETE("/orders/:order_id/assignment", server.unassignOrder)
+	adminAuthRoutes.PUT("/orders/:order_id/priority", server.updateOrderPriority)
+	adminAuthRoutes.GET("/staff/queue", server.listMyQueue)
+
+	// Notes and attachments are shared by staff and customers, what each can see is decided per request
+	userAuthRoutes.POST("/orders/:order_id/notes", server.createOrderNote)
+	userAuthRoutes.GET("/orders/:order_id/notes", server.listOrderNotes)
+	userAuthRoutes.DELETE("/orders/:order_id/notes/:note_id", server.deleteOrderNote)
+	userAuthRoutes.POST("/orders/:order_id/attachments", server.createOrderAttachment)
+	userAuthRoutes.GET("/orders/:order_id/attachments", server.listOrderAttachments)
+	userAuthRoutes.GET("/orders/:order_id/attachments/:attachment_id/file", server.downloadOrderAttachment)
+	userAuthRoutes.DELETE("/orders/:order_id/attachments/:attachment_id", server.deleteOrderAttachment)
+	// Large files go straight to the storage with a presigned URL and are attached once confirmed
+	userAuthRoutes.POST("/uploads", server.createUpload)
+	userAuthRoutes.POST("/uploads/:upload_id/confirm", server.confirmUpload)
+
+	userAuthRoutes.POST("/orders/:order_id/ratings", server.createOrderRating)
+	userAuthRoutes.PUT("/orders/:order_id/ratings/:rating_id", server.updateOrderRating)
+	userAuthRoutes.GET("/orders/:order_id/ratings", server.listOrderRatings)
+	adminAuthRoutes.GET("/ratings", server.listRatings)
+
+	// Recurring orders, the scheduler creates an order on every run of an active subscription
+	userAuthRoutes.POST("/subscriptions", server.createSubscription)
+	userAuthRoutes.GET("/subscriptions", server.listMySubscriptions)
+	adminAuthRoutes.GET("/subscriptions/all", server.listSubscriptions)
+	userAuthRoutes.GET("/subscriptions/:subscription_id", server.getSubscription)
+	userAuthRoutes.POST("/subscriptions/:subscription_id/pause", server.pauseSubscription)
+	userAuthRoutes.POST("/subscriptions/:subscription_id/resume", server.resumeSubscription)
+	userAuthRoutes.POST("/subscriptions/:subscription_id/skip", server.skipSubscriptionRun)
+	userAuthRoutes.DELETE("/subscriptions/:subscription_id", server.cancelSubscription)
+
+	// Branches take orders and can change the price or availability of services, staff limited to
+	// branches only see and work on the orders of their branches
+	userAuthRoutes.GET("/branches", server.listBranches)
+	adminAuthRoutes.POST("/branches", server.createBranch)
+	adminAuthRoutes.PUT("/branches/:branch_id", server.updateBranch)
+	adminAuthRoutes.GET("/branches/:branch_id/services", server.listServiceBranches)
+	adminAuthRoutes.PUT("/branches/:branch_id/services/:service_id", server.setServiceBranch)
+	adminAuthRoutes.DELETE("/branches/:branch_id/services/:service_id", server.deleteServiceBranch)
+	adminAuthRoutes.GET("/admins/:admin_id/branches", server.listAdminBranches)
+	adminAuthRoutes.PUT("/admins/:admin_id/branches", server.setAdminBranches)
+
+	// Customers pick pickup and delivery slots from the available ones when ordering
+	userAuthRoutes.GET("/slots", server.listAvailableTimeSlots)
+	adminAuthRoutes.POST("/slots", server.createTimeSlots)
+	adminAuthRoutes.GET("/slots/utilization", server.listSlotUtilization)
+	adminAuthRoutes.PUT("/slots/:slot_id", server.updateTimeSlot)
+
+	// Imports upsert customers by email and services by name, pass dry_run=true to only check the file
+	adminAuthRoutes.POST("/imports/users", server.importUsers)
+	adminAuthRoutes.POST("/imports/services", server.importServices)
+
+	// Exports take the filters of the matching list endpoint, large ones run in the background
+	adminAuthRoutes.GET("/exports/orders", server.exportOrders)
+	adminAuthRoutes.GET("/exports/users", server.exportUsers)
+	adminAuthRoutes.GET("/exports/services", server.exportServices)
+	adminAuthRoutes.GET("/exports", server.listExportJobs)
+	adminAuthRoutes.GET("/exports/:job_id", server.getExportJob)
+	adminAuthRoutes.GET("/exports/:job_id/download", server.downloadExportJob)
+
+	adminAuthRoutes.POST("/orders/:order_id/payments", server.recordPayment)
+	adminAuthRoutes.POST("/payments/:payment_id/refunds", server.refundPayment)
+	userAuthRoutes.GET("/orders/:order_id/payments", server.listOrderPayments)
+	// Starts the online payment again when it could not be started together with the order
+	userAuthRoutes.POST("/orders/:order_id/payments/intent", server.createOrderPaymentIntent)
+	// Payment gateways call this endpoint, requests are authenticated by their signature
+	router.POST("/payments/webhook", server.handlePaymentWebhook)
 
 	server.router = router
 }
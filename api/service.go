@@ -2,14 +2,25 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"mime/multipart"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
 )
 
+var errServiceNameTaken = errors.New("a service with this name already exists")
+
+// isServiceNameTaken reports whether err is the unique violation of a service name used by another service
+func isServiceNameTaken(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code.Name() == "unique_violation"
+}
+
 // func (server *Server) uploadToS3(fileHeader *multipart.FileHeader) (string, error) {
 // 	file, err := fileHeader.Open()
 // 	if err != nil {
@@ -27,14 +38,22 @@ import (
 // }
 
 type createServiceRequest struct {
-	ServiceName  string                `json:"service_name" binding:"required"`
-	ServicePrice int64                 `json:"service_price" binding:"required"`
-	ServiceImage *multipart.FileHeader `form:"service_image" binding:"required"`
+	ServiceName     string                `json:"service_name" binding:"required"`
+	ServicePrice    int64                 `json:"service_price" binding:"required"`
+	ServiceImage    *multipart.FileHeader `form:"service_image" binding:"required"`
+	TurnaroundHours int32                 `form:"turnaround_hours"`
 }
 
+// createService adds a service to the catalog shared by every branch, only staff that are not limited to branches can add one
 func (server *Server) createService(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
 	// Parse the multipart form data
-	err := ctx.Request.ParseMultipartForm(10 << 20) // 10 MB is the maximum size of the uploaded file
+	maxSize := server.config.UploadMaxImageSize
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+1<<20)
+	err := ctx.Request.ParseMultipartForm(10 << 20) // 10 MB of the form is kept in memory, the rest in temporary files
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
@@ -51,6 +70,16 @@ func (server *Server) createService(ctx *gin.Context) {
 		return
 	}
 
+	// Turnaround is optional and defaults to the standard promise
+	turnaroundHours := int64(util.DefaultTurnaroundHours)
+	if turnaroundStr := ctx.Request.FormValue("turnaround_hours"); turnaroundStr != "" {
+		turnaroundHours, err = strconv.ParseInt(turnaroundStr, 10, 32)
+		if err != nil || turnaroundHours <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "turnaround_hours must be a positive number of hours"})
+			return
+		}
+	}
+
 	// Access the file
 	file, fileHeader, err := ctx.Request.FormFile("service_image")
 	if err != nil {
@@ -59,8 +88,13 @@ func (server *Server) createService(ctx *gin.Context) {
 	}
 	defer file.Close()
 
-	// Upload the image to S3
-	imageURL, err := server.uploadToS3(fileHeader)
+	image, err := readUpload(fileHeader, imageTypes, maxSize, "services")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	imageURL, err := server.storeUpload(ctx, image)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -68,16 +102,24 @@ func (server *Server) createService(ctx *gin.Context) {
 
 	// Create service with imageURL
 	arg := db.CreateServiceParams{
-		ServiceName:  serviceName,
-		ServicePrice: servicePrice,
-		ServiceImage: imageURL,
+		ServiceName:     serviceName,
+		ServicePrice:    servicePrice,
+		ServiceImage:    imageURL,
+		TurnaroundHours: int32(turnaroundHours),
 	}
 
 	service, err := server.store.CreateService(ctx, arg)
 	if err != nil {
+		server.removeUpload(image.Key)
+		if isServiceNameTaken(err) {
+			ctx.JSON(http.StatusConflict, errorResponse(errServiceNameTaken))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+
+	server.queueServiceImage(ctx, service)
 	ctx.JSON(http.StatusOK, service)
 }
 
@@ -97,6 +139,12 @@ func (server *Server) createService(ctx *gin.Context) {
 // 	return uploadedURL, nil
 // }
 
+type serviceResponse struct {
+	db.Service
+	AverageRating float64 `json:"average_rating"`
+	RatingCount   int64   `json:"rating_count"`
+}
+
 type getServiceRequest struct {
 	ServiceID int64 `uri:"service_id" binding:"required,min=1"`
 }
@@ -117,12 +165,25 @@ func (server *Server) getService(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, service)
+
+	ratings, err := server.store.GetServiceRatingSummary(ctx, sql.NullInt32{Int32: service.ServiceID, Valid: true})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, serviceResponse{
+		Service:       service,
+		AverageRating: ratings.AverageRating,
+		RatingCount:   ratings.RatingCount,
+	})
 }
 
 type listServicesRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	PageID          int32 `form:"page_id" binding:"required,min=1"`
+	PageSize        int32 `form:"page_size" binding:"required,min=5,max=10"`
+	IncludeArchived bool  `form:"include_archived"`
+	// BranchID leaves out the services that are not available at the branch
+	BranchID *int32 `form:"branch_id" binding:"omitempty,min=1"`
 }
 
 func (server *Server) listLimitedServices(ctx *gin.Context) {
@@ -133,8 +194,10 @@ func (server *Server) listLimitedServices(ctx *gin.Context) {
 	}
 
 	arg := db.ListLimitedServicesParams{
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		IncludeArchived: req.IncludeArchived,
+		BranchID:        nullInt32(req.BranchID),
+		Limit:           req.PageSize,
+		Offset:          (req.PageID - 1) * req.PageSize,
 	}
 	services, err := server.store.ListLimitedServices(ctx, arg)
 	if err != nil {
@@ -144,31 +207,117 @@ func (server *Server) listLimitedServices(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, services)
 }
 
+type updateServiceURI struct {
+	ServiceID int64 `uri:"service_id" binding:"required,min=1"`
+}
+
+// updateServiceRequest is sent as JSON, or as a multipart form when the image is replaced.
+// Fields that are left out keep their value.
 type updateServiceRequest struct {
-	ServiceID    int64  `uri:"service_id" binding:"required,min=1"`
-	ServiceName  string `json:"service_name" binding:"required"`
-	ServicePrice int64  `json:"service_price" binding:"required"`
-	ServiceImage string `json:"service_image" binding:"required"`
+	ServiceName     *string               `json:"service_name" form:"service_name" binding:"omitempty,min=1"`
+	ServicePrice    *int64                `json:"service_price" form:"service_price" binding:"omitempty,min=0"`
+	TurnaroundHours *int32                `json:"turnaround_hours" form:"turnaround_hours" binding:"omitempty,min=1"`
+	ServiceImage    *multipart.FileHeader `json:"-" form:"service_image"`
+	// Version of the service the changes were made to, see ErrServiceModified
+	Version int32 `json:"version" form:"version" binding:"required,min=1"`
 }
 
 func (server *Server) updateService(ctx *gin.Context) {
+	var uri updateServiceURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	maxSize := server.config.UploadMaxImageSize
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+1<<20)
 	var req updateServiceRequest
-	if err := ctx.ShouldBindUri(&req); err != nil {
+	if err := ctx.ShouldBind(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
-	updateParams := db.UpdateServiceParams{
-		ServiceID:    int32(req.ServiceID),
-		ServiceName:  req.ServiceName,
-		ServicePrice: req.ServicePrice,
-		ServiceImage: req.ServiceImage,
+	if req.ServiceName == nil && req.ServicePrice == nil && req.TurnaroundHours == nil && req.ServiceImage == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "nothing to update"})
+		return
 	}
 
-	service, err := server.store.UpdateService(ctx, updateParams)
+	// Services are shared by every branch, branches change their price with their own overrides
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	previous, err := server.store.GetService(ctx, int32(uri.ServiceID))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	// Checked here as well so a stale request does not upload its image first
+	if previous.Version != req.Version {
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrServiceModified))
+		return
+	}
+
+	arg := db.UpdateServiceParams{
+		ServiceID: previous.ServiceID,
+		Version:   req.Version,
+	}
+	if req.ServiceName != nil {
+		arg.ServiceName = sql.NullString{String: *req.ServiceName, Valid: true}
+	}
+	if req.ServicePrice != nil {
+		arg.ServicePrice = sql.NullInt64{Int64: *req.ServicePrice, Valid: true}
+	}
+	if req.TurnaroundHours != nil {
+		arg.TurnaroundHours = sql.NullInt32{Int32: *req.TurnaroundHours, Valid: true}
+	}
+
+	var image upload
+	if req.ServiceImage != nil {
+		image, err = readUpload(req.ServiceImage, imageTypes, maxSize, "services")
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		imageURL, err := server.storeUpload(ctx, image)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		arg.ServiceImage = sql.NullString{String: imageURL, Valid: true}
+	}
+
+	// A changed price is recorded in the price timeline of the service
+	service, err := server.store.UpdateServiceTx(ctx, arg, sql.NullInt32{Int32: admin.AdminID, Valid: true})
+	if err != nil {
+		if image.Key != "" {
+			server.removeUpload(image.Key)
+		}
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if err == db.ErrServiceModified {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if isServiceNameTaken(err) {
+			ctx.JSON(http.StatusConflict, errorResponse(errServiceNameTaken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.serviceImageReplaced(ctx, previous, service)
 	ctx.JSON(http.StatusOK, service)
 }
 
@@ -176,23 +325,43 @@ type deleteServiceRequest struct {
 	ServiceID int64 `uri:"service_id" binding:"required,min=1"`
 }
 
+// deleteService archives a service, it stays on past orders but can no longer be ordered
 func (server *Server) deleteService(ctx *gin.Context) {
 	var req deleteServiceRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
-	err := server.store.DeleteService(ctx, int32(req.ServiceID))
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+	service, err := server.store.ArchiveService(ctx, int32(req.ServiceID))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, "Service Deleted Successfully")
+	ctx.JSON(http.StatusOK, service)
 }
 
-func (server *Server) listServices(ctx *gin.Context) {
-	service, err := server.store.ListAllServices(ctx)
+func (server *Server) restoreService(ctx *gin.Context) {
+	var req deleteServiceRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+	service, err := server.store.RestoreService(ctx, int32(req.ServiceID))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
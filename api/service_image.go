@@ -0,0 +1,236 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/imaging"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/messaging"
+)
+
+// maxServiceImagePixels keeps the worker from decoding images that would take too much memory,
+// a small file can still declare a huge image
+const maxServiceImagePixels = 50_000_000
+
+// imageWorkerRetryDelay is how long the worker waits before connecting to RabbitMQ again
+const imageWorkerRetryDelay = 10 * time.Second
+
+// imageJobRetryDelay is how long the worker waits before a failed job is put back on the queue
+const imageJobRetryDelay = 30 * time.Second
+
+// serviceImageJob asks the image worker for the variants of the image of a service
+type serviceImageJob struct {
+	ServiceID int32  `json:"service_id"`
+	ImageURL  string `json:"image_url"`
+}
+
+// queueServiceImage asks the image worker for the variants of a service image that has none yet.
+// Images kept anywhere else than the storage, such as imported URLs, are left as they are.
+func (server *Server) queueServiceImage(ctx context.Context, service db.Service) {
+	if service.ImageThumbnail != "" {
+		return
+	}
+	if _, ok := server.storage.Key(service.ServiceImage); !ok {
+		return
+	}
+
+	err := server.publishEvent(ctx, serviceImagesQueue, serviceImageJob{
+		ServiceID: service.ServiceID,
+		ImageURL:  service.ServiceImage,
+	})
+	if err != nil {
+		log.Printf("cannot queue the image of service %d: %v", service.ServiceID, err)
+	}
+}
+
+// serviceImageReplaced removes the objects of the image a service had before and queues the variants of the new one
+func (server *Server) serviceImageReplaced(ctx context.Context, previous, service db.Service) {
+	if previous.ServiceImage == service.ServiceImage {
+		return
+	}
+
+	// Variants belong to a single service, the original can also be used by another one
+	for _, url := range []string{previous.ImageThumbnail, previous.ImageMedium, previous.ImageWebp} {
+		if key, ok := server.storage.Key(url); ok {
+			server.removeUpload(key)
+		}
+	}
+	if key, ok := server.storage.Key(previous.ServiceImage); ok {
+		uses, err := server.store.CountServicesWithImage(ctx, previous.ServiceImage)
+		if err != nil {
+			log.Printf("cannot check the uses of %s: %v", previous.ServiceImage, err)
+		} else if uses == 0 {
+			server.removeUpload(key)
+		}
+	}
+
+	server.queueServiceImage(ctx, service)
+}
+
+// RunImageWorker makes the variants of the service images queued on serviceImagesQueue until ctx is done.
+// It connects to RabbitMQ again when the connection is lost.
+func (server *Server) RunImageWorker(ctx context.Context) {
+	for {
+		err := server.consumeServiceImages(ctx)
+		if err != nil {
+			log.Printf("cannot consume service images: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(imageWorkerRetryDelay):
+		}
+	}
+}
+
+func (server *Server) consumeServiceImages(ctx context.Context) error {
+	consumer, err := messaging.NewConsumer(server.config.RabbitMQURL, serviceImagesQueue)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	// Jobs are acknowledged once they are done, so jobs in progress when the worker stops are delivered again
+	messages, err := consumer.ConsumeMessagesWithAck(serviceImagesQueue, 1)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return errors.New("connection to RabbitMQ was closed")
+			}
+
+			var job serviceImageJob
+			if err := json.Unmarshal(msg.Body, &job); err != nil {
+				log.Printf("cannot parse service image job: %v", err)
+				if err := msg.Nack(false, false); err != nil {
+					return err
+				}
+				continue
+			}
+
+			err := server.makeServiceImageVariants(ctx, job)
+			if err == nil {
+				if err := msg.Ack(false); err != nil {
+					return err
+				}
+				continue
+			}
+			log.Printf("cannot make the image variants of service %d: %v", job.ServiceID, err)
+
+			// A failed job is retried once after a delay, one that fails again is dropped
+			// and the service keeps its original image until the image is replaced
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(imageJobRetryDelay):
+			}
+			if err := msg.Nack(false, !msg.Redelivered); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// makeServiceImageVariants renders and stores the variants of a service image.
+// Jobs for images that have been replaced since, or that already have variants, are skipped.
+func (server *Server) makeServiceImageVariants(ctx context.Context, job serviceImageJob) error {
+	service, err := server.store.GetService(ctx, job.ServiceID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if service.ServiceImage != job.ImageURL || service.ImageThumbnail != "" {
+		return nil
+	}
+
+	key, ok := server.storage.Key(job.ImageURL)
+	if !ok {
+		return nil
+	}
+	img, err := server.readServiceImage(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	// Variants get keys of their own, so a replaced image never shares objects with the new one
+	prefix := fmt.Sprintf("services/%d/%s", service.ServiceID, uuid.New())
+	var keys []string
+	urls := make(map[string]string)
+	for _, variant := range imaging.ServiceImageVariants {
+		data, err := imaging.Render(img, variant)
+		if err == nil {
+			variantKey := fmt.Sprintf("%s-%s%s", prefix, variant.Name, variant.Extension)
+			urls[variant.Name], err = server.uploadObject(ctx, variantKey, variant.ContentType, bytes.NewReader(data))
+			keys = append(keys, variantKey)
+		}
+		if err != nil {
+			for _, key := range keys {
+				server.removeUpload(key)
+			}
+			return err
+		}
+	}
+
+	_, err = server.store.SetServiceImageVariants(ctx, db.SetServiceImageVariantsParams{
+		ServiceID:      service.ServiceID,
+		ServiceImage:   job.ImageURL,
+		ImageThumbnail: urls["thumbnail"],
+		ImageMedium:    urls["medium"],
+		ImageWebp:      urls["webp"],
+	})
+	if err != nil {
+		for _, key := range keys {
+			server.removeUpload(key)
+		}
+		// The image was replaced while its variants were made
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (server *Server) readServiceImage(ctx context.Context, key string) (image.Image, error) {
+	body, err := server.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, server.config.UploadMaxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > server.config.UploadMaxImageSize {
+		return nil, fmt.Errorf("image is larger than %s", formatSize(server.config.UploadMaxImageSize))
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxServiceImagePixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+	return imaging.Decode(bytes.NewReader(data))
+}
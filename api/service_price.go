@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+)
+
+type servicePricesURI struct {
+	ServiceID int32 `uri:"service_id" binding:"required,min=1"`
+}
+
+type servicePriceURI struct {
+	ServiceID int32 `uri:"service_id" binding:"required,min=1"`
+	PriceID   int64 `uri:"price_id" binding:"required,min=1"`
+}
+
+type scheduleServicePriceRequest struct {
+	Price int64 `json:"price" binding:"min=0"`
+	// EffectiveAt defaults to now, a price that is already in effect replaces the service price at once
+	EffectiveAt *time.Time `json:"effective_at"`
+}
+
+func (server *Server) scheduleServicePrice(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var uri servicePricesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req scheduleServicePriceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	effectiveAt := time.Now()
+	if req.EffectiveAt != nil {
+		effectiveAt = *req.EffectiveAt
+	}
+
+	result, err := server.store.SetServicePriceTx(ctx, db.SetServicePriceTxParams{
+		ServiceID:   uri.ServiceID,
+		Price:       req.Price,
+		EffectiveAt: effectiveAt,
+		CreatedBy:   sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+type servicePriceTimeline struct {
+	ServiceID int32                    `json:"service_id"`
+	Price     int64                    `json:"price"`
+	Prices    []db.ServicePriceHistory `json:"prices"`
+}
+
+// listServicePrices returns the price in effect now and every past and scheduled price of a service
+func (server *Server) listServicePrices(ctx *gin.Context) {
+	if _, err := server.currentAdmin(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	var uri servicePricesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	price, err := server.store.GetEffectiveServicePrice(ctx, db.GetEffectiveServicePriceParams{
+		At:        time.Now(),
+		ServiceID: uri.ServiceID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	prices, err := server.store.ListServicePrices(ctx, uri.ServiceID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, servicePriceTimeline{
+		ServiceID: uri.ServiceID,
+		Price:     price,
+		Prices:    prices,
+	})
+}
+
+// cancelServicePrice removes a price change that has not taken effect yet
+func (server *Server) cancelServicePrice(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var uri servicePriceURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	price, err := server.store.GetServicePrice(ctx, uri.PriceID)
+	if err == nil && price.ServiceID != uri.ServiceID {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	price, err = server.store.DeleteScheduledServicePrice(ctx, uri.PriceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := errors.New("price is already in effect, schedule a new price instead")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, price)
+}
+
+// RunPriceScheduler applies scheduled price changes to the services they are due for every
+// PRICE_CHECK_INTERVAL until ctx is done. Orders resolve the effective price themselves,
+// this keeps the prices shown in listings in step.
+func (server *Server) RunPriceScheduler(ctx context.Context) {
+	ticker := time.NewTicker(server.config.PriceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		services, err := server.store.ApplyDueServicePrices(ctx)
+		if err != nil {
+			log.Printf("cannot apply scheduled prices: %v", err)
+		}
+		for _, service := range services {
+			log.Printf("price of service %d is now %d", service.ServiceID, service.ServicePrice)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
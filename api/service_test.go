@@ -0,0 +1,74 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/token"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestService(t *testing.T) db.Service {
+	service, err := testStore.CreateService(context.Background(), db.CreateServiceParams{
+		ServiceName:     "Service " + util.RandomString(10),
+		ServicePrice:    int64(util.RandomPrice()),
+		TurnaroundHours: 24,
+	})
+	require.NoError(t, err)
+	return service
+}
+
+func TestUpdateServiceNameTaken(t *testing.T) {
+	server := newTestServer(t)
+	server.config.UploadMaxImageSize = 1 << 20
+	admin := createTestAdmin(t)
+
+	taken := createTestService(t)
+	service := createTestService(t)
+
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewNRGBA(image.Rect(0, 0, 4, 4))))
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("service_name", taken.ServiceName))
+	require.NoError(t, writer.WriteField("version", strconv.Itoa(int(service.Version))))
+	part, err := writer.CreateFormFile("service_image", "photo.png")
+	require.NoError(t, err)
+	_, err = part.Write(img.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	url := fmt.Sprintf("/services/%d", service.ServiceID)
+	request, err := http.NewRequest(http.MethodPatch, url, &body)
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	addAuthorization(t, request, server, admin.Email, token.AccountAdmin, admin.AdminID)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	// The image uploaded with the rejected change is removed
+	var files []string
+	err = filepath.WalkDir(server.config.StorageLocalDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	})
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+type serviceVariantsURI struct {
+	ServiceID int32 `uri:"service_id" binding:"required,min=1"`
+}
+
+type serviceVariantURI struct {
+	ServiceID int32 `uri:"service_id" binding:"required,min=1"`
+	VariantID int32 `uri:"variant_id" binding:"required,min=1"`
+}
+
+type serviceVariantRequest struct {
+	Name  string `json:"name" binding:"required"`
+	SKU   string `json:"sku" binding:"required"`
+	Price int64  `json:"price" binding:"min=0"`
+	// TurnaroundHours defaults to the standard promise
+	TurnaroundHours int32 `json:"turnaround_hours" binding:"omitempty,min=1"`
+}
+
+func (server *Server) createServiceVariant(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var uri serviceVariantsURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req serviceVariantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetService(ctx, uri.ServiceID); err != nil {
+		serviceVariantError(ctx, err)
+		return
+	}
+
+	turnaroundHours := req.TurnaroundHours
+	if turnaroundHours == 0 {
+		turnaroundHours = util.DefaultTurnaroundHours
+	}
+
+	variant, err := server.store.CreateServiceVariant(ctx, db.CreateServiceVariantParams{
+		ServiceID:       uri.ServiceID,
+		Name:            req.Name,
+		Sku:             req.SKU,
+		Price:           req.Price,
+		TurnaroundHours: turnaroundHours,
+	})
+	if err != nil {
+		serviceVariantError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, variant)
+}
+
+func (server *Server) listServiceVariants(ctx *gin.Context) {
+	var uri serviceVariantsURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetService(ctx, uri.ServiceID); err != nil {
+		serviceVariantError(ctx, err)
+		return
+	}
+
+	variants, err := server.store.ListServiceVariants(ctx, uri.ServiceID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, variants)
+}
+
+// variantByURI loads the variant in the URI and checks it belongs to the service in the URI
+func (server *Server) variantByURI(ctx *gin.Context) (db.ServiceVariant, bool) {
+	var uri serviceVariantURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.ServiceVariant{}, false
+	}
+
+	variant, err := server.store.GetServiceVariant(ctx, uri.VariantID)
+	if err == nil && variant.ServiceID != uri.ServiceID {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		serviceVariantError(ctx, err)
+		return db.ServiceVariant{}, false
+	}
+	return variant, true
+}
+
+// updateServiceVariant changes a variant, orders already placed keep the price they were placed at
+func (server *Server) updateServiceVariant(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	variant, ok := server.variantByURI(ctx)
+	if !ok {
+		return
+	}
+
+	var req serviceVariantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	turnaroundHours := req.TurnaroundHours
+	if turnaroundHours == 0 {
+		turnaroundHours = variant.TurnaroundHours
+	}
+
+	variant, err := server.store.UpdateServiceVariant(ctx, db.UpdateServiceVariantParams{
+		VariantID:       variant.VariantID,
+		Name:            req.Name,
+		Sku:             req.SKU,
+		Price:           req.Price,
+		TurnaroundHours: turnaroundHours,
+	})
+	if err != nil {
+		serviceVariantError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, variant)
+}
+
+// deleteServiceVariant deletes a variant that was never ordered
+func (server *Server) deleteServiceVariant(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	variant, ok := server.variantByURI(ctx)
+	if !ok {
+		return
+	}
+
+	err := server.store.DeleteServiceVariant(ctx, variant.VariantID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			err := errors.New("variant has been ordered and cannot be deleted")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// serviceVariantError maps the errors of writing a variant to a response
+func serviceVariantError(ctx *gin.Context, err error) {
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+		err := errors.New("a variant with this name or sku already exists")
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/messaging"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+type slaEvent struct {
+	Event      string    `json:"event"`
+	OrderID    int64     `json:"order_id"`
+	UserID     int32     `json:"user_id"`
+	DueAt      time.Time `json:"due_at"`
+	AssignedTo int32     `json:"assigned_to,omitempty"`
+}
+
+// RunSLAChecker flags open orders that are at risk of missing their due time or are overdue
+// every SLA_CHECK_INTERVAL until ctx is done
+func (server *Server) RunSLAChecker(ctx context.Context) {
+	ticker := time.NewTicker(server.config.SLACheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := server.checkSLA(ctx, time.Now()); err != nil {
+			log.Printf("cannot check order deadlines: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkSLA moves orders to the at risk and overdue states and publishes an event for every
+// order that changed state. The events are published before the change is committed, so an
+// order whose event could not be published is flagged again on the next check.
+func (server *Server) checkSLA(ctx context.Context, now time.Time) error {
+	return server.store.FlagSLATx(ctx, db.FlagSLATxParams{
+		Now:          now,
+		AtRiskBefore: now.Add(server.config.SLAAtRiskWindow),
+		Notify: func(atRisk, overdue []db.Order) error {
+			return server.publishSLAEvents(ctx, slaEvents(atRisk, overdue))
+		},
+	})
+}
+
+// publishSLAEvents publishes the events over a single connection
+func (server *Server) publishSLAEvents(ctx context.Context, events []slaEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	publisher, err := messaging.NewPublisher(server.config.RabbitMQURL)
+	if err != nil {
+		return err
+	}
+	defer publisher.Close()
+
+	for _, event := range events {
+		message, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if err := publisher.PublishMessage(slaEventsQueue, message, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slaEvents builds one event per order, as every order has a row for each of its services
+func slaEvents(atRisk, overdue []db.Order) []slaEvent {
+	var events []slaEvent
+	seen := make(map[int64]bool)
+	add := func(orders []db.Order, state string) {
+		for _, order := range orders {
+			if seen[order.OrderID] {
+				continue
+			}
+			seen[order.OrderID] = true
+			events = append(events, slaEvent{
+				Event:      "order." + state,
+				OrderID:    order.OrderID,
+				UserID:     order.UserID,
+				DueAt:      order.DueAt.Time,
+				AssignedTo: order.AssignedTo.Int32,
+			})
+		}
+	}
+	add(atRisk, util.SLAAtRisk)
+	add(overdue, util.SLAOverdue)
+	return events
+}
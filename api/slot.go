@@ -0,0 +1,362 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// maxSlotRangeDays limits how many days of slots can be generated or listed at once
+	maxSlotRangeDays = 92
+)
+
+// parseDateRange parses the from and to dates in the server time zone. A missing
+// from date means today and a missing to date means a week after the from date.
+func (server *Server) parseDateRange(from, to string) (time.Time, time.Time, error) {
+	now := time.Now().In(server.location)
+	fromDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, server.location)
+	if from != "" {
+		var err error
+		fromDate, err = time.ParseInLocation(dateLayout, from, server.location)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from_date: %w", err)
+		}
+	}
+
+	toDate := fromDate.AddDate(0, 0, 6)
+	if to != "" {
+		var err error
+		toDate, err = time.ParseInLocation(dateLayout, to, server.location)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to_date: %w", err)
+		}
+	}
+
+	if toDate.Before(fromDate) {
+		return time.Time{}, time.Time{}, errors.New("to_date must not be before from_date")
+	}
+	if toDate.Sub(fromDate) > maxSlotRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("date range cannot be longer than %d days", maxSlotRangeDays)
+	}
+	return fromDate, toDate, nil
+}
+
+type createTimeSlotsRequest struct {
+	SlotType string `json:"slot_type" binding:"required,oneof=pickup delivery"`
+	FromDate string `json:"from_date" binding:"required"`
+	ToDate   string `json:"to_date" binding:"required"`
+	// Windows are the daily slots such as "09:00-11:00", in the server time zone
+	Windows  []string `json:"windows" binding:"required,min=1"`
+	Capacity int32    `json:"capacity" binding:"required,min=1"`
+}
+
+// createTimeSlots generates slots for every window on every day of the range.
+// Slots that already exist are left untouched, so the same range can be generated again safely.
+// Slots are shared by every branch, so only staff that are not limited to branches can create them.
+func (server *Server) createTimeSlots(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var req createTimeSlotsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	from, to, err := server.parseDateRange(req.FromDate, req.ToDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	windows := make([]util.SlotWindow, len(req.Windows))
+	for i, w := range req.Windows {
+		windows[i], err = util.ParseSlotWindow(w)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	slots := []db.TimeSlot{}
+	for _, slotTime := range util.SlotTimes(from, to, windows, server.location) {
+		slot, err := server.store.CreateTimeSlot(ctx, db.CreateTimeSlotParams{
+			SlotType: req.SlotType,
+			StartsAt: slotTime.StartsAt,
+			EndsAt:   slotTime.EndsAt,
+			Capacity: req.Capacity,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		slots = append(slots, slot)
+	}
+
+	ctx.JSON(http.StatusOK, slots)
+}
+
+type listAvailableTimeSlotsRequest struct {
+	SlotType string `form:"slot_type" binding:"required,oneof=pickup delivery"`
+	FromDate string `form:"from_date"`
+	ToDate   string `form:"to_date"`
+}
+
+func (server *Server) listAvailableTimeSlots(ctx *gin.Context) {
+	var req listAvailableTimeSlotsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	from, to, err := server.parseDateRange(req.FromDate, req.ToDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	slots, err := server.store.ListAvailableTimeSlots(ctx, db.ListAvailableTimeSlotsParams{
+		SlotType: req.SlotType,
+		FromTime: from,
+		ToTime:   to.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, slots)
+}
+
+type listSlotUtilizationRequest struct {
+	SlotType string `form:"slot_type" binding:"omitempty,oneof=pickup delivery"`
+	FromDate string `form:"from_date"`
+	ToDate   string `form:"to_date"`
+}
+
+type slotUtilizationResponse struct {
+	db.TimeSlot
+	Available int32 `json:"available"`
+	// Utilization is the booked share of the capacity in percent
+	Utilization float64 `json:"utilization"`
+}
+
+func (server *Server) listSlotUtilization(ctx *gin.Context) {
+	if _, err := server.currentAdmin(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	var req listSlotUtilizationRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	from, to, err := server.parseDateRange(req.FromDate, req.ToDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	slots, err := server.store.ListTimeSlots(ctx, db.ListTimeSlotsParams{
+		FromTime: from,
+		ToTime:   to.AddDate(0, 0, 1),
+		SlotType: sql.NullString{String: req.SlotType, Valid: req.SlotType != ""},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := make([]slotUtilizationResponse, len(slots))
+	for i, slot := range slots {
+		response[i] = slotUtilizationResponse{
+			TimeSlot:    slot,
+			Available:   slot.Capacity - slot.Booked,
+			Utilization: float64(slot.Booked) * 100 / float64(slot.Capacity),
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+type timeSlotURI struct {
+	SlotID int32 `uri:"slot_id" binding:"required,min=1"`
+}
+
+type updateTimeSlotRequest struct {
+	Capacity int32 `json:"capacity" binding:"required,min=1"`
+}
+
+func (server *Server) updateTimeSlot(ctx *gin.Context) {
+	if !server.requireHeadOffice(ctx) {
+		return
+	}
+
+	var uri timeSlotURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateTimeSlotRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	slot, err := server.store.GetTimeSlot(ctx, uri.SlotID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if req.Capacity < slot.Booked {
+		err := fmt.Errorf("capacity cannot be lower than the %d orders already booked", slot.Booked)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	slot, err = server.store.UpdateTimeSlotCapacity(ctx, db.UpdateTimeSlotCapacityParams{
+		SlotID:   uri.SlotID,
+		Capacity: req.Capacity,
+	})
+	if err != nil {
+		// An order can book the slot between the check above and the update, the
+		// table constraint then keeps the capacity from dropping below the bookings
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "check_violation" {
+			err := errors.New("the slot was booked in the meantime, capacity cannot be lower than its bookings")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, slot)
+}
+
+type rescheduleOrderRequest struct {
+	PickupSlotID   *int32 `json:"pickup_slot_id" binding:"omitempty,min=1"`
+	DeliverySlotID *int32 `json:"delivery_slot_id" binding:"omitempty,min=1"`
+}
+
+func (server *Server) rescheduleOrder(ctx *gin.Context) {
+	var uri getOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req rescheduleOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.PickupSlotID == nil && req.DeliverySlotID == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "pickup_slot_id or delivery_slot_id is required"})
+		return
+	}
+	if !server.checkOrderBranch(ctx, uri.OrderID) {
+		return
+	}
+
+	admin, err := server.currentAdmin(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.RescheduleOrderTx(ctx, db.RescheduleOrderTxParams{
+		OrderID:        uri.OrderID,
+		PickupSlotID:   nullInt32(req.PickupSlotID),
+		DeliverySlotID: nullInt32(req.DeliverySlotID),
+		ChangedBy:      sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrSlotUnavailable), errors.Is(err, db.ErrInvalidSchedule), errors.Is(err, db.ErrOrderDelivered):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (server *Server) listOrderHistory(ctx *gin.Context) {
+	var req getOrderRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	orders, err := server.store.GetOrder(ctx, req.OrderID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if len(orders) == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+
+	allowed, err := server.canAccessOrder(ctx, orders[0].UserID, orders[0].BranchID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !allowed {
+		err := errors.New("order does not belong to the current customer")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	history, err := server.store.ListOrderHistory(ctx, req.OrderID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, history)
+}
+
+// nullInt32 converts an optional request field to its database value
+func nullInt32(v *int32) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: *v, Valid: true}
+}
+
+// nullInt64 converts an optional request field to its database value
+func nullInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
+
+// optionalInt32 is the reverse of nullInt32 for responses
+func optionalInt32(v sql.NullInt32) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
@@ -0,0 +1,374 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+const (
+	// subscriptionBatchSize is how many due subscriptions are run on one pass of the scheduler
+	subscriptionBatchSize = 100
+	// subscriptionMaxAttempts is how often a run is tried before it is recorded as failed
+	subscriptionMaxAttempts = 3
+	// subscriptionRunsShown is how many past runs are returned with a subscription
+	subscriptionRunsShown = 20
+)
+
+type subscriptionEvent struct {
+	Event          string `json:"event"`
+	SubscriptionID int64  `json:"subscription_id"`
+	UserID         int32  `json:"user_id"`
+	OrderID        int64  `json:"order_id,omitempty"`
+	Error          string `json:"error,omitempty"`
+	// Attempt is set on failures, the run is given up after subscriptionMaxAttempts
+	Attempt int32 `json:"attempt,omitempty"`
+}
+
+type createSubscriptionRequest struct {
+	ServiceIDs []int32 `json:"service_ids" binding:"required,min=1,dive,min=1"`
+	Frequency  string  `json:"frequency" binding:"required,oneof=weekly biweekly monthly cron"`
+	CronExpr   string  `json:"cron_expr"`
+	// FirstRunAt defaults to the first run counted from now
+	FirstRunAt *time.Time `json:"first_run_at"`
+	// BranchID defaults to the home branch of the customer, every run is ordered there
+	BranchID *int32 `json:"branch_id" binding:"omitempty,min=1"`
+}
+
+func (server *Server) createSubscription(ctx *gin.Context) {
+	var req createSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Frequency != util.FrequencyCron {
+		req.CronExpr = ""
+	}
+	if err := util.ValidateSchedule(req.Frequency, req.CronExpr); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.currentUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	branchID, err := server.orderBranch(ctx, req.BranchID, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	now := time.Now()
+	var nextRunAt time.Time
+	// Monthly subscriptions keep the day of the month they start on
+	anchorAt := now
+	if req.FirstRunAt != nil {
+		if !req.FirstRunAt.After(now) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "first_run_at must be in the future"})
+			return
+		}
+		nextRunAt = *req.FirstRunAt
+		anchorAt = *req.FirstRunAt
+	} else {
+		nextRunAt, err = util.NextRun(req.Frequency, req.CronExpr, now, anchorAt, server.location)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	result, err := server.store.CreateSubscriptionTx(ctx, db.CreateSubscriptionTxParams{
+		UserID:     user.UserID,
+		BranchID:   branchID,
+		Frequency:  req.Frequency,
+		CronExpr:   req.CronExpr,
+		NextRunAt:  nextRunAt,
+		AnchorAt:   anchorAt,
+		ServiceIDs: req.ServiceIDs,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			err := errors.New("the branch or one of the services does not exist")
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrServiceArchived) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (server *Server) listMySubscriptions(ctx *gin.Context) {
+	user, err := server.currentUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	subscriptions, err := server.store.ListSubscriptionsByUser(ctx, user.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, subscriptions)
+}
+
+type listSubscriptionsRequest struct {
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=50"`
+	BranchID *int32 `form:"branch_id" binding:"omitempty,min=1"`
+}
+
+func (server *Server) listSubscriptions(ctx *gin.Context) {
+	var req listSubscriptionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	branchIDs, ok := server.orderBranches(ctx, req.BranchID)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := server.store.ListSubscriptions(ctx, db.ListSubscriptionsParams{
+		BranchIds: branchIDs,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, subscriptions)
+}
+
+type subscriptionURI struct {
+	SubscriptionID int64 `uri:"subscription_id" binding:"required,min=1"`
+}
+
+// subscriptionByURI loads the subscription in the URI and checks the caller is staff or the
+// customer it belongs to. It writes the error response and returns false when the request cannot go on.
+func (server *Server) subscriptionByURI(ctx *gin.Context) (db.Subscription, bool) {
+	var uri subscriptionURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Subscription{}, false
+	}
+
+	sub, err := server.store.GetSubscription(ctx, uri.SubscriptionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return db.Subscription{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.Subscription{}, false
+	}
+
+	allowed, err := server.canAccessOrder(ctx, sub.UserID, sub.BranchID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.Subscription{}, false
+	}
+	if !allowed {
+		err := errors.New("subscription does not belong to the current customer")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return db.Subscription{}, false
+	}
+	return sub, true
+}
+
+type subscriptionResponse struct {
+	db.Subscription
+	Services []db.SubscriptionService `json:"services"`
+	Runs     []db.SubscriptionRun     `json:"runs"`
+}
+
+func (server *Server) getSubscription(ctx *gin.Context) {
+	sub, ok := server.subscriptionByURI(ctx)
+	if !ok {
+		return
+	}
+
+	services, err := server.store.ListSubscriptionServices(ctx, sub.SubscriptionID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	runs, err := server.store.ListSubscriptionRuns(ctx, db.ListSubscriptionRunsParams{
+		SubscriptionID: sub.SubscriptionID,
+		Limit:          subscriptionRunsShown,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subscriptionResponse{
+		Subscription: sub,
+		Services:     services,
+		Runs:         runs,
+	})
+}
+
+func (server *Server) pauseSubscription(ctx *gin.Context) {
+	server.setSubscriptionStatus(ctx, util.SubscriptionPaused)
+}
+
+func (server *Server) resumeSubscription(ctx *gin.Context) {
+	server.setSubscriptionStatus(ctx, util.SubscriptionActive)
+}
+
+func (server *Server) cancelSubscription(ctx *gin.Context) {
+	server.setSubscriptionStatus(ctx, util.SubscriptionCancelled)
+}
+
+func (server *Server) setSubscriptionStatus(ctx *gin.Context, status string) {
+	sub, ok := server.subscriptionByURI(ctx)
+	if !ok {
+		return
+	}
+
+	sub, err := server.store.SetSubscriptionStatusTx(ctx, db.SetSubscriptionStatusTxParams{
+		SubscriptionID: sub.SubscriptionID,
+		Status:         status,
+		Now:            time.Now(),
+		Location:       server.location,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrSubscriptionCancelled) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, sub)
+}
+
+// skipSubscriptionRun skips the next run only, the subscription carries on after it
+func (server *Server) skipSubscriptionRun(ctx *gin.Context) {
+	sub, ok := server.subscriptionByURI(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.SkipSubscriptionRunTx(ctx, db.SkipSubscriptionRunTxParams{
+		SubscriptionID: sub.SubscriptionID,
+		Location:       server.location,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrSubscriptionCancelled) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// RunSubscriptionScheduler creates the orders of due subscriptions every
+// SUBSCRIPTION_CHECK_INTERVAL until ctx is done
+func (server *Server) RunSubscriptionScheduler(ctx context.Context) {
+	ticker := time.NewTicker(server.config.SubscriptionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := server.runDueSubscriptions(ctx, time.Now()); err != nil {
+			log.Printf("cannot run due subscriptions: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDueSubscriptions creates an order for every due subscription. A subscription that fails
+// is retried on the following passes and the customer is notified of every failure.
+func (server *Server) runDueSubscriptions(ctx context.Context, now time.Time) error {
+	due, err := server.store.ListDueSubscriptions(ctx, db.ListDueSubscriptionsParams{
+		Now:   now,
+		Limit: subscriptionBatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range due {
+		result, err := server.store.RunSubscriptionTx(ctx, db.RunSubscriptionTxParams{
+			SubscriptionID: sub.SubscriptionID,
+			Now:            now,
+			Location:       server.location,
+		})
+		if err != nil {
+			// Another scheduler ran it, or it was paused in the meantime
+			if errors.Is(err, db.ErrSubscriptionNotDue) {
+				continue
+			}
+			server.recordSubscriptionFailure(ctx, sub, err, now)
+			continue
+		}
+
+		err = server.publishEvent(ctx, subscriptionEventsQueue, subscriptionEvent{
+			Event:          "subscription.order_created",
+			SubscriptionID: sub.SubscriptionID,
+			UserID:         sub.UserID,
+			OrderID:        result.Run.OrderID.Int64,
+		})
+		if err != nil {
+			log.Printf("cannot publish event for subscription %d: %v", sub.SubscriptionID, err)
+		}
+	}
+	return nil
+}
+
+func (server *Server) recordSubscriptionFailure(ctx context.Context, sub db.Subscription, runErr error, now time.Time) {
+	log.Printf("cannot create order for subscription %d: %v", sub.SubscriptionID, runErr)
+
+	result, err := server.store.RecordSubscriptionFailureTx(ctx, db.RecordSubscriptionFailureTxParams{
+		SubscriptionID: sub.SubscriptionID,
+		Error:          runErr.Error(),
+		MaxAttempts:    subscriptionMaxAttempts,
+		Now:            now,
+		Location:       server.location,
+	})
+	if err != nil {
+		log.Printf("cannot record failure of subscription %d: %v", sub.SubscriptionID, err)
+		return
+	}
+
+	event := subscriptionEvent{
+		Event:          "subscription.order_failed",
+		SubscriptionID: sub.SubscriptionID,
+		UserID:         sub.UserID,
+		Error:          runErr.Error(),
+		Attempt:        result.Subscription.FailureCount,
+	}
+	if result.GaveUp {
+		event.Event = "subscription.run_failed"
+		event.Attempt = subscriptionMaxAttempts
+	}
+	if err := server.publishEvent(ctx, subscriptionEventsQueue, event); err != nil {
+		log.Printf("cannot publish event for subscription %d: %v", sub.SubscriptionID, err)
+	}
+}
@@ -67,6 +67,8 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		refreshPayload.Email,
+		refreshPayload.AccountKind,
+		refreshPayload.AccountID,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
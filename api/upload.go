@@ -0,0 +1,467 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/storage"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+)
+
+// imageTypes are the content types accepted for images, with the extension they are stored under
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// upload is a file received from a client that has been checked and is ready to be stored
+type upload struct {
+	// Key is generated, the name the client sent is only kept for display
+	Key         string
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// readUpload reads a file sent to the API in a multipart form, see newUpload
+func readUpload(fileHeader *multipart.FileHeader, allowedTypes map[string]string, maxSize int64, prefix string) (upload, error) {
+	if fileHeader.Size > maxSize {
+		return upload{}, fmt.Errorf("files cannot be larger than %s", formatSize(maxSize))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return upload{}, err
+	}
+	defer file.Close()
+
+	return newUpload(file, fileHeader.Filename, allowedTypes, maxSize, prefix)
+}
+
+// newUpload reads a file no larger than maxSize, sniffs its content type from the file itself rather than
+// trusting the client and strips the metadata of images. The file is keyed under prefix by a random UUID,
+// so uploads never overwrite each other and client file names never end up in keys.
+func newUpload(r io.Reader, fileName string, allowedTypes map[string]string, maxSize int64, prefix string) (upload, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return upload{}, err
+	}
+	if int64(len(data)) > maxSize {
+		return upload{}, fmt.Errorf("files cannot be larger than %s", formatSize(maxSize))
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return upload{}, fmt.Errorf("files of type %s are not allowed", contentType)
+	}
+
+	data, err = util.StripImageMetadata(data, contentType)
+	if err != nil {
+		return upload{}, fmt.Errorf("cannot read the image: %w", err)
+	}
+
+	return upload{
+		Key:         fmt.Sprintf("%s/%s%s", prefix, uuid.New(), ext),
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		Data:        data,
+	}, nil
+}
+
+// storeUpload stores an upload and returns its URL, a partly stored upload is removed again
+func (server *Server) storeUpload(ctx context.Context, file upload) (string, error) {
+	url, err := server.uploadObject(ctx, file.Key, file.ContentType, bytes.NewReader(file.Data))
+	if err != nil {
+		server.removeUpload(file.Key)
+		return "", err
+	}
+	return url, nil
+}
+
+// removeUpload deletes a stored upload that ended up unused, such as the image of a service that could not be saved.
+// It outlives the request, which may have been cancelled.
+func (server *Server) removeUpload(key string) {
+	if err := server.storage.Delete(context.Background(), key); err != nil {
+		log.Printf("cannot remove unused upload %s: %v", key, err)
+	}
+}
+
+const (
+	// uploadSweepInterval is how often expired uploads that were never confirmed are removed
+	uploadSweepInterval = time.Hour
+	uploadSweepBatch    = 100
+)
+
+// RunUploadSweeper removes uploads that expired before they were confirmed, with their files, until ctx is done
+func (server *Server) RunUploadSweeper(ctx context.Context) {
+	ticker := time.NewTicker(uploadSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := server.sweepExpiredUploads(ctx)
+		if err != nil {
+			log.Printf("cannot remove expired uploads: %v", err)
+		} else if deleted > 0 {
+			log.Printf("removed %d expired uploads", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweepExpiredUploads removes the file of each expired upload before its row, so a file that cannot be
+// removed is tried again on the next sweep
+func (server *Server) sweepExpiredUploads(ctx context.Context) (int, error) {
+	deleted := 0
+	for {
+		uploads, err := server.store.ListExpiredUploads(ctx, db.ListExpiredUploadsParams{
+			ExpiredBefore: time.Now(),
+			Limit:         uploadSweepBatch,
+		})
+		if err != nil {
+			return deleted, err
+		}
+
+		for _, expired := range uploads {
+			if err := server.storage.Delete(ctx, expired.ObjectKey); err != nil {
+				return deleted, err
+			}
+			if err := server.store.DeleteUpload(ctx, expired.UploadID); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+		if len(uploads) < uploadSweepBatch {
+			return deleted, nil
+		}
+	}
+}
+
+func formatSize(size int64) string {
+	if size >= 1<<20 && size%(1<<20) == 0 {
+		return fmt.Sprintf("%d MB", size>>20)
+	}
+	if size >= 1<<10 && size%(1<<10) == 0 {
+		return fmt.Sprintf("%d KB", size>>10)
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
+
+// uploadRules returns the content types and the largest size accepted for uploads of a purpose
+func (server *Server) uploadRules(purpose string) (map[string]string, int64) {
+	if purpose == util.UploadOrderAttachment {
+		return attachmentTypes, server.config.UploadMaxAttachmentSize
+	}
+	return imageTypes, server.config.UploadMaxImageSize
+}
+
+type createUploadRequest struct {
+	Purpose     string `json:"purpose" binding:"required,oneof=service_image order_attachment profile_image"`
+	FileName    string `json:"file_name" binding:"required,max=255"`
+	ContentType string `json:"content_type" binding:"required"`
+	SizeBytes   int64  `json:"size_bytes" binding:"required,min=1"`
+}
+
+type createUploadResponse struct {
+	UploadID  uuid.UUID `json:"upload_id"`
+	UploadURL string    `json:"upload_url"`
+	Method    string    `json:"method"`
+	// Headers have to be sent with the upload as they are, the URL is signed for them
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// createUpload issues a presigned URL clients upload a file to straight away, without sending it through the API.
+// The file is checked and attached once the client confirms the upload.
+func (server *Server) createUpload(ctx *gin.Context) {
+	presigner, ok := server.storage.(storage.Presigner)
+	if !ok {
+		err := errors.New("direct uploads are not supported by the storage backend")
+		ctx.JSON(http.StatusNotImplemented, errorResponse(err))
+		return
+	}
+
+	var req createUploadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	c, err := server.currentCaller(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if req.Purpose == util.UploadServiceImage {
+		if !c.isStaff() {
+			err := errors.New("only staff can upload service images")
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		// Services are shared by every branch, as in updateService
+		if !server.requireHeadOffice(ctx) {
+			return
+		}
+	}
+
+	allowedTypes, maxSize := server.uploadRules(req.Purpose)
+	ext, ok := allowedTypes[req.ContentType]
+	if !ok {
+		err := fmt.Errorf("files of type %s are not allowed", req.ContentType)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.SizeBytes > maxSize {
+		err := fmt.Errorf("files cannot be larger than %s", formatSize(maxSize))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Files wait under uploads/ until they are confirmed, unconfirmed ones are removed by RunUploadSweeper
+	uploadID := uuid.New()
+	key := fmt.Sprintf("uploads/%s%s", uploadID, ext)
+	uploadURL, err := presigner.PresignPut(key, req.ContentType, req.SizeBytes, server.config.UploadURLExpiry)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	adminID, userID := c.authorID()
+	upload, err := server.store.CreateUpload(ctx, db.CreateUploadParams{
+		UploadID:    uploadID,
+		ObjectKey:   key,
+		FileName:    filepath.Base(req.FileName),
+		ContentType: req.ContentType,
+		SizeBytes:   req.SizeBytes,
+		Purpose:     req.Purpose,
+		AdminID:     adminID,
+		UserID:      userID,
+		ExpiresAt:   time.Now().Add(server.config.UploadURLExpiry),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createUploadResponse{
+		UploadID:  upload.UploadID,
+		UploadURL: uploadURL,
+		Method:    http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type":   upload.ContentType,
+			"Content-Length": strconv.FormatInt(upload.SizeBytes, 10),
+		},
+		ExpiresAt: upload.ExpiresAt,
+	})
+}
+
+type uploadURI struct {
+	UploadID string `uri:"upload_id" binding:"required,uuid"`
+}
+
+// confirmUploadRequest names what the upload is attached to, profile images need nothing.
+// Version is the version of the service a service image replaces the image of, see ErrServiceModified.
+type confirmUploadRequest struct {
+	ServiceID  int32  `json:"service_id"`
+	Version    int32  `json:"version"`
+	OrderID    int64  `json:"order_id"`
+	NoteID     *int64 `json:"note_id"`
+	Visibility string `json:"visibility"`
+}
+
+type confirmUploadResponse struct {
+	Upload     db.Upload           `json:"upload"`
+	Service    *db.Service         `json:"service,omitempty"`
+	Attachment *db.OrderAttachment `json:"attachment,omitempty"`
+	User       *userResponse       `json:"user,omitempty"`
+	Admin      *adminResponse      `json:"admin,omitempty"`
+}
+
+// confirmUpload checks a file uploaded with a presigned URL the same way files sent to the API are checked,
+// moves it next to the other files of its purpose and attaches it
+func (server *Server) confirmUpload(ctx *gin.Context) {
+	var uri uploadURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req confirmUploadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	c, err := server.currentCaller(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Uploads of someone else are reported as missing
+	pending, err := server.store.GetUpload(ctx, uuid.MustParse(uri.UploadID))
+	if err == nil && !c.isAuthor(pending.AdminID, pending.UserID) {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if pending.ConfirmedAt.Valid || time.Now().After(pending.ExpiresAt) {
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrUploadUnavailable))
+		return
+	}
+
+	arg := db.ConfirmUploadTxParams{UploadID: pending.UploadID}
+	var prefix string
+	// previous is the service a service image replaces the image of
+	var previous db.Service
+	switch pending.Purpose {
+	case util.UploadServiceImage:
+		if !server.requireHeadOffice(ctx) {
+			return
+		}
+		// Archived services cannot be changed
+		previous, err = server.store.GetService(ctx, req.ServiceID)
+		if err == nil && previous.ArchivedAt.Valid {
+			err = sql.ErrNoRows
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if previous.Version != req.Version {
+			ctx.JSON(http.StatusConflict, errorResponse(db.ErrServiceModified))
+			return
+		}
+		arg.ServiceID = req.ServiceID
+		arg.Version = req.Version
+		prefix = "services"
+	case util.UploadOrderAttachment:
+		c, ok := server.orderCaller(ctx, req.OrderID)
+		if !ok {
+			return
+		}
+		arg.OrderID = req.OrderID
+		arg.Visibility, err = noteVisibility(c, req.Visibility)
+		if err != nil {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		if req.NoteID != nil {
+			if !server.checkAttachmentNote(ctx, c, req.OrderID, *req.NoteID) {
+				return
+			}
+			arg.NoteID = sql.NullInt64{Int64: *req.NoteID, Valid: true}
+		}
+		prefix = attachmentPrefix(req.OrderID)
+	default:
+		prefix = "profiles"
+	}
+
+	info, err := server.storage.Stat(ctx, pending.ObjectKey)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			err := errors.New("file has not been uploaded yet")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if info.Size != pending.SizeBytes {
+		err := fmt.Errorf("uploaded file has %d bytes instead of %d", info.Size, pending.SizeBytes)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	body, err := server.storage.Get(ctx, pending.ObjectKey)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	allowedTypes, maxSize := server.uploadRules(pending.Purpose)
+	file, err := newUpload(body, pending.FileName, allowedTypes, maxSize, prefix)
+	body.Close()
+	if err == nil && file.ContentType != pending.ContentType {
+		err = fmt.Errorf("uploaded file is %s rather than %s", file.ContentType, pending.ContentType)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	arg.FileURL, err = server.storeUpload(ctx, file)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ConfirmUploadTx(ctx, arg)
+	if err != nil {
+		server.removeUpload(file.Key)
+		if err == db.ErrUploadUnavailable || err == db.ErrServiceModified {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.removeUpload(pending.ObjectKey)
+	if result.Service != nil {
+		server.serviceImageReplaced(ctx, previous, *result.Service)
+	}
+
+	res := confirmUploadResponse{
+		Upload:  result.Upload,
+		Service: result.Service,
+	}
+	if result.Attachment != nil {
+		attachment := newAttachmentResponse(*result.Attachment)
+		res.Attachment = &attachment
+	}
+	if result.User != nil {
+		user := newUserResponse(*result.User)
+		res.User = &user
+	}
+	if result.Admin != nil {
+		admin := newAdminResponse(*result.Admin)
+		res.Admin = &admin
+	}
+	ctx.JSON(http.StatusOK, res)
+}
@@ -2,12 +2,15 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/token"
 	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
 )
 
@@ -17,17 +20,22 @@ type createUserRequest struct {
 	Phone    string `json:"phone" binding:"required"`
 	Address  string `json:"address" binding:"required"`
 	Password string `json:"password" binding:"required,min=8"`
+	// BranchID is the home branch of the customer, their orders go there unless they pick another one
+	BranchID *int32 `json:"branch_id" binding:"omitempty,min=1"`
 }
 
 type userResponse struct {
-	UserID            int32     `json:"user_id"`
-	Name              string    `json:"name"`
-	Email             string    `json:"email"`
-	Phone             string    `json:"phone"`
-	Address           string    `json:"address"`
-	TotalOrders       int32     `json:"total_orders"`
-	CreatedAt         time.Time `json:"created_at"`
-	PasswordChangedAt time.Time `json:"password_changed_at"`
+	UserID            int32      `json:"user_id"`
+	Name              string     `json:"name"`
+	Email             string     `json:"email"`
+	Phone             string     `json:"phone"`
+	Address           string     `json:"address"`
+	BranchID          *int32     `json:"branch_id"`
+	TotalOrders       int32      `json:"total_orders"`
+	CreatedAt         time.Time  `json:"created_at"`
+	PasswordChangedAt time.Time  `json:"password_changed_at"`
+	ArchivedAt        *time.Time `json:"archived_at,omitempty"`
+	ProfileImage      string     `json:"profile_image"`
 }
 
 func newUserResponse(user db.User) userResponse {
@@ -37,11 +45,25 @@ func newUserResponse(user db.User) userResponse {
 		Email:             user.Email,
 		Phone:             user.Phone,
 		Address:           user.Address,
+		BranchID:          optionalInt32(user.BranchID),
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
+		ArchivedAt:        optionalTime(user.ArchivedAt),
+		ProfileImage:      user.ProfileImage,
 	}
 }
 
+// errAccountArchived is returned when an archived customer tries to log in, archived staff are not found at all
+var errAccountArchived = errors.New("account has been archived")
+
+// optionalTime converts a nullable database time for responses
+func optionalTime(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
+
 func (server *Server) createUser(ctx *gin.Context) {
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -59,10 +81,16 @@ func (server *Server) createUser(ctx *gin.Context) {
 		Phone:          req.Phone,
 		Address:        req.Address,
 		HashedPassword: hashedPassword,
+		BranchID:       nullInt32(req.BranchID),
 	}
 
 	user, err := server.store.CreateUser(ctx, arg)
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			err := errors.New("branch does not exist")
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -96,8 +124,11 @@ func (server *Server) getUser(ctx *gin.Context) {
 }
 
 type listUserRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	PageID          int32 `form:"page_id" binding:"required,min=1"`
+	PageSize        int32 `form:"page_size" binding:"required,min=5,max=10"`
+	IncludeArchived bool  `form:"include_archived"`
+	// BranchID lists only the customers whose home branch it is
+	BranchID *int32 `form:"branch_id" binding:"omitempty,min=1"`
 }
 
 func (server *Server) listUser(ctx *gin.Context) {
@@ -108,8 +139,10 @@ func (server *Server) listUser(ctx *gin.Context) {
 	}
 
 	arg := db.ListUsersParams{
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		IncludeArchived: req.IncludeArchived,
+		BranchID:        nullInt32(req.BranchID),
+		Limit:           req.PageSize,
+		Offset:          (req.PageID - 1) * req.PageSize,
 	}
 	users, err := server.store.ListUsers(ctx, arg)
 	if err != nil {
@@ -157,9 +190,15 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
+	if user.ArchivedAt.Valid {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAccountArchived))
+		return
+	}
 
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Email,
+		token.AccountUser,
+		user.UserID,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
@@ -169,6 +208,8 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
 		user.Email,
+		token.AccountUser,
+		user.UserID,
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
@@ -237,8 +278,18 @@ func (server *Server) UpdateUserOrder(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, userOrders)
 }
 
+type listAllUsersRequest struct {
+	BranchID *int32 `form:"branch_id" binding:"omitempty,min=1"`
+}
+
 func (server *Server) listAllUsers(ctx *gin.Context) {
-	users, err := server.store.ListAllUsers(ctx)
+	var req listAllUsersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	users, err := server.store.ListAllUsers(ctx, nullInt32(req.BranchID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -251,3 +302,76 @@ func (server *Server) listAllUsers(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, res)
 }
+
+// archiveUser archives a customer, their orders are kept and they can no longer log in
+func (server *Server) archiveUser(ctx *gin.Context) {
+	var req getUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !server.checkUserBranch(ctx, int32(req.UserID)) {
+		return
+	}
+
+	user, err := server.store.ArchiveUserTx(ctx, int32(req.UserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// restoreUser lets an archived customer log in again
+func (server *Server) restoreUser(ctx *gin.Context) {
+	var req getUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !server.checkUserBranch(ctx, int32(req.UserID)) {
+		return
+	}
+
+	user, err := server.store.RestoreUser(ctx, int32(req.UserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// checkUserBranch makes sure staff limited to branches only change the customers of their branches.
+// It writes the error response and returns false when the request cannot go on.
+func (server *Server) checkUserBranch(ctx *gin.Context, userID int32) bool {
+	scope, ok := server.currentScope(ctx)
+	if !ok {
+		return false
+	}
+	if len(scope) == 0 {
+		return true
+	}
+
+	user, err := server.store.GetUser(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if !user.BranchID.Valid || !scope.covers(user.BranchID.Int32) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errBranchForbidden))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "invoice_taxes";
+DROP TABLE IF EXISTS "invoice_items";
+DROP TABLE IF EXISTS "invoices";
+DROP TABLE IF EXISTS "invoice_counters";
+DROP FUNCTION IF EXISTS prevent_invoice_mutation();
@@ -0,0 +1,86 @@
+-- One counter row per invoice series (calendar year). Numbers are taken with
+-- an UPDATE ... RETURNING inside the issuing transaction so the series has no gaps.
+CREATE TABLE "invoice_counters" (
+  "series" varchar PRIMARY KEY NOT NULL,
+  "last_number" bigint NOT NULL DEFAULT 0
+);
+
+CREATE TABLE "invoices" (
+  "invoice_id" bigserial PRIMARY KEY NOT NULL,
+  "invoice_number" varchar UNIQUE NOT NULL,
+  "order_id" bigint UNIQUE NOT NULL,
+  "user_id" int NOT NULL,
+  "customer_name" varchar NOT NULL,
+  "customer_email" varchar NOT NULL,
+  "customer_phone" varchar NOT NULL,
+  "customer_address" varchar NOT NULL,
+  "subtotal" bigint NOT NULL,
+  "tax_total" bigint NOT NULL,
+  "total" bigint NOT NULL,
+  "pdf_url" varchar NOT NULL DEFAULT '',
+  "issued_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "invoice_items" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "invoice_id" bigint NOT NULL,
+  "service_id" int NOT NULL,
+  "description" varchar NOT NULL,
+  "quantity" int NOT NULL,
+  "unit_price" bigint NOT NULL,
+  "amount" bigint NOT NULL
+);
+
+CREATE TABLE "invoice_taxes" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "invoice_id" bigint NOT NULL,
+  "tax_name" varchar NOT NULL,
+  "rate_bps" int NOT NULL,
+  "taxable_amount" bigint NOT NULL,
+  "tax_amount" bigint NOT NULL
+);
+
+CREATE INDEX ON "invoices" ("user_id");
+
+CREATE INDEX ON "invoice_items" ("invoice_id");
+
+CREATE INDEX ON "invoice_taxes" ("invoice_id");
+
+COMMENT ON COLUMN "invoice_taxes"."rate_bps" IS 'tax rate in basis points, 900 = 9%';
+
+ALTER TABLE "invoices" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
+
+ALTER TABLE "invoice_items" ADD FOREIGN KEY ("invoice_id") REFERENCES "invoices" ("invoice_id");
+
+ALTER TABLE "invoice_taxes" ADD FOREIGN KEY ("invoice_id") REFERENCES "invoices" ("invoice_id");
+
+-- Issued invoices are immutable. The only change allowed is attaching the
+-- rendered PDF once.
+CREATE FUNCTION prevent_invoice_mutation() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'UPDATE' AND TG_TABLE_NAME = 'invoices'
+     AND OLD.pdf_url = ''
+     AND (NEW.invoice_id, NEW.invoice_number, NEW.order_id, NEW.user_id,
+          NEW.customer_name, NEW.customer_email, NEW.customer_phone, NEW.customer_address,
+          NEW.subtotal, NEW.tax_total, NEW.total, NEW.issued_at)
+       IS NOT DISTINCT FROM
+         (OLD.invoice_id, OLD.invoice_number, OLD.order_id, OLD.user_id,
+          OLD.customer_name, OLD.customer_email, OLD.customer_phone, OLD.customer_address,
+          OLD.subtotal, OLD.tax_total, OLD.total, OLD.issued_at) THEN
+    RETURN NEW;
+  END IF;
+  RAISE EXCEPTION '% is immutable once issued', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER invoices_immutable
+BEFORE UPDATE OR DELETE ON "invoices"
+FOR EACH ROW EXECUTE FUNCTION prevent_invoice_mutation();
+
+CREATE TRIGGER invoice_items_immutable
+BEFORE UPDATE OR DELETE ON "invoice_items"
+FOR EACH ROW EXECUTE FUNCTION prevent_invoice_mutation();
+
+CREATE TRIGGER invoice_taxes_immutable
+BEFORE UPDATE OR DELETE ON "invoice_taxes"
+FOR EACH ROW EXECUTE FUNCTION prevent_invoice_mutation();
@@ -0,0 +1,24 @@
+CREATE TABLE "payments" (
+  "payment_id" bigserial PRIMARY KEY NOT NULL,
+  "order_id" bigint NOT NULL,
+  "method" varchar NOT NULL,
+  "amount" bigint NOT NULL,
+  "reference" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL,
+  "refund_of" bigint,
+  "recorded_by" int,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("method" IN ('cash', 'card', 'upi', 'wallet')),
+  CHECK ("status" IN ('pending', 'captured', 'failed')),
+  CHECK (("refund_of" IS NULL AND "amount" > 0) OR ("refund_of" IS NOT NULL AND "amount" < 0))
+);
+
+CREATE INDEX ON "payments" ("order_id");
+
+CREATE INDEX ON "payments" ("refund_of");
+
+COMMENT ON COLUMN "payments"."amount" IS 'positive for payments, negative for refunds';
+
+ALTER TABLE "payments" ADD FOREIGN KEY ("refund_of") REFERENCES "payments" ("payment_id");
+
+ALTER TABLE "payments" ADD FOREIGN KEY ("recorded_by") REFERENCES "admins" ("admin_id");
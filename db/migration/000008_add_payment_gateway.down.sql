@@ -0,0 +1,6 @@
+ALTER TABLE "payments" DROP CONSTRAINT IF EXISTS "payments_method_check";
+ALTER TABLE "payments" ADD CONSTRAINT "payments_method_check" CHECK ("method" IN ('cash', 'card', 'upi', 'wallet'));
+
+ALTER TABLE IF EXISTS "payments"
+DROP COLUMN IF EXISTS "gateway_payment_id",
+DROP COLUMN IF EXISTS "provider";
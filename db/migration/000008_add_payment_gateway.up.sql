@@ -0,0 +1,12 @@
+-- Adding gateway details to the payments table
+ALTER TABLE IF EXISTS "payments"
+ADD COLUMN "provider" varchar NOT NULL DEFAULT '',
+ADD COLUMN "gateway_payment_id" varchar NOT NULL DEFAULT '';
+
+-- Online payments do not know the method until the gateway reports it
+ALTER TABLE "payments" DROP CONSTRAINT IF EXISTS "payments_method_check";
+ALTER TABLE "payments" ADD CONSTRAINT "payments_method_check" CHECK ("method" IN ('cash', 'card', 'upi', 'wallet', 'online'));
+
+COMMENT ON COLUMN "payments"."reference" IS 'for gateway payments this is the id of the payment intent at the provider';
+
+CREATE UNIQUE INDEX ON "payments" ("provider", "reference") WHERE "provider" <> '';
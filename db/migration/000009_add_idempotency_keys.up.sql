@@ -0,0 +1,15 @@
+CREATE TABLE "idempotency_keys" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "idempotency_key" varchar NOT NULL,
+  "scope" varchar NOT NULL,
+  "request_hash" varchar NOT NULL,
+  "response_status" int,
+  "response_body" bytea,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "idempotency_keys" ("scope", "idempotency_key");
+
+COMMENT ON COLUMN "idempotency_keys"."scope" IS 'caller, method and path the key was used for';
+
+COMMENT ON COLUMN "idempotency_keys"."response_status" IS 'null while the original request is still being processed';
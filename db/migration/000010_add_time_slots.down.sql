@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS "orders"
+DROP COLUMN IF EXISTS "pickup_slot_id",
+DROP COLUMN IF EXISTS "delivery_slot_id";
+
+DROP TABLE IF EXISTS "order_history";
+DROP TABLE IF EXISTS "time_slots";
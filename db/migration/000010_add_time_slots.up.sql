@@ -0,0 +1,38 @@
+CREATE TABLE "time_slots" (
+  "slot_id" serial PRIMARY KEY NOT NULL,
+  "slot_type" varchar NOT NULL,
+  "starts_at" timestamptz NOT NULL,
+  "ends_at" timestamptz NOT NULL,
+  "capacity" int NOT NULL,
+  "booked" int NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("slot_type" IN ('pickup', 'delivery')),
+  CHECK ("ends_at" > "starts_at"),
+  CHECK ("booked" >= 0 AND "booked" <= "capacity")
+);
+
+CREATE UNIQUE INDEX ON "time_slots" ("slot_type", "starts_at");
+
+CREATE TABLE "order_history" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "order_id" bigint NOT NULL,
+  "event" varchar NOT NULL,
+  "details" jsonb NOT NULL DEFAULT '{}',
+  "changed_by" int,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "order_history" ("order_id");
+
+COMMENT ON COLUMN "order_history"."changed_by" IS 'admin who made the change, null for changes made by the customer or the system';
+
+-- Adding the chosen pickup and delivery slots to the orders table
+ALTER TABLE IF EXISTS "orders"
+ADD COLUMN "pickup_slot_id" int,
+ADD COLUMN "delivery_slot_id" int;
+
+ALTER TABLE "orders" ADD FOREIGN KEY ("pickup_slot_id") REFERENCES "time_slots" ("slot_id");
+
+ALTER TABLE "orders" ADD FOREIGN KEY ("delivery_slot_id") REFERENCES "time_slots" ("slot_id");
+
+ALTER TABLE "order_history" ADD FOREIGN KEY ("changed_by") REFERENCES "admins" ("admin_id");
@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS "orders"
+DROP COLUMN IF EXISTS "assigned_to",
+DROP COLUMN IF EXISTS "assigned_at",
+DROP COLUMN IF EXISTS "priority";
+
+-- modified_by stays nullable, the values the old serial default produced cannot be restored
@@ -0,0 +1,18 @@
+-- modified_by was added as a serial, so every new order took the next value of its own
+-- sequence instead of the admin who changed it. It is now set explicitly and null until then.
+ALTER TABLE "orders" ALTER COLUMN "modified_by" DROP DEFAULT;
+ALTER TABLE "orders" ALTER COLUMN "modified_by" DROP NOT NULL;
+DROP SEQUENCE IF EXISTS "orders_modified_by_seq";
+UPDATE "orders" SET "modified_by" = NULL;
+
+-- Adding the staff member an order is assigned to
+ALTER TABLE IF EXISTS "orders"
+ADD COLUMN "assigned_to" int,
+ADD COLUMN "assigned_at" timestamptz,
+ADD COLUMN "priority" int NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "orders"."priority" IS 'orders with a higher priority are worked on first';
+
+CREATE INDEX ON "orders" ("assigned_to");
+
+ALTER TABLE "orders" ADD FOREIGN KEY ("assigned_to") REFERENCES "admins" ("admin_id");
@@ -0,0 +1,6 @@
+ALTER TABLE IF EXISTS "orders"
+DROP COLUMN IF EXISTS "due_at",
+DROP COLUMN IF EXISTS "sla_state";
+
+ALTER TABLE IF EXISTS "services"
+DROP COLUMN IF EXISTS "turnaround_hours";
@@ -0,0 +1,16 @@
+-- Adding the standard turnaround promised for every service
+ALTER TABLE IF EXISTS "services"
+ADD COLUMN "turnaround_hours" int NOT NULL DEFAULT 48;
+
+ALTER TABLE "services" ADD CHECK ("turnaround_hours" > 0);
+
+-- Adding the deadline of every order and how it is tracking against it
+ALTER TABLE IF EXISTS "orders"
+ADD COLUMN "due_at" timestamptz,
+ADD COLUMN "sla_state" varchar NOT NULL DEFAULT 'on_track';
+
+ALTER TABLE "orders" ADD CHECK ("sla_state" IN ('on_track', 'at_risk', 'overdue'));
+
+COMMENT ON COLUMN "orders"."due_at" IS 'order_started plus the turnaround of the slowest service in the order';
+
+CREATE INDEX ON "orders" ("sla_state", "due_at");
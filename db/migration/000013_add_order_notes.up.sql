@@ -0,0 +1,45 @@
+CREATE TABLE "order_notes" (
+  "note_id" bigserial PRIMARY KEY NOT NULL,
+  "order_id" bigint NOT NULL,
+  "body" text NOT NULL,
+  "visibility" varchar NOT NULL,
+  "admin_id" int,
+  "user_id" int,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("visibility" IN ('internal', 'customer')),
+  CHECK (("admin_id" IS NULL) <> ("user_id" IS NULL))
+);
+
+CREATE INDEX ON "order_notes" ("order_id");
+
+COMMENT ON COLUMN "order_notes"."visibility" IS 'internal notes are only shown to staff';
+
+COMMENT ON COLUMN "order_notes"."admin_id" IS 'set when the note was written by staff, user_id is set otherwise';
+
+CREATE TABLE "order_attachments" (
+  "attachment_id" bigserial PRIMARY KEY NOT NULL,
+  "order_id" bigint NOT NULL,
+  "note_id" bigint,
+  "file_url" varchar NOT NULL,
+  "file_name" varchar NOT NULL,
+  "content_type" varchar NOT NULL,
+  "size_bytes" bigint NOT NULL,
+  "visibility" varchar NOT NULL,
+  "admin_id" int,
+  "user_id" int,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("visibility" IN ('internal', 'customer')),
+  CHECK (("admin_id" IS NULL) <> ("user_id" IS NULL))
+);
+
+CREATE INDEX ON "order_attachments" ("order_id");
+
+ALTER TABLE "order_notes" ADD FOREIGN KEY ("admin_id") REFERENCES "admins" ("admin_id");
+
+ALTER TABLE "order_notes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
+
+ALTER TABLE "order_attachments" ADD FOREIGN KEY ("note_id") REFERENCES "order_notes" ("note_id") ON DELETE SET NULL;
+
+ALTER TABLE "order_attachments" ADD FOREIGN KEY ("admin_id") REFERENCES "admins" ("admin_id");
+
+ALTER TABLE "order_attachments" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
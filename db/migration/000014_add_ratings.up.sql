@@ -0,0 +1,24 @@
+CREATE TABLE "order_ratings" (
+  "rating_id" bigserial PRIMARY KEY NOT NULL,
+  "order_id" bigint NOT NULL,
+  "user_id" int NOT NULL,
+  "service_id" int,
+  "rating" int NOT NULL,
+  "comment" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("rating" BETWEEN 1 AND 5)
+);
+
+COMMENT ON COLUMN "order_ratings"."service_id" IS 'null for the rating of the order as a whole';
+
+-- A customer rates an order once as a whole and once per service
+CREATE UNIQUE INDEX ON "order_ratings" ("order_id") WHERE "service_id" IS NULL;
+
+CREATE UNIQUE INDEX ON "order_ratings" ("order_id", "service_id") WHERE "service_id" IS NOT NULL;
+
+CREATE INDEX ON "order_ratings" ("service_id");
+
+ALTER TABLE "order_ratings" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
+
+ALTER TABLE "order_ratings" ADD FOREIGN KEY ("service_id") REFERENCES "services" ("service_id");
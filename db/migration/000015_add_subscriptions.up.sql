@@ -0,0 +1,51 @@
+CREATE TABLE "subscriptions" (
+  "subscription_id" bigserial PRIMARY KEY NOT NULL,
+  "user_id" int NOT NULL,
+  "frequency" varchar NOT NULL,
+  "cron_expr" varchar NOT NULL DEFAULT '',
+  "next_run_at" timestamptz NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "failure_count" int NOT NULL DEFAULT 0,
+  "last_error" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("frequency" IN ('weekly', 'biweekly', 'monthly', 'cron')),
+  CHECK ("status" IN ('active', 'paused', 'cancelled'))
+);
+
+CREATE INDEX ON "subscriptions" ("user_id");
+
+CREATE INDEX ON "subscriptions" ("status", "next_run_at");
+
+COMMENT ON COLUMN "subscriptions"."cron_expr" IS 'five field cron expression, only used when frequency is cron';
+
+COMMENT ON COLUMN "subscriptions"."failure_count" IS 'failed attempts at the current run, reset once the run is over';
+
+CREATE TABLE "subscription_services" (
+  "subscription_id" bigint NOT NULL,
+  "service_id" int NOT NULL,
+  "quantity" int NOT NULL DEFAULT 1,
+  PRIMARY KEY ("subscription_id", "service_id"),
+  CHECK ("quantity" > 0)
+);
+
+CREATE TABLE "subscription_runs" (
+  "run_id" bigserial PRIMARY KEY NOT NULL,
+  "subscription_id" bigint NOT NULL,
+  "scheduled_for" timestamptz NOT NULL,
+  "status" varchar NOT NULL,
+  "order_id" bigint,
+  "error" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("status" IN ('created', 'failed', 'skipped'))
+);
+
+CREATE INDEX ON "subscription_runs" ("subscription_id");
+
+ALTER TABLE "subscriptions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
+
+ALTER TABLE "subscription_services" ADD FOREIGN KEY ("subscription_id") REFERENCES "subscriptions" ("subscription_id") ON DELETE CASCADE;
+
+ALTER TABLE "subscription_services" ADD FOREIGN KEY ("service_id") REFERENCES "services" ("service_id");
+
+ALTER TABLE "subscription_runs" ADD FOREIGN KEY ("subscription_id") REFERENCES "subscriptions" ("subscription_id") ON DELETE CASCADE;
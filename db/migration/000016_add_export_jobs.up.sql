@@ -0,0 +1,22 @@
+CREATE TABLE "export_jobs" (
+  "job_id" bigserial PRIMARY KEY NOT NULL,
+  "kind" varchar NOT NULL,
+  "format" varchar NOT NULL,
+  "filters" jsonb NOT NULL DEFAULT '{}',
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "row_count" bigint NOT NULL DEFAULT 0,
+  "file_url" varchar NOT NULL DEFAULT '',
+  "error" text NOT NULL DEFAULT '',
+  "requested_by" int NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "finished_at" timestamptz,
+  CHECK ("kind" IN ('orders', 'users', 'services')),
+  CHECK ("format" IN ('csv', 'xlsx')),
+  CHECK ("status" IN ('pending', 'running', 'done', 'failed'))
+);
+
+COMMENT ON COLUMN "export_jobs"."row_count" IS 'rows matching the filters when the export was requested';
+
+COMMENT ON COLUMN "export_jobs"."file_url" IS 'download link of the finished export in object storage';
+
+ALTER TABLE "export_jobs" ADD FOREIGN KEY ("requested_by") REFERENCES "admins" ("admin_id");
@@ -0,0 +1,24 @@
+CREATE TABLE "categories" (
+  "category_id" serial PRIMARY KEY NOT NULL,
+  "parent_id" int,
+  "name" varchar NOT NULL,
+  "icon" varchar NOT NULL DEFAULT '',
+  "display_order" int NOT NULL DEFAULT 0,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "categories"."parent_id" IS 'null for top level categories';
+
+COMMENT ON COLUMN "categories"."display_order" IS 'categories with the same parent are shown in ascending order';
+
+-- Names only have to be unique among the categories with the same parent
+CREATE UNIQUE INDEX ON "categories" (COALESCE("parent_id", 0), "name");
+
+ALTER TABLE "categories" ADD FOREIGN KEY ("parent_id") REFERENCES "categories" ("category_id");
+
+ALTER TABLE "services" ADD COLUMN "category_id" int;
+
+CREATE INDEX ON "services" ("category_id");
+
+ALTER TABLE "services" ADD FOREIGN KEY ("category_id") REFERENCES "categories" ("category_id") ON DELETE SET NULL;
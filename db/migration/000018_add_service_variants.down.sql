@@ -0,0 +1,5 @@
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "unit_price";
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "variant_id";
+
+DROP TABLE IF EXISTS "service_variants";
@@ -0,0 +1,23 @@
+CREATE TABLE "service_variants" (
+  "variant_id" serial PRIMARY KEY NOT NULL,
+  "service_id" int NOT NULL,
+  "name" varchar NOT NULL,
+  "sku" varchar UNIQUE NOT NULL,
+  "price" bigint NOT NULL,
+  "turnaround_hours" int NOT NULL DEFAULT 48,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("price" >= 0),
+  CHECK ("turnaround_hours" > 0)
+);
+
+CREATE UNIQUE INDEX ON "service_variants" ("service_id", "name");
+
+ALTER TABLE "service_variants" ADD FOREIGN KEY ("service_id") REFERENCES "services" ("service_id") ON DELETE CASCADE;
+
+ALTER TABLE "orders" ADD COLUMN "variant_id" int;
+
+ALTER TABLE "orders" ADD COLUMN "unit_price" bigint;
+
+COMMENT ON COLUMN "orders"."unit_price" IS 'price of the service or variant when the order was placed, null for orders placed before prices were recorded';
+
+ALTER TABLE "orders" ADD FOREIGN KEY ("variant_id") REFERENCES "service_variants" ("variant_id");
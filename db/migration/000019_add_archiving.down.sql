@@ -0,0 +1,5 @@
+ALTER TABLE "admins" DROP COLUMN IF EXISTS "archived_at";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "archived_at";
+
+ALTER TABLE "services" DROP COLUMN IF EXISTS "archived_at";
@@ -0,0 +1,7 @@
+ALTER TABLE "services" ADD COLUMN "archived_at" timestamptz;
+
+ALTER TABLE "users" ADD COLUMN "archived_at" timestamptz;
+
+ALTER TABLE "admins" ADD COLUMN "archived_at" timestamptz;
+
+COMMENT ON COLUMN "services"."archived_at" IS 'archived rows are kept for past orders but left out of listings';
@@ -0,0 +1,21 @@
+CREATE TABLE "service_price_history" (
+  "price_id" bigserial PRIMARY KEY,
+  "service_id" int NOT NULL,
+  "price" bigint NOT NULL,
+  "effective_at" timestamptz NOT NULL,
+  "created_by" int,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("price" >= 0)
+);
+
+CREATE INDEX ON "service_price_history" ("service_id", "effective_at");
+
+COMMENT ON COLUMN "service_price_history"."effective_at" IS 'the price applies to orders placed from this moment until the next change';
+
+ALTER TABLE "service_price_history" ADD FOREIGN KEY ("service_id") REFERENCES "services" ("service_id") ON DELETE CASCADE;
+
+ALTER TABLE "service_price_history" ADD FOREIGN KEY ("created_by") REFERENCES "admins" ("admin_id") ON DELETE SET NULL;
+
+-- The current prices start the timeline of existing services
+INSERT INTO "service_price_history" ("service_id", "price", "effective_at")
+SELECT "service_id", "service_price", now() FROM "services";
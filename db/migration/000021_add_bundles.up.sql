@@ -0,0 +1,29 @@
+CREATE TABLE "bundles" (
+  "bundle_id" serial PRIMARY KEY NOT NULL,
+  "name" varchar UNIQUE NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "price" bigint NOT NULL,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("price" >= 0)
+);
+
+COMMENT ON COLUMN "bundles"."price" IS 'price of the whole bundle, spread over its services when ordered';
+
+CREATE TABLE "bundle_items" (
+  "bundle_id" int NOT NULL,
+  "service_id" int NOT NULL,
+  "quantity" int NOT NULL DEFAULT 1,
+  PRIMARY KEY ("bundle_id", "service_id"),
+  CHECK ("quantity" > 0)
+);
+
+ALTER TABLE "bundle_items" ADD FOREIGN KEY ("bundle_id") REFERENCES "bundles" ("bundle_id") ON DELETE CASCADE;
+
+ALTER TABLE "bundle_items" ADD FOREIGN KEY ("service_id") REFERENCES "services" ("service_id");
+
+ALTER TABLE "orders" ADD COLUMN "bundle_id" int;
+
+COMMENT ON COLUMN "orders"."bundle_id" IS 'set on the rows of an order that were ordered as part of a bundle';
+
+ALTER TABLE "orders" ADD FOREIGN KEY ("bundle_id") REFERENCES "bundles" ("bundle_id");
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS orders_order_number_idx;
+
+DROP INDEX IF EXISTS services_search_idx;
+
+DROP INDEX IF EXISTS services_name_trgm_idx;
+
+DROP INDEX IF EXISTS users_search_idx;
+
+DROP INDEX IF EXISTS users_phone_digits_trgm_idx;
+
+DROP INDEX IF EXISTS users_email_trgm_idx;
+
+DROP INDEX IF EXISTS users_name_trgm_idx;
@@ -0,0 +1,16 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram indexes serve partial matches, the full text ones whole words
+CREATE INDEX users_name_trgm_idx ON "users" USING gin ("name" gin_trgm_ops);
+
+CREATE INDEX users_email_trgm_idx ON "users" USING gin ("email" gin_trgm_ops);
+
+CREATE INDEX users_phone_digits_trgm_idx ON "users" USING gin ((regexp_replace("phone", '\D', '', 'g')) gin_trgm_ops);
+
+CREATE INDEX users_search_idx ON "users" USING gin (to_tsvector('simple', "name" || ' ' || "email"));
+
+CREATE INDEX services_name_trgm_idx ON "services" USING gin ("service_name" gin_trgm_ops);
+
+CREATE INDEX services_search_idx ON "services" USING gin (to_tsvector('simple', "service_name"));
+
+CREATE INDEX orders_order_number_idx ON "orders" (("order_id"::text) text_pattern_ops);
@@ -0,0 +1,5 @@
+ALTER TABLE "admins" DROP COLUMN IF EXISTS "profile_image";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "profile_image";
+
+DROP TABLE IF EXISTS "uploads";
@@ -0,0 +1,24 @@
+-- Uploads clients send straight to the storage with a presigned URL, kept until they are confirmed
+CREATE TABLE "uploads" (
+  "upload_id" uuid PRIMARY KEY NOT NULL,
+  "object_key" varchar NOT NULL,
+  "file_name" varchar NOT NULL,
+  "content_type" varchar NOT NULL,
+  "size_bytes" bigint NOT NULL,
+  "purpose" varchar NOT NULL,
+  "admin_id" int,
+  "user_id" int,
+  "expires_at" timestamptz NOT NULL,
+  "confirmed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CHECK ("purpose" IN ('service_image', 'order_attachment', 'profile_image')),
+  CHECK (("admin_id" IS NULL) <> ("user_id" IS NULL))
+);
+
+ALTER TABLE "uploads" ADD FOREIGN KEY ("admin_id") REFERENCES "admins" ("admin_id");
+
+ALTER TABLE "uploads" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
+
+ALTER TABLE "users" ADD COLUMN "profile_image" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "admins" ADD COLUMN "profile_image" varchar NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+ALTER TABLE "services" DROP COLUMN IF EXISTS "image_webp";
+
+ALTER TABLE "services" DROP COLUMN IF EXISTS "image_medium";
+
+ALTER TABLE "services" DROP COLUMN IF EXISTS "image_thumbnail";
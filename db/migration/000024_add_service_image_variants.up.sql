@@ -0,0 +1,6 @@
+-- Smaller copies of service_image made in the background, empty until they are ready
+ALTER TABLE "services" ADD COLUMN "image_thumbnail" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "services" ADD COLUMN "image_medium" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "services" ADD COLUMN "image_webp" varchar NOT NULL DEFAULT '';
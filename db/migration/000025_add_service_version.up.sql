@@ -0,0 +1,2 @@
+-- Incremented on every change made by an admin, an update has to name the version it was based on
+ALTER TABLE "services" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
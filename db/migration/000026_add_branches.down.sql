@@ -0,0 +1,11 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "branch_id";
+
+ALTER TABLE "subscriptions" DROP COLUMN IF EXISTS "branch_id";
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "branch_id";
+
+DROP TABLE IF EXISTS "admin_branches";
+
+DROP TABLE IF EXISTS "service_branches";
+
+DROP TABLE IF EXISTS "branches";
@@ -0,0 +1,64 @@
+CREATE TABLE "branches" (
+  "branch_id" serial PRIMARY KEY,
+  "name" varchar UNIQUE NOT NULL,
+  "address" varchar NOT NULL DEFAULT '',
+  "phone" varchar NOT NULL DEFAULT '',
+  "is_default" boolean NOT NULL DEFAULT false,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- Orders placed without a branch go to the default branch, there is only ever one
+CREATE UNIQUE INDEX ON "branches" ("is_default") WHERE "is_default";
+
+INSERT INTO "branches" ("name", "is_default") VALUES ('Main', true);
+
+-- Services are offered at every branch at their own price, a row here overrides that for one branch
+CREATE TABLE "service_branches" (
+  "service_id" int NOT NULL,
+  "branch_id" int NOT NULL,
+  "price" bigint,
+  "is_available" boolean NOT NULL DEFAULT true,
+  PRIMARY KEY ("service_id", "branch_id")
+);
+
+ALTER TABLE "service_branches" ADD FOREIGN KEY ("service_id") REFERENCES "services" ("service_id") ON DELETE CASCADE;
+
+ALTER TABLE "service_branches" ADD FOREIGN KEY ("branch_id") REFERENCES "branches" ("branch_id") ON DELETE CASCADE;
+
+-- Admins only see the orders of their branches, admins without a branch see every branch
+CREATE TABLE "admin_branches" (
+  "admin_id" int NOT NULL,
+  "branch_id" int NOT NULL,
+  PRIMARY KEY ("admin_id", "branch_id")
+);
+
+ALTER TABLE "admin_branches" ADD FOREIGN KEY ("admin_id") REFERENCES "admins" ("admin_id") ON DELETE CASCADE;
+
+ALTER TABLE "admin_branches" ADD FOREIGN KEY ("branch_id") REFERENCES "branches" ("branch_id") ON DELETE CASCADE;
+
+-- Existing orders, subscriptions and customers belong to the default branch
+ALTER TABLE "orders" ADD COLUMN "branch_id" int;
+
+UPDATE "orders" SET "branch_id" = (SELECT "branch_id" FROM "branches" WHERE "is_default");
+
+ALTER TABLE "orders" ALTER COLUMN "branch_id" SET NOT NULL;
+
+ALTER TABLE "orders" ADD FOREIGN KEY ("branch_id") REFERENCES "branches" ("branch_id");
+
+CREATE INDEX ON "orders" ("branch_id");
+
+ALTER TABLE "subscriptions" ADD COLUMN "branch_id" int;
+
+UPDATE "subscriptions" SET "branch_id" = (SELECT "branch_id" FROM "branches" WHERE "is_default");
+
+ALTER TABLE "subscriptions" ALTER COLUMN "branch_id" SET NOT NULL;
+
+ALTER TABLE "subscriptions" ADD FOREIGN KEY ("branch_id") REFERENCES "branches" ("branch_id");
+
+-- The branch a customer usually orders from, their orders go there unless they pick another one
+ALTER TABLE "users" ADD COLUMN "branch_id" int;
+
+UPDATE "users" SET "branch_id" = (SELECT "branch_id" FROM "branches" WHERE "is_default");
+
+ALTER TABLE "users" ADD FOREIGN KEY ("branch_id") REFERENCES "branches" ("branch_id");
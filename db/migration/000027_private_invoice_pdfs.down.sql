@@ -0,0 +1,20 @@
+COMMENT ON COLUMN "invoices"."pdf_key" IS NULL;
+
+ALTER TABLE "invoices" RENAME COLUMN "pdf_key" TO "pdf_url";
+
+CREATE OR REPLACE FUNCTION prevent_invoice_mutation() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'UPDATE' AND TG_TABLE_NAME = 'invoices'
+     AND OLD.pdf_url = ''
+     AND (NEW.invoice_id, NEW.invoice_number, NEW.order_id, NEW.user_id,
+          NEW.customer_name, NEW.customer_email, NEW.customer_phone, NEW.customer_address,
+          NEW.subtotal, NEW.tax_total, NEW.total, NEW.issued_at)
+       IS NOT DISTINCT FROM
+         (OLD.invoice_id, OLD.invoice_number, OLD.order_id, OLD.user_id,
+          OLD.customer_name, OLD.customer_email, OLD.customer_phone, OLD.customer_address,
+          OLD.subtotal, OLD.tax_total, OLD.total, OLD.issued_at) THEN
+    RETURN NEW;
+  END IF;
+  RAISE EXCEPTION '% is immutable once issued', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,32 @@
+-- Invoice PDFs were stored under public, guessable keys. They are rendered again under
+-- private keys and served by the API to the customer and staff only.
+-- The public copy under invoices/<invoice_number>.pdf is deleted when an invoice is rendered again.
+-- Invoices that are never fetched again keep theirs, remove them by deleting every object under
+-- the invoices/ prefix of the bucket, e.g. aws s3 rm s3://<bucket>/invoices/ --recursive.
+-- New PDFs are kept under private/invoices/ and are not affected.
+ALTER TABLE "invoices" DISABLE TRIGGER "invoices_immutable";
+
+ALTER TABLE "invoices" RENAME COLUMN "pdf_url" TO "pdf_key";
+
+UPDATE "invoices" SET "pdf_key" = '';
+
+ALTER TABLE "invoices" ENABLE TRIGGER "invoices_immutable";
+
+COMMENT ON COLUMN "invoices"."pdf_key" IS 'storage key of the rendered PDF, empty until it is rendered';
+
+CREATE OR REPLACE FUNCTION prevent_invoice_mutation() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'UPDATE' AND TG_TABLE_NAME = 'invoices'
+     AND OLD.pdf_key = ''
+     AND (NEW.invoice_id, NEW.invoice_number, NEW.order_id, NEW.user_id,
+          NEW.customer_name, NEW.customer_email, NEW.customer_phone, NEW.customer_address,
+          NEW.subtotal, NEW.tax_total, NEW.total, NEW.issued_at)
+       IS NOT DISTINCT FROM
+         (OLD.invoice_id, OLD.invoice_number, OLD.order_id, OLD.user_id,
+          OLD.customer_name, OLD.customer_email, OLD.customer_phone, OLD.customer_address,
+          OLD.subtotal, OLD.tax_total, OLD.total, OLD.issued_at) THEN
+    RETURN NEW;
+  END IF;
+  RAISE EXCEPTION '% is immutable once issued', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,5 @@
+ALTER TABLE "idempotency_keys" ADD COLUMN "content_type" varchar NOT NULL DEFAULT '';
+
+CREATE INDEX ON "idempotency_keys" ("created_at");
+
+COMMENT ON COLUMN "idempotency_keys"."content_type" IS 'content type of the stored response, empty for responses stored before it was recorded';
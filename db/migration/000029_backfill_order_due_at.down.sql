@@ -0,0 +1 @@
+-- The backfilled deadlines and SLA states are kept, they cannot be told apart from the ones set when the orders were placed
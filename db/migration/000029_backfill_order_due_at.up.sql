@@ -0,0 +1,19 @@
+-- Orders placed before deadlines were tracked have no due_at and would sort after every
+-- other order in the staff queues. Their deadline is computed the way it is for new orders,
+-- order_started plus the turnaround of the slowest service or variant in the order.
+-- Orders that are already past their backfilled deadline are marked overdue right away,
+-- the SLA checker would otherwise publish an overdue event for every one of them.
+UPDATE "orders"
+SET "due_at" = "due"."due_at",
+"sla_state" = CASE WHEN "due"."due_at" <= now() THEN 'overdue' ELSE "orders"."sla_state" END
+FROM (
+  SELECT
+    "orders"."order_id",
+    MIN("orders"."order_started") + MAX(COALESCE("service_variants"."turnaround_hours", "services"."turnaround_hours")) * interval '1 hour' AS "due_at"
+  FROM "orders"
+  JOIN "services" ON "services"."service_id" = "orders"."service_ids"
+  LEFT JOIN "service_variants" ON "service_variants"."variant_id" = "orders"."variant_id"
+  GROUP BY "orders"."order_id"
+) AS "due"
+WHERE "orders"."order_id" = "due"."order_id"
+AND "orders"."due_at" IS NULL;
@@ -0,0 +1,13 @@
+-- Monthly runs fall on the day of the month of the first run. Stepping a month from the
+-- previous run instead would move a subscription started on the 31st to the 28th for good.
+ALTER TABLE "subscriptions" ADD COLUMN "anchor_at" timestamptz;
+
+UPDATE "subscriptions"
+SET "anchor_at" = COALESCE(
+  (SELECT MIN("scheduled_for") FROM "subscription_runs" WHERE "subscription_runs"."subscription_id" = "subscriptions"."subscription_id"),
+  "next_run_at"
+);
+
+ALTER TABLE "subscriptions" ALTER COLUMN "anchor_at" SET NOT NULL;
+
+COMMENT ON COLUMN "subscriptions"."anchor_at" IS 'first run of the subscription, monthly runs fall on its day of the month';
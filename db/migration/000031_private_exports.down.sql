@@ -0,0 +1,3 @@
+COMMENT ON COLUMN "export_jobs"."file_key" IS 'download link of the finished export in object storage';
+
+ALTER TABLE "export_jobs" RENAME COLUMN "file_key" TO "file_url";
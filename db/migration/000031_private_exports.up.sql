@@ -0,0 +1,7 @@
+-- Exports hold customer details and were uploaded under public keys. They are now kept
+-- private and downloaded through the API by staff only, older exports have to be run again.
+ALTER TABLE "export_jobs" RENAME COLUMN "file_url" TO "file_key";
+
+UPDATE "export_jobs" SET "file_key" = '';
+
+COMMENT ON COLUMN "export_jobs"."file_key" IS 'storage key of the finished export, empty until it is done';
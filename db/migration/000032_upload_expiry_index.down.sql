@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "uploads_unconfirmed_expires_at_idx";
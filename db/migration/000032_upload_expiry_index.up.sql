@@ -0,0 +1,2 @@
+-- Unconfirmed uploads are swept once they expire
+CREATE INDEX "uploads_unconfirmed_expires_at_idx" ON "uploads" ("expires_at") WHERE "confirmed_at" IS NULL;
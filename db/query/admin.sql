@@ -22,13 +22,70 @@ INSERT INTO admins (
 
 -- name: GetAdminByEmail :one
 SELECT * FROM admins
-WHERE email = $1 LIMIT 1;
+WHERE email = $1 AND archived_at IS NULL LIMIT 1;
 
 -- name: ListAdmins :many
 SELECT * FROM admins
+WHERE (archived_at IS NULL OR sqlc.arg('include_archived')::boolean)
+AND (sqlc.narg('branch_id')::int IS NULL OR (
+  NOT EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id)
+  OR EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id AND admin_branches.branch_id = sqlc.narg('branch_id'))
+))
 ORDER BY admin_id
-LIMIT $1
-OFFSET $2;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
 
--- name: DeleteAdmin :exec
-DELETE FROM admins WHERE admin_id = $1;
\ No newline at end of file
+-- name: ArchiveAdmin :one
+UPDATE admins
+SET archived_at = COALESCE(archived_at, now())
+WHERE admin_id = $1
+RETURNING *;
+
+-- name: RestoreAdmin :one
+UPDATE admins
+SET archived_at = NULL
+WHERE admin_id = $1
+RETURNING *;
+
+-- name: GetAdmin :one
+SELECT * FROM admins
+WHERE admin_id = $1 LIMIT 1;
+
+-- name: GetActiveAdmin :one
+SELECT * FROM admins
+WHERE admin_id = $1 AND archived_at IS NULL LIMIT 1;
+
+-- name: GetRoundRobinAdmin :one
+SELECT admins.admin_id FROM admins
+LEFT JOIN orders ON orders.assigned_to = admins.admin_id
+WHERE admins.archived_at IS NULL
+AND (
+  NOT EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id)
+  OR EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id AND admin_branches.branch_id = $1)
+)
+GROUP BY admins.admin_id
+ORDER BY MAX(orders.assigned_at) NULLS FIRST, admins.admin_id
+LIMIT 1;
+
+-- name: GetLeastLoadedAdmin :one
+SELECT admins.admin_id FROM admins
+LEFT JOIN orders ON orders.assigned_to = admins.admin_id
+AND orders.order_delivered = false
+AND orders.order_status <> 'Cancelled'
+WHERE admins.archived_at IS NULL
+AND (
+  NOT EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id)
+  OR EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id AND admin_branches.branch_id = $1)
+)
+GROUP BY admins.admin_id
+ORDER BY COUNT(DISTINCT orders.order_id), admins.admin_id
+LIMIT 1;
+
+-- name: LockBranchAssignment :exec
+SELECT pg_advisory_xact_lock(hashtext('order_assignment'), sqlc.arg('branch_id')::int);
+
+-- name: SetAdminProfileImage :one
+UPDATE admins
+SET profile_image = $2
+WHERE admin_id = $1
+RETURNING *;
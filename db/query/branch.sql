@@ -0,0 +1,72 @@
+-- name: CreateBranch :one
+INSERT INTO branches (
+  name,
+  address,
+  phone
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetBranch :one
+SELECT * FROM branches
+WHERE branch_id = $1 LIMIT 1;
+
+-- name: GetDefaultBranch :one
+SELECT * FROM branches
+WHERE is_default LIMIT 1;
+
+-- name: ListBranches :many
+SELECT * FROM branches
+ORDER BY branch_id;
+
+-- name: UpdateBranch :one
+UPDATE branches
+SET name = $2,
+address = $3,
+phone = $4,
+is_active = $5
+WHERE branch_id = $1
+RETURNING *;
+
+-- name: UpsertServiceBranch :one
+INSERT INTO service_branches (
+  service_id,
+  branch_id,
+  price,
+  is_available
+) VALUES (
+  $1, $2, $3, $4
+) ON CONFLICT (service_id, branch_id) DO UPDATE
+SET price = EXCLUDED.price,
+is_available = EXCLUDED.is_available
+RETURNING *;
+
+-- name: GetServiceBranch :one
+SELECT * FROM service_branches
+WHERE service_id = $1 AND branch_id = $2 LIMIT 1;
+
+-- name: ListServiceBranches :many
+SELECT * FROM service_branches
+WHERE branch_id = $1
+ORDER BY service_id;
+
+-- name: DeleteServiceBranch :execrows
+DELETE FROM service_branches
+WHERE service_id = $1 AND branch_id = $2;
+
+-- name: ListAdminBranchIDs :many
+SELECT branch_id FROM admin_branches
+WHERE admin_id = $1
+ORDER BY branch_id;
+
+-- name: AddAdminBranch :exec
+INSERT INTO admin_branches (
+  admin_id,
+  branch_id
+) VALUES (
+  $1, $2
+);
+
+-- name: DeleteAdminBranches :exec
+DELETE FROM admin_branches
+WHERE admin_id = $1;
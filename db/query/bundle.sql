@@ -0,0 +1,50 @@
+-- name: CreateBundle :one
+INSERT INTO bundles (
+  name,
+  description,
+  price,
+  is_active
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetBundle :one
+SELECT * FROM bundles
+WHERE bundle_id = $1 LIMIT 1;
+
+-- name: ListBundles :many
+SELECT * FROM bundles
+ORDER BY name, bundle_id;
+
+-- name: UpdateBundle :one
+UPDATE bundles
+SET name = $2,
+description = $3,
+price = $4,
+is_active = $5
+WHERE bundle_id = $1
+RETURNING *;
+
+-- name: DeleteBundle :exec
+DELETE FROM bundles WHERE bundle_id = $1;
+
+-- name: AddBundleItem :one
+INSERT INTO bundle_items (
+  bundle_id,
+  service_id,
+  quantity
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: ListBundleItems :many
+SELECT * FROM bundle_items
+WHERE bundle_id = $1
+ORDER BY service_id;
+
+-- name: ListAllBundleItems :many
+SELECT * FROM bundle_items
+ORDER BY bundle_id, service_id;
+
+-- name: DeleteBundleItems :exec
+DELETE FROM bundle_items WHERE bundle_id = $1;
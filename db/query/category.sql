@@ -0,0 +1,31 @@
+-- name: CreateCategory :one
+INSERT INTO categories (
+  parent_id,
+  name,
+  icon,
+  display_order,
+  is_active
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetCategory :one
+SELECT * FROM categories
+WHERE category_id = $1 LIMIT 1;
+
+-- name: ListCategories :many
+SELECT * FROM categories
+ORDER BY display_order, name, category_id;
+
+-- name: UpdateCategory :one
+UPDATE categories
+SET parent_id = $2,
+name = $3,
+icon = $4,
+display_order = $5,
+is_active = $6
+WHERE category_id = $1
+RETURNING *;
+
+-- name: DeleteCategory :exec
+DELETE FROM categories WHERE category_id = $1;
@@ -0,0 +1,37 @@
+-- name: CreateExportJob :one
+INSERT INTO export_jobs (
+  kind,
+  format,
+  filters,
+  row_count,
+  requested_by
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetExportJob :one
+SELECT * FROM export_jobs
+WHERE job_id = $1 LIMIT 1;
+
+-- name: ListExportJobs :many
+SELECT * FROM export_jobs
+WHERE (sqlc.narg('requested_by')::int IS NULL OR requested_by = sqlc.narg('requested_by'))
+ORDER BY job_id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: StartExportJob :one
+UPDATE export_jobs
+SET status = 'running'
+WHERE job_id = $1
+AND status = 'pending'
+RETURNING *;
+
+-- name: FinishExportJob :one
+UPDATE export_jobs
+SET status = $2,
+file_key = $3,
+error = $4,
+finished_at = now()
+WHERE job_id = $1
+RETURNING *;
@@ -0,0 +1,35 @@
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+  idempotency_key,
+  scope,
+  request_hash
+) VALUES (
+  sqlc.arg('idempotency_key'), sqlc.arg('scope'), sqlc.arg('request_hash')
+)
+ON CONFLICT (scope, idempotency_key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+response_status = NULL,
+response_body = NULL,
+content_type = '',
+created_at = now()
+WHERE idempotency_keys.created_at < sqlc.arg('expired_before')
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE scope = $1
+AND idempotency_key = $2 LIMIT 1;
+
+-- name: SaveIdempotencyResponse :exec
+UPDATE idempotency_keys
+SET response_status = $2,
+response_body = $3,
+content_type = $4
+WHERE id = $1;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE id = $1;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE created_at < $1;
@@ -0,0 +1,69 @@
+-- name: NextInvoiceNumber :one
+INSERT INTO invoice_counters (
+  series,
+  last_number
+) VALUES (
+  $1, 1
+)
+ON CONFLICT (series) DO UPDATE
+SET last_number = invoice_counters.last_number + 1
+RETURNING last_number;
+
+-- name: CreateInvoice :one
+INSERT INTO invoices (
+  invoice_number,
+  order_id,
+  user_id,
+  customer_name,
+  customer_email,
+  customer_phone,
+  customer_address,
+  subtotal,
+  tax_total,
+  total
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING *;
+
+-- name: CreateInvoiceItem :one
+INSERT INTO invoice_items (
+  invoice_id,
+  service_id,
+  description,
+  quantity,
+  unit_price,
+  amount
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: CreateInvoiceTax :one
+INSERT INTO invoice_taxes (
+  invoice_id,
+  tax_name,
+  rate_bps,
+  taxable_amount,
+  tax_amount
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetInvoiceByOrderID :one
+SELECT * FROM invoices
+WHERE order_id = $1 LIMIT 1;
+
+-- name: ListInvoiceItems :many
+SELECT * FROM invoice_items
+WHERE invoice_id = $1
+ORDER BY id;
+
+-- name: ListInvoiceTaxes :many
+SELECT * FROM invoice_taxes
+WHERE invoice_id = $1
+ORDER BY id;
+
+-- name: SetInvoicePDF :one
+UPDATE invoices
+SET pdf_key = $2
+WHERE invoice_id = $1
+RETURNING *;
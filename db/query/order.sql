@@ -3,9 +3,13 @@ INSERT INTO orders (
   order_id,
   user_id,
   service_ids,
-  order_status 
+  order_status,
+  variant_id,
+  unit_price,
+  bundle_id,
+  branch_id
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6, $7, $8
 ) RETURNING *;
 
 -- name: GetOrder :many
@@ -14,12 +18,15 @@ WHERE order_id = $1;
 
 -- name: ListOrders :many
 SELECT * FROM orders
+WHERE (sqlc.narg('sla_state')::varchar IS NULL OR sla_state = sqlc.narg('sla_state'))
+AND (COALESCE(cardinality(sqlc.arg('branch_ids')::int[]), 0) = 0 OR branch_id = ANY(sqlc.arg('branch_ids')::int[]))
 ORDER BY order_id DESC
-LIMIT $1
-OFFSET $2;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
 
 -- name: ListAllOrders :many
 SELECT * FROM orders
+WHERE (COALESCE(cardinality(sqlc.arg('branch_ids')::int[]), 0) = 0 OR branch_id = ANY(sqlc.arg('branch_ids')::int[]))
 ORDER BY id DESC;
 
 -- name: ListAllOrdersByUserId :many
@@ -52,4 +59,107 @@ WHERE order_id = $1
 RETURNING *;
 
 -- name: DeleteOrder :exec
-DELETE FROM orders WHERE order_id = $1;
\ No newline at end of file
+DELETE FROM orders WHERE order_id = $1;
+
+-- name: GetOrderSubtotal :one
+SELECT COALESCE(SUM(COALESCE(orders.unit_price, services.service_price)), 0)::bigint AS subtotal
+FROM orders
+JOIN services ON services.service_id = orders.service_ids
+WHERE orders.order_id = $1;
+
+-- name: UpdateOrderSlots :many
+UPDATE orders
+SET pickup_slot_id = $2,
+delivery_slot_id = $3,
+order_delivery_time = $4
+WHERE order_id = $1
+RETURNING *;
+
+-- name: GetOrderForUpdate :many
+SELECT * FROM orders
+WHERE order_id = $1
+FOR NO KEY UPDATE;
+
+-- name: AssignOrder :many
+UPDATE orders
+SET assigned_to = $2,
+assigned_at = $3,
+modified_by = $4
+WHERE order_id = $1
+RETURNING *;
+
+-- name: UpdateOrderPriority :many
+UPDATE orders
+SET priority = $2,
+modified_by = $3
+WHERE order_id = $1
+RETURNING *;
+
+-- name: ListAssignedOrders :many
+SELECT * FROM orders
+WHERE assigned_to = sqlc.arg('assigned_to')
+AND order_delivered = false
+AND order_status <> 'Cancelled'
+AND (COALESCE(cardinality(sqlc.arg('branch_ids')::int[]), 0) = 0 OR branch_id = ANY(sqlc.arg('branch_ids')::int[]))
+ORDER BY priority DESC, due_at NULLS LAST, order_delivery_time, order_id, id;
+
+-- name: SetOrderDueAt :many
+UPDATE orders
+SET due_at = $2
+WHERE order_id = $1
+RETURNING *;
+
+-- name: FlagAtRiskOrders :many
+UPDATE orders
+SET sla_state = 'at_risk'
+WHERE sla_state = 'on_track'
+AND order_delivered = false
+AND order_status <> 'Cancelled'
+AND due_at > sqlc.arg('now')::timestamptz
+AND due_at <= sqlc.arg('at_risk_before')::timestamptz
+RETURNING *;
+
+-- name: FlagOverdueOrders :many
+UPDATE orders
+SET sla_state = 'overdue'
+WHERE sla_state <> 'overdue'
+AND order_delivered = false
+AND order_status <> 'Cancelled'
+AND due_at <= sqlc.arg('now')::timestamptz
+RETURNING *;
+
+-- name: SetOrderStatus :many
+UPDATE orders
+SET order_status = $2,
+modified_by = $3
+WHERE order_id = $1
+RETURNING *;
+
+-- name: MarkOrderDelivered :many
+UPDATE orders
+SET order_status = $2,
+order_delivered = true,
+order_delivery_time = $3,
+modified_by = $4
+WHERE order_id = $1
+RETURNING *;
+
+-- name: ListOrderExportRows :many
+SELECT orders.id, orders.order_id, orders.order_status, orders.order_started,
+orders.order_delivered, orders.order_delivery_time, orders.due_at, orders.sla_state, orders.branch_id,
+users.user_id, users.name AS customer_name, users.email AS customer_email, users.phone AS customer_phone,
+services.service_id, services.service_name,
+COALESCE(orders.unit_price, services.service_price)::bigint AS service_price
+FROM orders
+JOIN users ON users.user_id = orders.user_id
+JOIN services ON services.service_id = orders.service_ids
+WHERE orders.id > sqlc.arg('after_id')
+AND (sqlc.narg('sla_state')::varchar IS NULL OR orders.sla_state = sqlc.narg('sla_state'))
+AND (COALESCE(cardinality(sqlc.arg('branch_ids')::int[]), 0) = 0 OR orders.branch_id = ANY(sqlc.arg('branch_ids')::int[]))
+ORDER BY orders.id
+LIMIT sqlc.arg('limit');
+
+-- name: CountOrderExportRows :one
+SELECT count(*) FROM orders
+WHERE (sqlc.narg('sla_state')::varchar IS NULL OR sla_state = sqlc.narg('sla_state'))
+AND (COALESCE(cardinality(sqlc.arg('branch_ids')::int[]), 0) = 0 OR branch_id = ANY(sqlc.arg('branch_ids')::int[]));
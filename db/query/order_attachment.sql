@@ -0,0 +1,39 @@
+-- name: CreateOrderAttachment :one
+INSERT INTO order_attachments (
+  order_id,
+  note_id,
+  file_url,
+  file_name,
+  content_type,
+  size_bytes,
+  visibility,
+  admin_id,
+  user_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetOrderAttachment :one
+SELECT * FROM order_attachments
+WHERE attachment_id = $1 LIMIT 1;
+
+-- name: ListOrderAttachments :many
+SELECT * FROM order_attachments
+WHERE order_id = sqlc.arg('order_id')
+AND (sqlc.arg('include_internal')::boolean OR visibility = 'customer')
+ORDER BY attachment_id;
+
+-- name: ListPublicOrderAttachments :many
+SELECT * FROM order_attachments
+WHERE attachment_id > sqlc.arg('after_id')
+AND position('/private/' in file_url) = 0
+ORDER BY attachment_id
+LIMIT sqlc.arg('limit');
+
+-- name: SetOrderAttachmentFileURL :exec
+UPDATE order_attachments
+SET file_url = $2
+WHERE attachment_id = $1;
+
+-- name: DeleteOrderAttachment :exec
+DELETE FROM order_attachments WHERE attachment_id = $1;
@@ -0,0 +1,14 @@
+-- name: CreateOrderHistory :one
+INSERT INTO order_history (
+  order_id,
+  event,
+  details,
+  changed_by
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListOrderHistory :many
+SELECT * FROM order_history
+WHERE order_id = $1
+ORDER BY id;
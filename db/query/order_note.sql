@@ -0,0 +1,23 @@
+-- name: CreateOrderNote :one
+INSERT INTO order_notes (
+  order_id,
+  body,
+  visibility,
+  admin_id,
+  user_id
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetOrderNote :one
+SELECT * FROM order_notes
+WHERE note_id = $1 LIMIT 1;
+
+-- name: ListOrderNotes :many
+SELECT * FROM order_notes
+WHERE order_id = sqlc.arg('order_id')
+AND (sqlc.arg('include_internal')::boolean OR visibility = 'customer')
+ORDER BY note_id;
+
+-- name: DeleteOrderNote :exec
+DELETE FROM order_notes WHERE note_id = $1;
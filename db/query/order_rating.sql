@@ -0,0 +1,40 @@
+-- name: CreateOrderRating :one
+INSERT INTO order_ratings (
+  order_id,
+  user_id,
+  service_id,
+  rating,
+  comment
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetOrderRating :one
+SELECT * FROM order_ratings
+WHERE rating_id = $1 LIMIT 1;
+
+-- name: ListOrderRatings :many
+SELECT * FROM order_ratings
+WHERE order_id = $1
+ORDER BY rating_id;
+
+-- name: ListRatings :many
+SELECT * FROM order_ratings
+WHERE COALESCE(cardinality(sqlc.arg('branch_ids')::int[]), 0) = 0
+OR order_id IN (SELECT order_id FROM orders WHERE branch_id = ANY(sqlc.arg('branch_ids')::int[]))
+ORDER BY rating_id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: UpdateOrderRating :one
+UPDATE order_ratings
+SET rating = $2,
+comment = $3,
+updated_at = now()
+WHERE rating_id = $1
+RETURNING *;
+
+-- name: GetServiceRatingSummary :one
+SELECT COALESCE(AVG(rating), 0)::float AS average_rating, COUNT(*) AS rating_count
+FROM order_ratings
+WHERE service_id = $1;
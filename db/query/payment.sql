@@ -0,0 +1,84 @@
+-- name: CreatePayment :one
+INSERT INTO payments (
+  order_id,
+  method,
+  amount,
+  reference,
+  status,
+  refund_of,
+  recorded_by,
+  provider
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetPayment :one
+SELECT * FROM payments
+WHERE payment_id = $1 LIMIT 1;
+
+-- name: GetPaymentByProviderReference :one
+SELECT * FROM payments
+WHERE provider = $1
+AND reference = $2 LIMIT 1;
+
+-- name: GetPaymentForUpdate :one
+SELECT * FROM payments
+WHERE payment_id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetPendingPayment :one
+SELECT * FROM payments
+WHERE order_id = $1
+AND provider = $2
+AND status = 'pending'
+ORDER BY payment_id DESC
+LIMIT 1;
+
+-- name: SupersedePayment :exec
+UPDATE payments
+SET status = 'failed'
+WHERE payment_id = $1
+AND status = 'pending';
+
+-- name: ListPaymentsByOrder :many
+SELECT * FROM payments
+WHERE order_id = $1
+ORDER BY payment_id;
+
+-- name: GetRefundedAmount :one
+SELECT COALESCE(-SUM(amount), 0)::bigint AS refunded
+FROM payments
+WHERE refund_of = $1
+AND status <> 'failed';
+
+-- name: GetOrderPaymentSummary :one
+SELECT
+  COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0)::bigint AS paid,
+  COALESCE(-SUM(amount) FILTER (WHERE amount < 0), 0)::bigint AS refunded
+FROM payments
+WHERE order_id = $1
+AND status = 'captured';
+
+-- name: UpdatePaymentFromGateway :one
+UPDATE payments
+SET status = $2,
+method = $3,
+gateway_payment_id = $4
+WHERE payment_id = $1
+AND status <> 'captured'
+AND status <> $2
+RETURNING *;
+
+-- name: ListOrderPaymentSummaries :many
+SELECT orders.order_id,
+  COALESCE(SUM(COALESCE(orders.unit_price, services.service_price)), 0)::bigint AS subtotal,
+  invoices.total AS invoice_total,
+  (SELECT COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0) FROM payments
+   WHERE payments.order_id = orders.order_id AND payments.status = 'captured')::bigint AS paid,
+  (SELECT COALESCE(-SUM(amount) FILTER (WHERE amount < 0), 0) FROM payments
+   WHERE payments.order_id = orders.order_id AND payments.status = 'captured')::bigint AS refunded
+FROM orders
+JOIN services ON services.service_id = orders.service_ids
+LEFT JOIN invoices ON invoices.order_id = orders.order_id
+WHERE orders.order_id = ANY(sqlc.arg('order_ids')::bigint[])
+GROUP BY orders.order_id, invoices.total;
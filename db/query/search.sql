@@ -0,0 +1,46 @@
+-- name: SearchUsers :many
+SELECT users.user_id, users.name, users.email, users.phone,
+(ts_rank(to_tsvector('simple', users.name || ' ' || users.email), plainto_tsquery('simple', sqlc.arg('query')::text))
++ GREATEST(similarity(users.name, sqlc.arg('query')::text), similarity(users.email, sqlc.arg('query')::text))
++ CASE WHEN sqlc.arg('digits')::text <> '' AND regexp_replace(users.phone, '\D', '', 'g') LIKE '%' || sqlc.arg('digits')::text || '%' THEN 1 ELSE 0 END)::real AS rank
+FROM users
+WHERE users.archived_at IS NULL
+AND (sqlc.narg('branch_id')::int IS NULL OR users.branch_id = sqlc.narg('branch_id'))
+AND (
+  to_tsvector('simple', users.name || ' ' || users.email) @@ plainto_tsquery('simple', sqlc.arg('query')::text)
+  OR users.name ILIKE '%' || sqlc.arg('query')::text || '%'
+  OR users.email ILIKE '%' || sqlc.arg('query')::text || '%'
+  OR users.name % sqlc.arg('query')::text
+  OR (sqlc.arg('digits')::text <> '' AND regexp_replace(users.phone, '\D', '', 'g') LIKE '%' || sqlc.arg('digits')::text || '%')
+)
+ORDER BY rank DESC, users.user_id
+LIMIT sqlc.arg('limit');
+
+-- name: SearchServices :many
+SELECT services.service_id, services.service_name, COALESCE(service_branches.price, services.service_price)::bigint AS service_price, services.service_image,
+services.image_thumbnail, services.turnaround_hours, services.category_id,
+(ts_rank(to_tsvector('simple', services.service_name), plainto_tsquery('simple', sqlc.arg('query')::text))
++ similarity(services.service_name, sqlc.arg('query')::text))::real AS rank
+FROM services
+LEFT JOIN service_branches ON service_branches.service_id = services.service_id
+AND service_branches.branch_id = sqlc.narg('branch_id')
+WHERE services.archived_at IS NULL
+AND COALESCE(service_branches.is_available, true)
+AND (
+  to_tsvector('simple', services.service_name) @@ plainto_tsquery('simple', sqlc.arg('query')::text)
+  OR services.service_name ILIKE '%' || sqlc.arg('query')::text || '%'
+  OR services.service_name % sqlc.arg('query')::text
+)
+ORDER BY rank DESC, services.service_id
+LIMIT sqlc.arg('limit');
+
+-- name: SearchOrders :many
+SELECT orders.order_id, orders.user_id, users.name AS customer_name, orders.order_status,
+MIN(orders.order_started)::timestamptz AS order_started
+FROM orders
+JOIN users ON users.user_id = orders.user_id
+WHERE orders.order_id::text LIKE sqlc.arg('digits')::text || '%'
+AND (COALESCE(cardinality(sqlc.arg('branch_ids')::int[]), 0) = 0 OR orders.branch_id = ANY(sqlc.arg('branch_ids')::int[]))
+GROUP BY orders.order_id, orders.user_id, users.name, orders.order_status
+ORDER BY orders.order_id DESC
+LIMIT sqlc.arg('limit');
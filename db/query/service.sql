@@ -2,9 +2,10 @@
 INSERT INTO services (
   service_name,
   service_price,
-  service_image 
+  service_image,
+  turnaround_hours
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 ) RETURNING *;
 
 -- name: GetService :one
@@ -13,21 +14,115 @@ WHERE service_id = $1 LIMIT 1;
 
 -- name: ListLimitedServices :many
 SELECT * FROM services
+WHERE (archived_at IS NULL OR sqlc.arg('include_archived')::boolean)
+AND (sqlc.narg('branch_id')::int IS NULL OR NOT EXISTS (
+  SELECT 1 FROM service_branches
+  WHERE service_branches.service_id = services.service_id
+  AND service_branches.branch_id = sqlc.narg('branch_id')
+  AND NOT service_branches.is_available
+))
 ORDER BY service_id
-LIMIT $1
-OFFSET $2;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
 
 -- name: ListAllServices :many
 SELECT * FROM services
+WHERE archived_at IS NULL
 ORDER BY service_id;
 
+-- name: ListServicesAfter :many
+SELECT * FROM services
+WHERE service_id > $1 AND archived_at IS NULL
+ORDER BY service_id
+LIMIT $2;
+
+-- name: SetServiceCategory :one
+UPDATE services
+SET category_id = $2,
+version = version + 1
+WHERE service_id = $1
+RETURNING *;
+
+-- name: CountServices :one
+SELECT count(*) FROM services
+WHERE archived_at IS NULL;
+
+-- name: GetServiceByName :one
+SELECT * FROM services
+WHERE service_name = $1 LIMIT 1;
+
+-- name: UpsertService :one
+INSERT INTO services (
+  service_name,
+  service_price,
+  service_image,
+  turnaround_hours
+) VALUES (
+  $1, $2, $3, $4
+) ON CONFLICT (service_name) DO UPDATE
+SET service_price = EXCLUDED.service_price,
+service_image = CASE WHEN EXCLUDED.service_image = '' THEN services.service_image ELSE EXCLUDED.service_image END,
+turnaround_hours = EXCLUDED.turnaround_hours,
+version = services.version + 1,
+image_thumbnail = CASE WHEN EXCLUDED.service_image IN ('', services.service_image) THEN services.image_thumbnail ELSE '' END,
+image_medium = CASE WHEN EXCLUDED.service_image IN ('', services.service_image) THEN services.image_medium ELSE '' END,
+image_webp = CASE WHEN EXCLUDED.service_image IN ('', services.service_image) THEN services.image_webp ELSE '' END
+RETURNING *;
+
 -- name: UpdateService :one
-UPDATE services 
-SET service_name = $2,
-service_price = $3,
-service_image = $4
+UPDATE services
+SET service_name = COALESCE(sqlc.narg('service_name'), service_name),
+service_price = COALESCE(sqlc.narg('service_price'), service_price),
+turnaround_hours = COALESCE(sqlc.narg('turnaround_hours'), turnaround_hours),
+service_image = COALESCE(sqlc.narg('service_image'), service_image),
+image_thumbnail = CASE WHEN COALESCE(sqlc.narg('service_image'), service_image) = service_image THEN image_thumbnail ELSE '' END,
+image_medium = CASE WHEN COALESCE(sqlc.narg('service_image'), service_image) = service_image THEN image_medium ELSE '' END,
+image_webp = CASE WHEN COALESCE(sqlc.narg('service_image'), service_image) = service_image THEN image_webp ELSE '' END,
+version = version + 1
+WHERE service_id = sqlc.arg('service_id')
+AND version = sqlc.arg('version')
+RETURNING *;
+
+-- name: ArchiveService :one
+UPDATE services
+SET archived_at = COALESCE(archived_at, now())
+WHERE service_id = $1
+RETURNING *;
+
+-- name: RestoreService :one
+UPDATE services
+SET archived_at = NULL
+WHERE service_id = $1
+RETURNING *;
+
+-- name: SetServicePrice :one
+UPDATE services
+SET service_price = $2,
+version = version + 1
+WHERE service_id = $1
+RETURNING *;
+
+-- name: SetServiceImage :one
+UPDATE services
+SET service_image = $2,
+image_thumbnail = '',
+image_medium = '',
+image_webp = '',
+version = version + 1
+WHERE service_id = $1
+AND version = $3
+AND archived_at IS NULL
+RETURNING *;
+
+-- name: SetServiceImageVariants :one
+UPDATE services
+SET image_thumbnail = $3,
+image_medium = $4,
+image_webp = $5
 WHERE service_id = $1
+AND service_image = $2
 RETURNING *;
 
--- name: DeleteService :exec
-DELETE FROM services WHERE service_id = $1;
\ No newline at end of file
+-- name: CountServicesWithImage :one
+SELECT count(*) FROM services
+WHERE service_image = $1;
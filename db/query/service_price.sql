@@ -0,0 +1,47 @@
+-- name: CreateServicePrice :one
+INSERT INTO service_price_history (
+  service_id,
+  price,
+  effective_at,
+  created_by
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetServicePrice :one
+SELECT * FROM service_price_history
+WHERE price_id = $1 LIMIT 1;
+
+-- name: ListServicePrices :many
+SELECT * FROM service_price_history
+WHERE service_id = $1
+ORDER BY effective_at, price_id;
+
+-- name: GetEffectiveServicePrice :one
+SELECT COALESCE((
+  SELECT service_price_history.price FROM service_price_history
+  WHERE service_price_history.service_id = services.service_id
+  AND service_price_history.effective_at <= sqlc.arg('at')
+  ORDER BY service_price_history.effective_at DESC, service_price_history.price_id DESC
+  LIMIT 1
+), services.service_price)::bigint AS price
+FROM services
+WHERE services.service_id = sqlc.arg('service_id');
+
+-- name: DeleteScheduledServicePrice :one
+DELETE FROM service_price_history
+WHERE price_id = $1 AND effective_at > now()
+RETURNING *;
+
+-- name: ApplyDueServicePrices :many
+UPDATE services
+SET service_price = effective.price
+FROM (
+  SELECT DISTINCT ON (service_id) service_id, price
+  FROM service_price_history
+  WHERE effective_at <= now()
+  ORDER BY service_id, effective_at DESC, price_id DESC
+) AS effective
+WHERE services.service_id = effective.service_id
+AND services.service_price <> effective.price
+RETURNING services.*;
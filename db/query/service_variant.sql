@@ -0,0 +1,35 @@
+-- name: CreateServiceVariant :one
+INSERT INTO service_variants (
+  service_id,
+  name,
+  sku,
+  price,
+  turnaround_hours
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetServiceVariant :one
+SELECT * FROM service_variants
+WHERE variant_id = $1 LIMIT 1;
+
+-- name: ListServiceVariants :many
+SELECT * FROM service_variants
+WHERE service_id = $1
+ORDER BY price, variant_id;
+
+-- name: ListAllServiceVariants :many
+SELECT * FROM service_variants
+ORDER BY service_id, price, variant_id;
+
+-- name: UpdateServiceVariant :one
+UPDATE service_variants
+SET name = $2,
+sku = $3,
+price = $4,
+turnaround_hours = $5
+WHERE variant_id = $1
+RETURNING *;
+
+-- name: DeleteServiceVariant :exec
+DELETE FROM service_variants WHERE variant_id = $1;
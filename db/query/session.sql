@@ -13,4 +13,9 @@ INSERT INTO sessions (
 
 -- name: GetSession :one
 SELECT * FROM sessions
-WHERE id = $1 LIMIT 1;
\ No newline at end of file
+WHERE id = $1 LIMIT 1;
+
+-- name: BlockSessions :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE email = $1;
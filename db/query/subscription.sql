@@ -0,0 +1,87 @@
+-- name: CreateSubscription :one
+INSERT INTO subscriptions (
+  user_id,
+  frequency,
+  cron_expr,
+  next_run_at,
+  branch_id,
+  anchor_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetSubscription :one
+SELECT * FROM subscriptions
+WHERE subscription_id = $1 LIMIT 1;
+
+-- name: GetSubscriptionForUpdate :one
+SELECT * FROM subscriptions
+WHERE subscription_id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListSubscriptionsByUser :many
+SELECT * FROM subscriptions
+WHERE user_id = $1
+ORDER BY subscription_id;
+
+-- name: ListSubscriptions :many
+SELECT * FROM subscriptions
+WHERE (COALESCE(cardinality(sqlc.arg('branch_ids')::int[]), 0) = 0 OR branch_id = ANY(sqlc.arg('branch_ids')::int[]))
+ORDER BY subscription_id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListDueSubscriptions :many
+SELECT * FROM subscriptions
+WHERE status = 'active'
+AND next_run_at <= sqlc.arg('now')
+ORDER BY next_run_at
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateSubscriptionStatus :one
+UPDATE subscriptions
+SET status = $2,
+next_run_at = $3,
+updated_at = now()
+WHERE subscription_id = $1
+RETURNING *;
+
+-- name: UpdateSubscriptionSchedule :one
+UPDATE subscriptions
+SET next_run_at = $2,
+failure_count = $3,
+last_error = $4,
+updated_at = now()
+WHERE subscription_id = $1
+RETURNING *;
+
+-- name: AddSubscriptionService :one
+INSERT INTO subscription_services (
+  subscription_id,
+  service_id,
+  quantity
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: ListSubscriptionServices :many
+SELECT * FROM subscription_services
+WHERE subscription_id = $1
+ORDER BY service_id;
+
+-- name: CreateSubscriptionRun :one
+INSERT INTO subscription_runs (
+  subscription_id,
+  scheduled_for,
+  status,
+  order_id,
+  error
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListSubscriptionRuns :many
+SELECT * FROM subscription_runs
+WHERE subscription_id = $1
+ORDER BY run_id DESC
+LIMIT $2;
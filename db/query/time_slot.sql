@@ -0,0 +1,52 @@
+-- name: CreateTimeSlot :one
+INSERT INTO time_slots (
+  slot_type,
+  starts_at,
+  ends_at,
+  capacity
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (slot_type, starts_at) DO NOTHING
+RETURNING *;
+
+-- name: GetTimeSlot :one
+SELECT * FROM time_slots
+WHERE slot_id = $1 LIMIT 1;
+
+-- name: ListAvailableTimeSlots :many
+SELECT * FROM time_slots
+WHERE slot_type = sqlc.arg('slot_type')
+AND starts_at >= sqlc.arg('from_time')
+AND starts_at < sqlc.arg('to_time')
+AND starts_at > now()
+AND booked < capacity
+ORDER BY starts_at;
+
+-- name: ListTimeSlots :many
+SELECT * FROM time_slots
+WHERE starts_at >= sqlc.arg('from_time')
+AND starts_at < sqlc.arg('to_time')
+AND (sqlc.narg('slot_type')::varchar IS NULL OR slot_type = sqlc.narg('slot_type'))
+ORDER BY starts_at, slot_type;
+
+-- name: UpdateTimeSlotCapacity :one
+UPDATE time_slots
+SET capacity = $2
+WHERE slot_id = $1
+RETURNING *;
+
+-- name: BookTimeSlot :one
+UPDATE time_slots
+SET booked = booked + 1
+WHERE slot_id = $1
+AND slot_type = $2
+AND starts_at > now()
+AND booked < capacity
+RETURNING *;
+
+-- name: ReleaseTimeSlot :exec
+UPDATE time_slots
+SET booked = booked - 1
+WHERE slot_id = $1
+AND booked > 0;
@@ -0,0 +1,37 @@
+-- name: CreateUpload :one
+INSERT INTO uploads (
+  upload_id,
+  object_key,
+  file_name,
+  content_type,
+  size_bytes,
+  purpose,
+  admin_id,
+  user_id,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetUpload :one
+SELECT * FROM uploads
+WHERE upload_id = $1 LIMIT 1;
+
+-- name: ConfirmUpload :one
+UPDATE uploads
+SET confirmed_at = now()
+WHERE upload_id = $1
+AND confirmed_at IS NULL
+AND expires_at > now()
+RETURNING *;
+
+-- name: ListExpiredUploads :many
+SELECT * FROM uploads
+WHERE confirmed_at IS NULL
+AND expires_at < sqlc.arg('expired_before')
+ORDER BY expires_at
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteUpload :exec
+DELETE FROM uploads
+WHERE upload_id = $1 AND confirmed_at IS NULL;
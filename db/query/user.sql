@@ -4,29 +4,65 @@ INSERT INTO users (
   email,
   phone,
   address,
-  hashed_password 
+  hashed_password,
+  branch_id
 ) VALUES (
-  $1, $2, $3, $4, $5
+  $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetUser :one
 SELECT * FROM users
 WHERE user_id = $1 LIMIT 1;
 
+-- name: GetActiveUser :one
+SELECT * FROM users
+WHERE user_id = $1 AND archived_at IS NULL LIMIT 1;
+
 -- name: GetUserByEmail :one
 SELECT * FROM users
 WHERE email = $1 LIMIT 1;
 
 -- name: ListUsers :many
 SELECT * FROM users
+WHERE (archived_at IS NULL OR sqlc.arg('include_archived')::boolean)
+AND (sqlc.narg('branch_id')::int IS NULL OR branch_id = sqlc.narg('branch_id'))
 ORDER BY user_id
-LIMIT $1
-OFFSET $2;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
 
 -- name: ListAllUsers :many
 SELECT * FROM users
+WHERE archived_at IS NULL
+AND (sqlc.narg('branch_id')::int IS NULL OR branch_id = sqlc.narg('branch_id'))
 ORDER BY user_id;
 
+-- name: ListUsersAfter :many
+SELECT * FROM users
+WHERE user_id > sqlc.arg('user_id') AND archived_at IS NULL
+AND (COALESCE(cardinality(sqlc.arg('branch_ids')::int[]), 0) = 0 OR branch_id = ANY(sqlc.arg('branch_ids')::int[]))
+ORDER BY user_id
+LIMIT sqlc.arg('limit');
+
+-- name: CountUsers :one
+SELECT count(*) FROM users
+WHERE archived_at IS NULL
+AND (COALESCE(cardinality(sqlc.arg('branch_ids')::int[]), 0) = 0 OR branch_id = ANY(sqlc.arg('branch_ids')::int[]));
+
+-- name: UpsertUser :one
+INSERT INTO users (
+  name,
+  email,
+  phone,
+  address,
+  hashed_password
+) VALUES (
+  $1, $2, $3, $4, $5
+) ON CONFLICT (email) DO UPDATE
+SET name = EXCLUDED.name,
+phone = EXCLUDED.phone,
+address = EXCLUDED.address
+RETURNING *;
+
 -- name: UpdateUser :one
 UPDATE users 
 SET email = $2,
@@ -43,5 +79,20 @@ SET total_orders = $2
 WHERE user_id = $1
 RETURNING *;
 
--- name: DeleteUser :exec
-DELETE FROM users WHERE user_id = $1;
\ No newline at end of file
+-- name: ArchiveUser :one
+UPDATE users
+SET archived_at = COALESCE(archived_at, now())
+WHERE user_id = $1
+RETURNING *;
+
+-- name: RestoreUser :one
+UPDATE users
+SET archived_at = NULL
+WHERE user_id = $1
+RETURNING *;
+
+-- name: SetUserProfileImage :one
+UPDATE users
+SET profile_image = $2
+WHERE user_id = $1
+RETURNING *;
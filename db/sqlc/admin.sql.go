@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const addAdmin = `-- name: AddAdmin :one
@@ -18,7 +19,7 @@ INSERT INTO admins (
   hashed_password 
 ) VALUES (
   $1, $2, $3, $4, $5
-) RETURNING admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at
+) RETURNING admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at, archived_at, profile_image
 `
 
 type AddAdminParams struct {
@@ -47,6 +48,33 @@ func (q *Queries) AddAdmin(ctx context.Context, arg AddAdminParams) (Admin, erro
 		&i.HashedPassword,
 		&i.CreatedAt,
 		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+	)
+	return i, err
+}
+
+const archiveAdmin = `-- name: ArchiveAdmin :one
+UPDATE admins
+SET archived_at = COALESCE(archived_at, now())
+WHERE admin_id = $1
+RETURNING admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at, archived_at, profile_image
+`
+
+func (q *Queries) ArchiveAdmin(ctx context.Context, adminID int32) (Admin, error) {
+	row := q.db.QueryRowContext(ctx, archiveAdmin, adminID)
+	var i Admin
+	err := row.Scan(
+		&i.AdminID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
 	)
 	return i, err
 }
@@ -60,7 +88,7 @@ INSERT INTO admins (
   hashed_password 
 ) VALUES (
   $1, $2, $3, $4, $5
-) RETURNING admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at
+) RETURNING admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at, archived_at, profile_image
 `
 
 type CreateAdminParams struct {
@@ -89,22 +117,61 @@ func (q *Queries) CreateAdmin(ctx context.Context, arg CreateAdminParams) (Admin
 		&i.HashedPassword,
 		&i.CreatedAt,
 		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
 	)
 	return i, err
 }
 
-const deleteAdmin = `-- name: DeleteAdmin :exec
-DELETE FROM admins WHERE admin_id = $1
+const getActiveAdmin = `-- name: GetActiveAdmin :one
+SELECT admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at, archived_at, profile_image FROM admins
+WHERE admin_id = $1 AND archived_at IS NULL LIMIT 1
 `
 
-func (q *Queries) DeleteAdmin(ctx context.Context, adminID int32) error {
-	_, err := q.db.ExecContext(ctx, deleteAdmin, adminID)
-	return err
+func (q *Queries) GetActiveAdmin(ctx context.Context, adminID int32) (Admin, error) {
+	row := q.db.QueryRowContext(ctx, getActiveAdmin, adminID)
+	var i Admin
+	err := row.Scan(
+		&i.AdminID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+	)
+	return i, err
+}
+
+const getAdmin = `-- name: GetAdmin :one
+SELECT admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at, archived_at, profile_image FROM admins
+WHERE admin_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAdmin(ctx context.Context, adminID int32) (Admin, error) {
+	row := q.db.QueryRowContext(ctx, getAdmin, adminID)
+	var i Admin
+	err := row.Scan(
+		&i.AdminID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+	)
+	return i, err
 }
 
 const getAdminByEmail = `-- name: GetAdminByEmail :one
-SELECT admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at FROM admins
-WHERE email = $1 LIMIT 1
+SELECT admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at, archived_at, profile_image FROM admins
+WHERE email = $1 AND archived_at IS NULL LIMIT 1
 `
 
 func (q *Queries) GetAdminByEmail(ctx context.Context, email string) (Admin, error) {
@@ -119,24 +186,80 @@ func (q *Queries) GetAdminByEmail(ctx context.Context, email string) (Admin, err
 		&i.HashedPassword,
 		&i.CreatedAt,
 		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
 	)
 	return i, err
 }
 
+const getLeastLoadedAdmin = `-- name: GetLeastLoadedAdmin :one
+SELECT admins.admin_id FROM admins
+LEFT JOIN orders ON orders.assigned_to = admins.admin_id
+AND orders.order_delivered = false
+AND orders.order_status <> 'Cancelled'
+WHERE admins.archived_at IS NULL
+AND (
+  NOT EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id)
+  OR EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id AND admin_branches.branch_id = $1)
+)
+GROUP BY admins.admin_id
+ORDER BY COUNT(DISTINCT orders.order_id), admins.admin_id
+LIMIT 1
+`
+
+func (q *Queries) GetLeastLoadedAdmin(ctx context.Context, branchID int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getLeastLoadedAdmin, branchID)
+	var admin_id int32
+	err := row.Scan(&admin_id)
+	return admin_id, err
+}
+
+const getRoundRobinAdmin = `-- name: GetRoundRobinAdmin :one
+SELECT admins.admin_id FROM admins
+LEFT JOIN orders ON orders.assigned_to = admins.admin_id
+WHERE admins.archived_at IS NULL
+AND (
+  NOT EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id)
+  OR EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id AND admin_branches.branch_id = $1)
+)
+GROUP BY admins.admin_id
+ORDER BY MAX(orders.assigned_at) NULLS FIRST, admins.admin_id
+LIMIT 1
+`
+
+func (q *Queries) GetRoundRobinAdmin(ctx context.Context, branchID int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getRoundRobinAdmin, branchID)
+	var admin_id int32
+	err := row.Scan(&admin_id)
+	return admin_id, err
+}
+
 const listAdmins = `-- name: ListAdmins :many
-SELECT admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at FROM admins
+SELECT admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at, archived_at, profile_image FROM admins
+WHERE (archived_at IS NULL OR $1::boolean)
+AND ($2::int IS NULL OR (
+  NOT EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id)
+  OR EXISTS (SELECT 1 FROM admin_branches WHERE admin_branches.admin_id = admins.admin_id AND admin_branches.branch_id = $2)
+))
 ORDER BY admin_id
-LIMIT $1
-OFFSET $2
+LIMIT $3
+OFFSET $4
 `
 
 type ListAdminsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	IncludeArchived bool          `json:"include_archived"`
+	BranchID        sql.NullInt32 `json:"branch_id"`
+	Limit           int32         `json:"limit"`
+	Offset          int32         `json:"offset"`
 }
 
 func (q *Queries) ListAdmins(ctx context.Context, arg ListAdminsParams) ([]Admin, error) {
-	rows, err := q.db.QueryContext(ctx, listAdmins, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listAdmins,
+		arg.IncludeArchived,
+		arg.BranchID,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -153,6 +276,8 @@ func (q *Queries) ListAdmins(ctx context.Context, arg ListAdminsParams) ([]Admin
 			&i.HashedPassword,
 			&i.CreatedAt,
 			&i.PasswordChangedAt,
+			&i.ArchivedAt,
+			&i.ProfileImage,
 		); err != nil {
 			return nil, err
 		}
@@ -166,3 +291,67 @@ func (q *Queries) ListAdmins(ctx context.Context, arg ListAdminsParams) ([]Admin
 	}
 	return items, nil
 }
+
+const lockBranchAssignment = `-- name: LockBranchAssignment :exec
+SELECT pg_advisory_xact_lock(hashtext('order_assignment'), $1::int)
+`
+
+func (q *Queries) LockBranchAssignment(ctx context.Context, branchID int32) error {
+	_, err := q.db.ExecContext(ctx, lockBranchAssignment, branchID)
+	return err
+}
+
+const restoreAdmin = `-- name: RestoreAdmin :one
+UPDATE admins
+SET archived_at = NULL
+WHERE admin_id = $1
+RETURNING admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at, archived_at, profile_image
+`
+
+func (q *Queries) RestoreAdmin(ctx context.Context, adminID int32) (Admin, error) {
+	row := q.db.QueryRowContext(ctx, restoreAdmin, adminID)
+	var i Admin
+	err := row.Scan(
+		&i.AdminID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+	)
+	return i, err
+}
+
+const setAdminProfileImage = `-- name: SetAdminProfileImage :one
+UPDATE admins
+SET profile_image = $2
+WHERE admin_id = $1
+RETURNING admin_id, name, email, phone, address, hashed_password, created_at, password_changed_at, archived_at, profile_image
+`
+
+type SetAdminProfileImageParams struct {
+	AdminID      int32  `json:"admin_id"`
+	ProfileImage string `json:"profile_image"`
+}
+
+func (q *Queries) SetAdminProfileImage(ctx context.Context, arg SetAdminProfileImageParams) (Admin, error) {
+	row := q.db.QueryRowContext(ctx, setAdminProfileImage, arg.AdminID, arg.ProfileImage)
+	var i Admin
+	err := row.Scan(
+		&i.AdminID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+	)
+	return i, err
+}
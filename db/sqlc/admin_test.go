@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAdmin(t *testing.T) Admin {
+	arg := CreateAdminParams{
+		Name:    util.RandomUser(),
+		Email:   util.RandomEmail(),
+		Phone:   util.RandomPhone(),
+		Address: util.RandomAddress(),
+	}
+
+	admin, err := testQueries.CreateAdmin(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, admin)
+
+	require.Equal(t, arg.Name, admin.Name)
+	require.Equal(t, arg.Email, admin.Email)
+	require.NotZero(t, admin.AdminID)
+
+	return admin
+}
+
+// createRandomStoreOrder creates an order with its own order id, unlike createRandomOrder
+func createRandomStoreOrder(t *testing.T, store *Store) Order {
+	user := createRandomUser(t)
+	service := createRandomService(t)
+
+	result, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{
+		OrderID:     util.NewOrderID(),
+		UserID:      user.UserID,
+		BranchID:    defaultBranch(t).BranchID,
+		ServiceIDs:  []int32{service.ServiceID},
+		OrderStatus: util.RandomOrderStatus(),
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Orders, 1)
+
+	return result.Orders[0]
+}
+
+func TestAssignOrderTx(t *testing.T) {
+	store := NewStore(testDB)
+	admin1 := createRandomAdmin(t)
+	admin2 := createRandomAdmin(t)
+	order := createRandomStoreOrder(t, store)
+
+	result, err := store.AssignOrderTx(context.Background(), AssignOrderTxParams{
+		OrderID: order.OrderID,
+		AdminID: sql.NullInt32{Int32: admin1.AdminID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, admin1.AdminID, result.Orders[0].AssignedTo.Int32)
+	require.True(t, result.Orders[0].AssignedAt.Valid)
+	require.Equal(t, util.OrderEventAssigned, result.History.Event)
+
+	_, err = store.AssignOrderTx(context.Background(), AssignOrderTxParams{
+		OrderID: order.OrderID,
+		AdminID: sql.NullInt32{Int32: admin2.AdminID, Valid: true},
+	})
+	require.ErrorIs(t, err, ErrOrderAlreadyAssigned)
+
+	result, err = store.AssignOrderTx(context.Background(), AssignOrderTxParams{
+		OrderID:   order.OrderID,
+		AdminID:   sql.NullInt32{Int32: admin2.AdminID, Valid: true},
+		Reassign:  true,
+		ChangedBy: sql.NullInt32{Int32: admin1.AdminID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, admin2.AdminID, result.Orders[0].AssignedTo.Int32)
+	require.Equal(t, admin1.AdminID, result.Orders[0].ModifiedBy.Int32)
+	require.Equal(t, util.OrderEventReassigned, result.History.Event)
+
+	result, err = store.AssignOrderTx(context.Background(), AssignOrderTxParams{
+		OrderID:  order.OrderID,
+		Reassign: true,
+	})
+	require.NoError(t, err)
+	require.False(t, result.Orders[0].AssignedTo.Valid)
+	require.Equal(t, util.OrderEventUnassigned, result.History.Event)
+
+	history, err := testQueries.ListOrderHistory(context.Background(), order.OrderID)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+}
+
+func TestAssignOrderTxLeastLoaded(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+
+	result, err := store.AssignOrderTx(context.Background(), AssignOrderTxParams{
+		OrderID:  order.OrderID,
+		Strategy: util.AssignLeastLoaded,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Orders[0].AssignedTo.Valid)
+}
+
+func TestAssignOrderTxArchivedAdmin(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+	admin := createRandomAdmin(t)
+
+	_, err := store.ArchiveAdminTx(context.Background(), admin.AdminID)
+	require.NoError(t, err)
+
+	_, err = store.AssignOrderTx(context.Background(), AssignOrderTxParams{
+		OrderID: order.OrderID,
+		AdminID: sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	require.ErrorIs(t, err, ErrStaffNotFound)
+}
+
+func TestGetActiveAdminArchived(t *testing.T) {
+	admin := createRandomAdmin(t)
+
+	found, err := testQueries.GetActiveAdmin(context.Background(), admin.AdminID)
+	require.NoError(t, err)
+	require.Equal(t, admin.AdminID, found.AdminID)
+
+	_, err = testQueries.ArchiveAdmin(context.Background(), admin.AdminID)
+	require.NoError(t, err)
+
+	// Archived staff can neither log in nor keep using the tokens they hold
+	_, err = testQueries.GetActiveAdmin(context.Background(), admin.AdminID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.GetAdminByEmail(context.Background(), admin.Email)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
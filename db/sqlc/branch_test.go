@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomBranch(t *testing.T) Branch {
+	arg := CreateBranchParams{
+		Name:    util.RandomUser(),
+		Address: util.RandomAddress(),
+		Phone:   util.RandomPhone(),
+	}
+
+	branch, err := testQueries.CreateBranch(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, branch.BranchID)
+	require.Equal(t, arg.Name, branch.Name)
+	require.True(t, branch.IsActive)
+	require.False(t, branch.IsDefault)
+
+	return branch
+}
+
+// defaultBranch returns the branch the migration assigns existing data to
+func defaultBranch(t *testing.T) Branch {
+	branch, err := testQueries.GetDefaultBranch(context.Background())
+	require.NoError(t, err)
+	require.True(t, branch.IsDefault)
+	return branch
+}
+
+func TestUpdateBranch(t *testing.T) {
+	branch := createRandomBranch(t)
+
+	updated, err := testQueries.UpdateBranch(context.Background(), UpdateBranchParams{
+		BranchID: branch.BranchID,
+		Name:     branch.Name,
+		Address:  util.RandomAddress(),
+		Phone:    branch.Phone,
+		IsActive: false,
+	})
+	require.NoError(t, err)
+	require.False(t, updated.IsActive)
+	require.Equal(t, branch.Name, updated.Name)
+}
+
+func TestCreateOrderTxBranch(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	service := createRandomService(t)
+	branch := createRandomBranch(t)
+
+	override, err := testQueries.UpsertServiceBranch(context.Background(), UpsertServiceBranchParams{
+		ServiceID:   service.ServiceID,
+		BranchID:    branch.BranchID,
+		Price:       sql.NullInt64{Int64: service.ServicePrice + 50, Valid: true},
+		IsAvailable: true,
+	})
+	require.NoError(t, err)
+
+	arg := CreateOrderTxParams{
+		OrderID:     util.NewOrderID(),
+		UserID:      user.UserID,
+		BranchID:    branch.BranchID,
+		ServiceIDs:  []int32{service.ServiceID},
+		OrderStatus: util.OrderStatusStarted,
+	}
+	result, err := store.CreateOrderTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, result.Orders, 1)
+	require.Equal(t, branch.BranchID, result.Orders[0].BranchID)
+	require.Equal(t, override.Price.Int64, result.Orders[0].UnitPrice.Int64)
+
+	// Other branches keep the price of the service
+	other := arg
+	other.OrderID = util.NewOrderID()
+	other.BranchID = defaultBranch(t).BranchID
+	result, err = store.CreateOrderTx(context.Background(), other)
+	require.NoError(t, err)
+	require.Equal(t, service.ServicePrice, result.Orders[0].UnitPrice.Int64)
+
+	_, err = testQueries.UpsertServiceBranch(context.Background(), UpsertServiceBranchParams{
+		ServiceID:   service.ServiceID,
+		BranchID:    branch.BranchID,
+		IsAvailable: false,
+	})
+	require.NoError(t, err)
+
+	arg.OrderID = util.NewOrderID()
+	_, err = store.CreateOrderTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrServiceUnavailable)
+
+	closed := createRandomBranch(t)
+	_, err = testQueries.UpdateBranch(context.Background(), UpdateBranchParams{
+		BranchID: closed.BranchID,
+		Name:     closed.Name,
+		IsActive: false,
+	})
+	require.NoError(t, err)
+
+	arg.OrderID = util.NewOrderID()
+	arg.BranchID = closed.BranchID
+	_, err = store.CreateOrderTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrBranchInactive)
+}
+
+func TestListOrdersByBranch(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	service := createRandomService(t)
+	branch := createRandomBranch(t)
+
+	result, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{
+		OrderID:     util.NewOrderID(),
+		UserID:      user.UserID,
+		BranchID:    branch.BranchID,
+		ServiceIDs:  []int32{service.ServiceID},
+		OrderStatus: util.OrderStatusStarted,
+	})
+	require.NoError(t, err)
+
+	orders, err := testQueries.ListOrders(context.Background(), ListOrdersParams{
+		BranchIds: []int32{branch.BranchID},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, result.Orders[0].OrderID, orders[0].OrderID)
+
+	orders, err = testQueries.ListOrders(context.Background(), ListOrdersParams{
+		BranchIds: []int32{createRandomBranch(t).BranchID},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, orders)
+}
+
+func TestSetAdminBranchesTx(t *testing.T) {
+	store := NewStore(testDB)
+	admin := createRandomAdmin(t)
+	branch := createRandomBranch(t)
+	order := createRandomStoreOrder(t, store)
+
+	branchIDs, err := store.SetAdminBranchesTx(context.Background(), admin.AdminID, []int32{branch.BranchID})
+	require.NoError(t, err)
+	require.Equal(t, []int32{branch.BranchID}, branchIDs)
+
+	// The order is at the default branch, which the admin does not work at
+	_, err = store.AssignOrderTx(context.Background(), AssignOrderTxParams{
+		OrderID: order.OrderID,
+		AdminID: sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	require.ErrorIs(t, err, ErrStaffNotInBranch)
+
+	admins, err := testQueries.ListAdmins(context.Background(), ListAdminsParams{
+		BranchID: sql.NullInt32{Int32: order.BranchID, Valid: true},
+		Limit:    1000,
+	})
+	require.NoError(t, err)
+	for _, listed := range admins {
+		require.NotEqual(t, admin.AdminID, listed.AdminID)
+	}
+
+	branchIDs, err = store.SetAdminBranchesTx(context.Background(), admin.AdminID, nil)
+	require.NoError(t, err)
+	require.Empty(t, branchIDs)
+
+	_, err = store.AssignOrderTx(context.Background(), AssignOrderTxParams{
+		OrderID: order.OrderID,
+		AdminID: sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	require.NoError(t, err)
+}
+
+func TestListUsersAfterByBranch(t *testing.T) {
+	branch := createRandomBranch(t)
+	user, err := testQueries.CreateUser(context.Background(), CreateUserParams{
+		Name:     util.RandomUser(),
+		Email:    util.RandomEmail(),
+		Phone:    util.RandomPhone(),
+		Address:  util.RandomAddress(),
+		BranchID: sql.NullInt32{Int32: branch.BranchID, Valid: true},
+	})
+	require.NoError(t, err)
+	createRandomUser(t)
+
+	// Exports of a branch only see the customers of the branch
+	count, err := testQueries.CountUsers(context.Background(), []int32{branch.BranchID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	users, err := testQueries.ListUsersAfter(context.Background(), ListUsersAfterParams{
+		BranchIds: []int32{branch.BranchID},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, user.UserID, users[0].UserID)
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createStoreOrderInStatus(t *testing.T, store *Store, status string) Order {
+	order := createRandomStoreOrder(t, store)
+	orders, err := testQueries.SetOrderStatus(context.Background(), SetOrderStatusParams{
+		OrderID:     order.OrderID,
+		OrderStatus: status,
+	})
+	require.NoError(t, err)
+	return orders[0]
+}
+
+func TestBulkUpdateOrdersTx(t *testing.T) {
+	store := NewStore(testDB)
+	order1 := createStoreOrderInStatus(t, store, util.OrderStatusStarted)
+	order2 := createStoreOrderInStatus(t, store, util.OrderStatusPending)
+	done := createStoreOrderInStatus(t, store, util.OrderStatusDone)
+
+	// A done order cannot be cancelled, so the whole batch is rejected
+	arg := BulkUpdateOrdersTxParams{
+		OrderIDs: []int64{order1.OrderID, order2.OrderID, done.OrderID},
+		Action:   BulkActionCancel,
+	}
+	result, err := store.BulkUpdateOrdersTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrBatchRejected)
+	require.Len(t, result.Results, 3)
+	// The orders that could be cancelled are reported as not applied
+	for _, res := range result.Results {
+		require.False(t, res.Success)
+		require.Empty(t, res.Orders)
+		if res.OrderID != done.OrderID {
+			require.Equal(t, ErrOrderNotApplied.Error(), res.Error)
+		} else {
+			require.NotEqual(t, ErrOrderNotApplied.Error(), res.Error)
+		}
+	}
+
+	orders, err := testQueries.GetOrder(context.Background(), order1.OrderID)
+	require.NoError(t, err)
+	require.Equal(t, util.OrderStatusStarted, orders[0].OrderStatus)
+
+	// Repeated ids are only applied once
+	arg.OrderIDs = []int64{order2.OrderID, order1.OrderID, order1.OrderID}
+	result, err = store.BulkUpdateOrdersTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, result.Results, 2)
+	for _, res := range result.Results {
+		require.True(t, res.Success)
+		require.Equal(t, util.OrderStatusCancelled, res.Orders[0].OrderStatus)
+	}
+
+	history, err := testQueries.ListOrderHistory(context.Background(), order1.OrderID)
+	require.NoError(t, err)
+	require.Equal(t, util.OrderEventCancelled, history[len(history)-1].Event)
+}
+
+func TestBulkUpdateOrdersTxStatusAction(t *testing.T) {
+	store := NewStore(testDB)
+	order := createStoreOrderInStatus(t, store, util.OrderStatusStarted)
+
+	// The status action cannot skip what delivering or cancelling an order does
+	for _, status := range []string{util.OrderStatusDelivered, util.OrderStatusCancelled} {
+		_, err := store.BulkUpdateOrdersTx(context.Background(), BulkUpdateOrdersTxParams{
+			OrderIDs: []int64{order.OrderID},
+			Action:   BulkActionStatus,
+			Status:   status,
+		})
+		require.ErrorIs(t, err, ErrBulkStatusAction)
+	}
+
+	orders, err := testQueries.GetOrder(context.Background(), order.OrderID)
+	require.NoError(t, err)
+	require.Equal(t, util.OrderStatusStarted, orders[0].OrderStatus)
+}
+
+func TestBulkUpdateOrdersTxBranchScope(t *testing.T) {
+	store := NewStore(testDB)
+	order := createStoreOrderInStatus(t, store, util.OrderStatusStarted)
+	branch := createRandomBranch(t)
+
+	// Orders of other branches fail on their own instead of failing the request
+	arg := BulkUpdateOrdersTxParams{
+		OrderIDs:  []int64{order.OrderID},
+		Action:    BulkActionStatus,
+		Status:    util.OrderStatusAccepted,
+		BranchIDs: []int32{branch.BranchID},
+	}
+	result, err := store.BulkUpdateOrdersTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrBatchRejected)
+	require.Len(t, result.Results, 1)
+	require.False(t, result.Results[0].Success)
+	require.Equal(t, ErrOrderNotInScope.Error(), result.Results[0].Error)
+
+	arg.BranchIDs = []int32{branch.BranchID, order.BranchID}
+	result, err = store.BulkUpdateOrdersTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.Results[0].Success)
+
+	// Staff of another branch cannot be assigned, which is reported for the order as well
+	admin := createRandomAdmin(t)
+	_, err = store.SetAdminBranchesTx(context.Background(), admin.AdminID, []int32{branch.BranchID})
+	require.NoError(t, err)
+	result, err = store.BulkUpdateOrdersTx(context.Background(), BulkUpdateOrdersTxParams{
+		OrderIDs: []int64{order.OrderID},
+		Action:   BulkActionAssign,
+		AdminID:  sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	require.ErrorIs(t, err, ErrBatchRejected)
+	require.Equal(t, ErrStaffNotInBranch.Error(), result.Results[0].Error)
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrderTxWithBundle(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	service1 := createRandomService(t)
+	service2 := createRandomService(t)
+
+	// A service can only be listed once, more of it are ordered through its quantity
+	arg := BundleTxParams{
+		Name:     util.RandomString(10),
+		Price:    100,
+		IsActive: true,
+		Items: []BundleItem{
+			{ServiceID: service1.ServiceID, Quantity: 1},
+			{ServiceID: service2.ServiceID, Quantity: 1},
+			{ServiceID: service1.ServiceID, Quantity: 1},
+		},
+	}
+	_, err := store.CreateBundleTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrDuplicateBundleItem)
+
+	arg.Items = []BundleItem{
+		{ServiceID: service1.ServiceID, Quantity: 2},
+		{ServiceID: service2.ServiceID, Quantity: 1},
+	}
+	bundle, err := store.CreateBundleTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, bundle.Items, 2)
+	require.Equal(t, int32(2), bundle.Items[0].Quantity)
+
+	result, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{
+		OrderID:     util.NewOrderID(),
+		UserID:      user.UserID,
+		BranchID:    defaultBranch(t).BranchID,
+		BundleIDs:   []int32{bundle.Bundle.BundleID},
+		OrderStatus: util.OrderStatusPending,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Orders, 3)
+
+	// The bundle price is spread over its lines
+	var total int64
+	for _, order := range result.Orders {
+		require.Equal(t, bundle.Bundle.BundleID, order.BundleID.Int32)
+		total += order.UnitPrice.Int64
+	}
+	require.Equal(t, bundle.Bundle.Price, total)
+
+	subtotal, err := testQueries.GetOrderSubtotal(context.Background(), result.Orders[0].OrderID)
+	require.NoError(t, err)
+	require.Equal(t, bundle.Bundle.Price, subtotal)
+
+	// Inactive bundles cannot be ordered
+	_, err = store.UpdateBundleTx(context.Background(), BundleTxParams{
+		BundleID: bundle.Bundle.BundleID,
+		Name:     bundle.Bundle.Name,
+		Price:    bundle.Bundle.Price,
+		Items:    bundle.Items,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateOrderTx(context.Background(), CreateOrderTxParams{
+		OrderID:     util.NewOrderID(),
+		UserID:      user.UserID,
+		BranchID:    defaultBranch(t).BranchID,
+		BundleIDs:   []int32{bundle.Bundle.BundleID},
+		OrderStatus: util.OrderStatusPending,
+	})
+	require.ErrorIs(t, err, ErrBundleInactive)
+}
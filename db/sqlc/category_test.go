@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomCategory(t *testing.T, parentID sql.NullInt32) Category {
+	arg := CreateCategoryParams{
+		ParentID:     parentID,
+		Name:         util.RandomString(8),
+		Icon:         "https://example.com/icons/" + util.RandomString(6) + ".png",
+		DisplayOrder: int32(util.RandomOrder() % 10),
+		IsActive:     true,
+	}
+
+	category, err := testQueries.CreateCategory(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, category.CategoryID)
+	require.Equal(t, arg.ParentID, category.ParentID)
+	require.Equal(t, arg.Name, category.Name)
+	require.Equal(t, arg.DisplayOrder, category.DisplayOrder)
+	require.True(t, category.IsActive)
+
+	return category
+}
+
+func TestCategoryTree(t *testing.T) {
+	parent := createRandomCategory(t, sql.NullInt32{})
+	child := createRandomCategory(t, sql.NullInt32{Int32: parent.CategoryID, Valid: true})
+
+	// The same name can be used under another parent but not twice under the same one
+	_, err := testQueries.CreateCategory(context.Background(), CreateCategoryParams{
+		ParentID: child.ParentID,
+		Name:     child.Name,
+		IsActive: true,
+	})
+	require.Error(t, err)
+
+	service := createRandomService(t)
+	service, err = testQueries.SetServiceCategory(context.Background(), SetServiceCategoryParams{
+		ServiceID:  service.ServiceID,
+		CategoryID: sql.NullInt32{Int32: child.CategoryID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, child.CategoryID, service.CategoryID.Int32)
+
+	// A category with subcategories cannot be deleted
+	require.Error(t, testQueries.DeleteCategory(context.Background(), parent.CategoryID))
+
+	// Deleting a category leaves its services uncategorized
+	require.NoError(t, testQueries.DeleteCategory(context.Background(), child.CategoryID))
+	service, err = testQueries.GetService(context.Background(), service.ServiceID)
+	require.NoError(t, err)
+	require.False(t, service.CategoryID.Valid)
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+var testTaxRates = []util.TaxRate{
+	{Name: "CGST", BasisPoints: 900},
+	{Name: "SGST", BasisPoints: 900},
+}
+
+// invoiceSequence returns the counter part of an invoice number such as INV-2024-000042
+func invoiceSequence(t *testing.T, invoiceNumber string) int64 {
+	i := strings.LastIndex(invoiceNumber, "-")
+	require.Greater(t, i, 0)
+	number, err := strconv.ParseInt(invoiceNumber[i+1:], 10, 64)
+	require.NoError(t, err)
+	return number
+}
+
+func TestIssueInvoiceTx(t *testing.T) {
+	store := NewStore(testDB)
+	order := createStoreOrderInStatus(t, store, util.OrderStatusDelivered)
+
+	result, err := store.IssueInvoiceTx(context.Background(), IssueInvoiceTxParams{
+		OrderID:  order.OrderID,
+		TaxRates: testTaxRates,
+	})
+	require.NoError(t, err)
+	require.Equal(t, order.OrderID, result.Invoice.OrderID)
+	require.Len(t, result.Items, 1)
+	require.Len(t, result.Taxes, len(testTaxRates))
+	require.Equal(t, result.Items[0].Amount, result.Invoice.Subtotal)
+	require.Equal(t, result.Invoice.Subtotal+result.Invoice.TaxTotal, result.Invoice.Total)
+
+	// Issuing again returns the same invoice instead of taking a new number
+	again, err := store.IssueInvoiceTx(context.Background(), IssueInvoiceTxParams{
+		OrderID:  order.OrderID,
+		TaxRates: testTaxRates,
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.Invoice, again.Invoice)
+	require.Equal(t, result.Items, again.Items)
+	require.Equal(t, result.Taxes, again.Taxes)
+}
+
+func TestIssueInvoiceTxGapless(t *testing.T) {
+	store := NewStore(testDB)
+
+	n := 5
+	orders := make([]Order, n)
+	for i := range orders {
+		orders[i] = createStoreOrderInStatus(t, store, util.OrderStatusDelivered)
+	}
+
+	errs := make(chan error)
+	results := make(chan IssueInvoiceTxResult)
+	for _, order := range orders {
+		orderID := order.OrderID
+		go func() {
+			result, err := store.IssueInvoiceTx(context.Background(), IssueInvoiceTxParams{
+				OrderID:  orderID,
+				TaxRates: testTaxRates,
+			})
+			errs <- err
+			results <- result
+		}()
+	}
+
+	numbers := make([]int64, n)
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+		result := <-results
+		numbers[i] = invoiceSequence(t, result.Invoice.InvoiceNumber)
+	}
+
+	// Concurrent invoices take consecutive numbers with no gaps or duplicates
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	for i := 1; i < n; i++ {
+		require.Equal(t, numbers[i-1]+1, numbers[i])
+	}
+}
+
+func TestInvoiceImmutable(t *testing.T) {
+	store := NewStore(testDB)
+	order := createStoreOrderInStatus(t, store, util.OrderStatusDelivered)
+
+	result, err := store.IssueInvoiceTx(context.Background(), IssueInvoiceTxParams{
+		OrderID:  order.OrderID,
+		TaxRates: testTaxRates,
+	})
+	require.NoError(t, err)
+	invoiceID := result.Invoice.InvoiceID
+
+	_, err = testDB.ExecContext(context.Background(), "UPDATE invoices SET total = total + 1 WHERE invoice_id = $1", invoiceID)
+	require.Error(t, err)
+	_, err = testDB.ExecContext(context.Background(), "UPDATE invoice_items SET amount = 0 WHERE invoice_id = $1", invoiceID)
+	require.Error(t, err)
+	_, err = testDB.ExecContext(context.Background(), "DELETE FROM invoice_taxes WHERE invoice_id = $1", invoiceID)
+	require.Error(t, err)
+	_, err = testDB.ExecContext(context.Background(), "DELETE FROM invoices WHERE invoice_id = $1", invoiceID)
+	require.Error(t, err)
+
+	// The PDF can be attached once, but not replaced afterwards
+	inv, err := testQueries.SetInvoicePDF(context.Background(), SetInvoicePDFParams{
+		InvoiceID: invoiceID,
+		PdfKey:    "private/invoices/" + util.RandomString(12) + ".pdf",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, inv.PdfKey)
+
+	_, err = testQueries.SetInvoicePDF(context.Background(), SetInvoicePDFParams{
+		InvoiceID: invoiceID,
+		PdfKey:    "private/invoices/" + util.RandomString(12) + ".pdf",
+	})
+	require.Error(t, err)
+
+	stored, err := testQueries.GetInvoiceByOrderID(context.Background(), order.OrderID)
+	require.NoError(t, err)
+	require.Equal(t, inv, stored)
+}
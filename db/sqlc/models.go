@@ -5,39 +5,277 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Admin struct {
-	AdminID           int32     `json:"admin_id"`
-	Name              string    `json:"name"`
-	Email             string    `json:"email"`
-	Phone             string    `json:"phone"`
-	Address           string    `json:"address"`
-	HashedPassword    string    `json:"hashed_password"`
-	CreatedAt         time.Time `json:"created_at"`
-	PasswordChangedAt time.Time `json:"password_changed_at"`
+	AdminID           int32        `json:"admin_id"`
+	Name              string       `json:"name"`
+	Email             string       `json:"email"`
+	Phone             string       `json:"phone"`
+	Address           string       `json:"address"`
+	HashedPassword    string       `json:"hashed_password"`
+	CreatedAt         time.Time    `json:"created_at"`
+	PasswordChangedAt time.Time    `json:"password_changed_at"`
+	ArchivedAt        sql.NullTime `json:"archived_at"`
+	ProfileImage      string       `json:"profile_image"`
+}
+
+type AdminBranch struct {
+	AdminID  int32 `json:"admin_id"`
+	BranchID int32 `json:"branch_id"`
+}
+
+type Branch struct {
+	BranchID int32  `json:"branch_id"`
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Phone    string `json:"phone"`
+	// orders placed without a branch go to the default branch
+	IsDefault bool      `json:"is_default"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Bundle struct {
+	BundleID    int32  `json:"bundle_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// price of the whole bundle, spread over its services when ordered
+	Price     int64     `json:"price"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BundleItem struct {
+	BundleID  int32 `json:"bundle_id"`
+	ServiceID int32 `json:"service_id"`
+	Quantity  int32 `json:"quantity"`
+}
+
+type Category struct {
+	CategoryID int32 `json:"category_id"`
+	// null for top level categories
+	ParentID sql.NullInt32 `json:"parent_id"`
+	Name     string        `json:"name"`
+	Icon     string        `json:"icon"`
+	// categories with the same parent are shown in ascending order
+	DisplayOrder int32     `json:"display_order"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ExportJob struct {
+	JobID   int64           `json:"job_id"`
+	Kind    string          `json:"kind"`
+	Format  string          `json:"format"`
+	Filters json.RawMessage `json:"filters"`
+	Status  string          `json:"status"`
+	// rows matching the filters when the export was requested
+	RowCount int64 `json:"row_count"`
+	// storage key of the finished export, empty until it is done
+	FileKey     string       `json:"-"`
+	Error       string       `json:"error"`
+	RequestedBy int32        `json:"requested_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	FinishedAt  sql.NullTime `json:"finished_at"`
+}
+
+type IdempotencyKey struct {
+	ID             int64  `json:"id"`
+	IdempotencyKey string `json:"idempotency_key"`
+	// caller, method and path the key was used for
+	Scope       string `json:"scope"`
+	RequestHash string `json:"request_hash"`
+	// null while the original request is still being processed
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	ResponseBody   []byte        `json:"response_body"`
+	CreatedAt      time.Time     `json:"created_at"`
+	// content type of the stored response, empty for responses stored before it was recorded
+	ContentType string `json:"content_type"`
+}
+
+type Invoice struct {
+	InvoiceID       int64  `json:"invoice_id"`
+	InvoiceNumber   string `json:"invoice_number"`
+	OrderID         int64  `json:"order_id"`
+	UserID          int32  `json:"user_id"`
+	CustomerName    string `json:"customer_name"`
+	CustomerEmail   string `json:"customer_email"`
+	CustomerPhone   string `json:"customer_phone"`
+	CustomerAddress string `json:"customer_address"`
+	Subtotal        int64  `json:"subtotal"`
+	TaxTotal        int64  `json:"tax_total"`
+	Total           int64  `json:"total"`
+	// storage key of the rendered PDF, empty until it is rendered
+	PdfKey   string    `json:"-"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+type InvoiceCounter struct {
+	Series     string `json:"series"`
+	LastNumber int64  `json:"last_number"`
+}
+
+type InvoiceItem struct {
+	ID          int64  `json:"id"`
+	InvoiceID   int64  `json:"invoice_id"`
+	ServiceID   int32  `json:"service_id"`
+	Description string `json:"description"`
+	Quantity    int32  `json:"quantity"`
+	UnitPrice   int64  `json:"unit_price"`
+	Amount      int64  `json:"amount"`
+}
+
+type InvoiceTax struct {
+	ID        int64  `json:"id"`
+	InvoiceID int64  `json:"invoice_id"`
+	TaxName   string `json:"tax_name"`
+	// tax rate in basis points, 900 = 9%
+	RateBps       int32 `json:"rate_bps"`
+	TaxableAmount int64 `json:"taxable_amount"`
+	TaxAmount     int64 `json:"tax_amount"`
 }
 
 type Order struct {
-	ID                int32     `json:"id"`
-	OrderID           int64     `json:"order_id"`
-	UserID            int32     `json:"user_id"`
-	ServiceIds        int32     `json:"service_ids"`
-	OrderStatus       string    `json:"order_status"`
-	OrderStarted      time.Time `json:"order_started"`
-	OrderDelivered    bool      `json:"order_delivered"`
-	OrderDeliveryTime time.Time `json:"order_delivery_time"`
-	ModifiedBy        int32     `json:"modified_by"`
+	ID                int32         `json:"id"`
+	OrderID           int64         `json:"order_id"`
+	UserID            int32         `json:"user_id"`
+	ServiceIds        int32         `json:"service_ids"`
+	OrderStatus       string        `json:"order_status"`
+	OrderStarted      time.Time     `json:"order_started"`
+	OrderDelivered    bool          `json:"order_delivered"`
+	OrderDeliveryTime time.Time     `json:"order_delivery_time"`
+	ModifiedBy        sql.NullInt32 `json:"modified_by"`
+	PickupSlotID      sql.NullInt32 `json:"pickup_slot_id"`
+	DeliverySlotID    sql.NullInt32 `json:"delivery_slot_id"`
+	AssignedTo        sql.NullInt32 `json:"assigned_to"`
+	AssignedAt        sql.NullTime  `json:"assigned_at"`
+	// orders with a higher priority are worked on first
+	Priority int32 `json:"priority"`
+	// order_started plus the turnaround of the slowest service in the order
+	DueAt     sql.NullTime  `json:"due_at"`
+	SlaState  string        `json:"sla_state"`
+	VariantID sql.NullInt32 `json:"variant_id"`
+	// price of the service or variant when the order was placed, null for orders placed before prices were recorded
+	UnitPrice sql.NullInt64 `json:"unit_price"`
+	// set on the rows of an order that were ordered as part of a bundle
+	BundleID sql.NullInt32 `json:"bundle_id"`
+	BranchID int32         `json:"branch_id"`
+}
+
+type OrderAttachment struct {
+	AttachmentID int64         `json:"attachment_id"`
+	OrderID      int64         `json:"order_id"`
+	NoteID       sql.NullInt64 `json:"note_id"`
+	FileUrl      string        `json:"file_url"`
+	FileName     string        `json:"file_name"`
+	ContentType  string        `json:"content_type"`
+	SizeBytes    int64         `json:"size_bytes"`
+	Visibility   string        `json:"visibility"`
+	AdminID      sql.NullInt32 `json:"admin_id"`
+	UserID       sql.NullInt32 `json:"user_id"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type OrderHistory struct {
+	ID      int64           `json:"id"`
+	OrderID int64           `json:"order_id"`
+	Event   string          `json:"event"`
+	Details json.RawMessage `json:"details"`
+	// admin who made the change, null for changes made by the customer or the system
+	ChangedBy sql.NullInt32 `json:"changed_by"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type OrderNote struct {
+	NoteID  int64  `json:"note_id"`
+	OrderID int64  `json:"order_id"`
+	Body    string `json:"body"`
+	// internal notes are only shown to staff
+	Visibility string `json:"visibility"`
+	// set when the note was written by staff, user_id is set otherwise
+	AdminID   sql.NullInt32 `json:"admin_id"`
+	UserID    sql.NullInt32 `json:"user_id"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type OrderRating struct {
+	RatingID int64 `json:"rating_id"`
+	OrderID  int64 `json:"order_id"`
+	UserID   int32 `json:"user_id"`
+	// null for the rating of the order as a whole
+	ServiceID sql.NullInt32 `json:"service_id"`
+	Rating    int32         `json:"rating"`
+	Comment   string        `json:"comment"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type Payment struct {
+	PaymentID int64  `json:"payment_id"`
+	OrderID   int64  `json:"order_id"`
+	Method    string `json:"method"`
+	// positive for payments, negative for refunds
+	Amount int64 `json:"amount"`
+	// for gateway payments this is the id of the payment intent at the provider
+	Reference        string        `json:"reference"`
+	Status           string        `json:"status"`
+	RefundOf         sql.NullInt64 `json:"refund_of"`
+	RecordedBy       sql.NullInt32 `json:"recorded_by"`
+	CreatedAt        time.Time     `json:"created_at"`
+	Provider         string        `json:"provider"`
+	GatewayPaymentID string        `json:"gateway_payment_id"`
 }
 
 type Service struct {
-	ServiceID    int32  `json:"service_id"`
-	ServiceName  string `json:"service_name"`
-	ServicePrice int64  `json:"service_price"`
-	ServiceImage string `json:"service_image"`
+	ServiceID       int32         `json:"service_id"`
+	ServiceName     string        `json:"service_name"`
+	ServicePrice    int64         `json:"service_price"`
+	ServiceImage    string        `json:"service_image"`
+	TurnaroundHours int32         `json:"turnaround_hours"`
+	CategoryID      sql.NullInt32 `json:"category_id"`
+	// archived rows are kept for past orders but left out of listings
+	ArchivedAt sql.NullTime `json:"archived_at"`
+	// variants of service_image, empty until the image worker has made them
+	ImageThumbnail string `json:"image_thumbnail"`
+	ImageMedium    string `json:"image_medium"`
+	// lossless, unlike the JPEG variants it keeps transparency
+	ImageWebp string `json:"image_webp"`
+	// incremented on every change made by an admin
+	Version int32 `json:"version"`
+}
+
+type ServiceBranch struct {
+	ServiceID int32 `json:"service_id"`
+	BranchID  int32 `json:"branch_id"`
+	// null keeps the price of the service
+	Price       sql.NullInt64 `json:"price"`
+	IsAvailable bool          `json:"is_available"`
+}
+
+type ServicePriceHistory struct {
+	PriceID   int64 `json:"price_id"`
+	ServiceID int32 `json:"service_id"`
+	Price     int64 `json:"price"`
+	// the price applies to orders placed from this moment until the next change
+	EffectiveAt time.Time     `json:"effective_at"`
+	CreatedBy   sql.NullInt32 `json:"created_by"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+type ServiceVariant struct {
+	VariantID       int32     `json:"variant_id"`
+	ServiceID       int32     `json:"service_id"`
+	Name            string    `json:"name"`
+	Sku             string    `json:"sku"`
+	Price           int64     `json:"price"`
+	TurnaroundHours int32     `json:"turnaround_hours"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 type Session struct {
@@ -51,15 +289,79 @@ type Session struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+type Subscription struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	UserID         int32  `json:"user_id"`
+	Frequency      string `json:"frequency"`
+	// five field cron expression, only used when frequency is cron
+	CronExpr  string    `json:"cron_expr"`
+	NextRunAt time.Time `json:"next_run_at"`
+	Status    string    `json:"status"`
+	// failed attempts at the current run, reset once the run is over
+	FailureCount int32     `json:"failure_count"`
+	LastError    string    `json:"last_error"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	BranchID     int32     `json:"branch_id"`
+	// first run of the subscription, monthly runs fall on its day of the month
+	AnchorAt time.Time `json:"anchor_at"`
+}
+
+type SubscriptionRun struct {
+	RunID          int64         `json:"run_id"`
+	SubscriptionID int64         `json:"subscription_id"`
+	ScheduledFor   time.Time     `json:"scheduled_for"`
+	Status         string        `json:"status"`
+	OrderID        sql.NullInt64 `json:"order_id"`
+	Error          string        `json:"error"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+type SubscriptionService struct {
+	SubscriptionID int64 `json:"subscription_id"`
+	ServiceID      int32 `json:"service_id"`
+	Quantity       int32 `json:"quantity"`
+}
+
+type TimeSlot struct {
+	SlotID    int32     `json:"slot_id"`
+	SlotType  string    `json:"slot_type"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Capacity  int32     `json:"capacity"`
+	Booked    int32     `json:"booked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Upload struct {
+	UploadID    uuid.UUID `json:"upload_id"`
+	ObjectKey   string    `json:"-"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	// exact size the presigned URL was issued for
+	SizeBytes int64         `json:"size_bytes"`
+	Purpose   string        `json:"purpose"`
+	AdminID   sql.NullInt32 `json:"admin_id"`
+	UserID    sql.NullInt32 `json:"user_id"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	// null until the client confirms the file was uploaded
+	ConfirmedAt sql.NullTime `json:"confirmed_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
 type User struct {
 	// this will consist of unique user_id
-	UserID            int32     `json:"user_id"`
-	Name              string    `json:"name"`
-	Email             string    `json:"email"`
-	Phone             string    `json:"phone"`
-	Address           string    `json:"address"`
-	TotalOrders       int32     `json:"total_orders"`
-	HashedPassword    string    `json:"hashed_password"`
-	CreatedAt         time.Time `json:"created_at"`
-	PasswordChangedAt time.Time `json:"password_changed_at"`
+	UserID            int32        `json:"user_id"`
+	Name              string       `json:"name"`
+	Email             string       `json:"email"`
+	Phone             string       `json:"phone"`
+	Address           string       `json:"address"`
+	TotalOrders       int32        `json:"total_orders"`
+	HashedPassword    string       `json:"hashed_password"`
+	CreatedAt         time.Time    `json:"created_at"`
+	PasswordChangedAt time.Time    `json:"password_changed_at"`
+	ArchivedAt        sql.NullTime `json:"archived_at"`
+	ProfileImage      string       `json:"profile_image"`
+	// branch the customer usually orders from, null when they have not picked one
+	BranchID sql.NullInt32 `json:"branch_id"`
 }
@@ -7,14 +7,82 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+const assignOrder = `-- name: AssignOrder :many
+UPDATE orders
+SET assigned_to = $2,
+assigned_at = $3,
+modified_by = $4
+WHERE order_id = $1
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
+`
+
+type AssignOrderParams struct {
+	OrderID    int64         `json:"order_id"`
+	AssignedTo sql.NullInt32 `json:"assigned_to"`
+	AssignedAt sql.NullTime  `json:"assigned_at"`
+	ModifiedBy sql.NullInt32 `json:"modified_by"`
+}
+
+func (q *Queries) AssignOrder(ctx context.Context, arg AssignOrderParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, assignOrder,
+		arg.OrderID,
+		arg.AssignedTo,
+		arg.AssignedAt,
+		arg.ModifiedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.UserID,
+			&i.ServiceIds,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const cancelOrder = `-- name: CancelOrder :one
 UPDATE orders 
 SET order_status = $2
 WHERE order_id = $1
-RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
 `
 
 type CancelOrderParams struct {
@@ -35,26 +103,63 @@ func (q *Queries) CancelOrder(ctx context.Context, arg CancelOrderParams) (Order
 		&i.OrderDelivered,
 		&i.OrderDeliveryTime,
 		&i.ModifiedBy,
+		&i.PickupSlotID,
+		&i.DeliverySlotID,
+		&i.AssignedTo,
+		&i.AssignedAt,
+		&i.Priority,
+		&i.DueAt,
+		&i.SlaState,
+		&i.VariantID,
+		&i.UnitPrice,
+		&i.BundleID,
+		&i.BranchID,
 	)
 	return i, err
 }
 
+const countOrderExportRows = `-- name: CountOrderExportRows :one
+SELECT count(*) FROM orders
+WHERE ($1::varchar IS NULL OR sla_state = $1)
+AND (COALESCE(cardinality($2::int[]), 0) = 0 OR branch_id = ANY($2::int[]))
+`
+
+type CountOrderExportRowsParams struct {
+	SlaState  sql.NullString `json:"sla_state"`
+	BranchIds []int32        `json:"branch_ids"`
+}
+
+func (q *Queries) CountOrderExportRows(ctx context.Context, arg CountOrderExportRowsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOrderExportRows, arg.SlaState, pq.Array(arg.BranchIds))
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createOrder = `-- name: CreateOrder :one
 INSERT INTO orders (
   order_id,
   user_id,
   service_ids,
-  order_status 
+  order_status,
+  variant_id,
+  unit_price,
+  bundle_id,
+  branch_id
 ) VALUES (
-  $1, $2, $3, $4
-) RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
 `
 
 type CreateOrderParams struct {
-	OrderID     int64  `json:"order_id"`
-	UserID      int32  `json:"user_id"`
-	ServiceIds  int32  `json:"service_ids"`
-	OrderStatus string `json:"order_status"`
+	OrderID     int64         `json:"order_id"`
+	UserID      int32         `json:"user_id"`
+	ServiceIds  int32         `json:"service_ids"`
+	OrderStatus string        `json:"order_status"`
+	VariantID   sql.NullInt32 `json:"variant_id"`
+	UnitPrice   sql.NullInt64 `json:"unit_price"`
+	BundleID    sql.NullInt32 `json:"bundle_id"`
+	BranchID    int32         `json:"branch_id"`
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
@@ -63,6 +168,10 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		arg.UserID,
 		arg.ServiceIds,
 		arg.OrderStatus,
+		arg.VariantID,
+		arg.UnitPrice,
+		arg.BundleID,
+		arg.BranchID,
 	)
 	var i Order
 	err := row.Scan(
@@ -75,6 +184,17 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.OrderDelivered,
 		&i.OrderDeliveryTime,
 		&i.ModifiedBy,
+		&i.PickupSlotID,
+		&i.DeliverySlotID,
+		&i.AssignedTo,
+		&i.AssignedAt,
+		&i.Priority,
+		&i.DueAt,
+		&i.SlaState,
+		&i.VariantID,
+		&i.UnitPrice,
+		&i.BundleID,
+		&i.BranchID,
 	)
 	return i, err
 }
@@ -88,8 +208,122 @@ func (q *Queries) DeleteOrder(ctx context.Context, orderID int64) error {
 	return err
 }
 
+const flagAtRiskOrders = `-- name: FlagAtRiskOrders :many
+UPDATE orders
+SET sla_state = 'at_risk'
+WHERE sla_state = 'on_track'
+AND order_delivered = false
+AND order_status <> 'Cancelled'
+AND due_at > $1::timestamptz
+AND due_at <= $2::timestamptz
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
+`
+
+type FlagAtRiskOrdersParams struct {
+	Now          time.Time `json:"now"`
+	AtRiskBefore time.Time `json:"at_risk_before"`
+}
+
+func (q *Queries) FlagAtRiskOrders(ctx context.Context, arg FlagAtRiskOrdersParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, flagAtRiskOrders, arg.Now, arg.AtRiskBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.UserID,
+			&i.ServiceIds,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const flagOverdueOrders = `-- name: FlagOverdueOrders :many
+UPDATE orders
+SET sla_state = 'overdue'
+WHERE sla_state <> 'overdue'
+AND order_delivered = false
+AND order_status <> 'Cancelled'
+AND due_at <= $1::timestamptz
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
+`
+
+func (q *Queries) FlagOverdueOrders(ctx context.Context, now time.Time) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, flagOverdueOrders, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.UserID,
+			&i.ServiceIds,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getOrder = `-- name: GetOrder :many
-SELECT id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by FROM orders
+SELECT id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id FROM orders
 WHERE order_id = $1
 `
 
@@ -112,6 +346,17 @@ func (q *Queries) GetOrder(ctx context.Context, orderID int64) ([]Order, error)
 			&i.OrderDelivered,
 			&i.OrderDeliveryTime,
 			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
 		); err != nil {
 			return nil, err
 		}
@@ -126,13 +371,78 @@ func (q *Queries) GetOrder(ctx context.Context, orderID int64) ([]Order, error)
 	return items, nil
 }
 
+const getOrderForUpdate = `-- name: GetOrderForUpdate :many
+SELECT id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id FROM orders
+WHERE order_id = $1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetOrderForUpdate(ctx context.Context, orderID int64) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, getOrderForUpdate, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.UserID,
+			&i.ServiceIds,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrderSubtotal = `-- name: GetOrderSubtotal :one
+SELECT COALESCE(SUM(COALESCE(orders.unit_price, services.service_price)), 0)::bigint AS subtotal
+FROM orders
+JOIN services ON services.service_id = orders.service_ids
+WHERE orders.order_id = $1
+`
+
+func (q *Queries) GetOrderSubtotal(ctx context.Context, orderID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getOrderSubtotal, orderID)
+	var subtotal int64
+	err := row.Scan(&subtotal)
+	return subtotal, err
+}
+
 const listAllOrders = `-- name: ListAllOrders :many
-SELECT id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by FROM orders
+SELECT id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id FROM orders
+WHERE (COALESCE(cardinality($1::int[]), 0) = 0 OR branch_id = ANY($1::int[]))
 ORDER BY id DESC
 `
 
-func (q *Queries) ListAllOrders(ctx context.Context) ([]Order, error) {
-	rows, err := q.db.QueryContext(ctx, listAllOrders)
+func (q *Queries) ListAllOrders(ctx context.Context, branchIds []int32) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, listAllOrders, pq.Array(branchIds))
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +460,17 @@ func (q *Queries) ListAllOrders(ctx context.Context) ([]Order, error) {
 			&i.OrderDelivered,
 			&i.OrderDeliveryTime,
 			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
 		); err != nil {
 			return nil, err
 		}
@@ -165,7 +486,7 @@ func (q *Queries) ListAllOrders(ctx context.Context) ([]Order, error) {
 }
 
 const listAllOrdersByUserId = `-- name: ListAllOrdersByUserId :many
-SELECT id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by FROM orders
+SELECT id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id FROM orders
 ORDER BY user_id DESC
 `
 
@@ -188,6 +509,162 @@ func (q *Queries) ListAllOrdersByUserId(ctx context.Context) ([]Order, error) {
 			&i.OrderDelivered,
 			&i.OrderDeliveryTime,
 			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAssignedOrders = `-- name: ListAssignedOrders :many
+SELECT id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id FROM orders
+WHERE assigned_to = $1
+AND order_delivered = false
+AND order_status <> 'Cancelled'
+AND (COALESCE(cardinality($2::int[]), 0) = 0 OR branch_id = ANY($2::int[]))
+ORDER BY priority DESC, due_at NULLS LAST, order_delivery_time, order_id, id
+`
+
+type ListAssignedOrdersParams struct {
+	AssignedTo sql.NullInt32 `json:"assigned_to"`
+	BranchIds  []int32       `json:"branch_ids"`
+}
+
+func (q *Queries) ListAssignedOrders(ctx context.Context, arg ListAssignedOrdersParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, listAssignedOrders, arg.AssignedTo, pq.Array(arg.BranchIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.UserID,
+			&i.ServiceIds,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderExportRows = `-- name: ListOrderExportRows :many
+SELECT orders.id, orders.order_id, orders.order_status, orders.order_started,
+orders.order_delivered, orders.order_delivery_time, orders.due_at, orders.sla_state, orders.branch_id,
+users.user_id, users.name AS customer_name, users.email AS customer_email, users.phone AS customer_phone,
+services.service_id, services.service_name,
+COALESCE(orders.unit_price, services.service_price)::bigint AS service_price
+FROM orders
+JOIN users ON users.user_id = orders.user_id
+JOIN services ON services.service_id = orders.service_ids
+WHERE orders.id > $1
+AND ($2::varchar IS NULL OR orders.sla_state = $2)
+AND (COALESCE(cardinality($3::int[]), 0) = 0 OR orders.branch_id = ANY($3::int[]))
+ORDER BY orders.id
+LIMIT $4
+`
+
+type ListOrderExportRowsParams struct {
+	AfterID   int32          `json:"after_id"`
+	SlaState  sql.NullString `json:"sla_state"`
+	BranchIds []int32        `json:"branch_ids"`
+	Limit     int32          `json:"limit"`
+}
+
+type ListOrderExportRowsRow struct {
+	ID                int32        `json:"id"`
+	OrderID           int64        `json:"order_id"`
+	OrderStatus       string       `json:"order_status"`
+	OrderStarted      time.Time    `json:"order_started"`
+	OrderDelivered    bool         `json:"order_delivered"`
+	OrderDeliveryTime time.Time    `json:"order_delivery_time"`
+	DueAt             sql.NullTime `json:"due_at"`
+	SlaState          string       `json:"sla_state"`
+	BranchID          int32        `json:"branch_id"`
+	UserID            int32        `json:"user_id"`
+	CustomerName      string       `json:"customer_name"`
+	CustomerEmail     string       `json:"customer_email"`
+	CustomerPhone     string       `json:"customer_phone"`
+	ServiceID         int32        `json:"service_id"`
+	ServiceName       string       `json:"service_name"`
+	ServicePrice      int64        `json:"service_price"`
+}
+
+func (q *Queries) ListOrderExportRows(ctx context.Context, arg ListOrderExportRowsParams) ([]ListOrderExportRowsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrderExportRows,
+		arg.AfterID,
+		arg.SlaState,
+		pq.Array(arg.BranchIds),
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOrderExportRowsRow{}
+	for rows.Next() {
+		var i ListOrderExportRowsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.DueAt,
+			&i.SlaState,
+			&i.BranchID,
+			&i.UserID,
+			&i.CustomerName,
+			&i.CustomerEmail,
+			&i.CustomerPhone,
+			&i.ServiceID,
+			&i.ServiceName,
+			&i.ServicePrice,
 		); err != nil {
 			return nil, err
 		}
@@ -203,19 +680,208 @@ func (q *Queries) ListAllOrdersByUserId(ctx context.Context) ([]Order, error) {
 }
 
 const listOrders = `-- name: ListOrders :many
-SELECT id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by FROM orders
+SELECT id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id FROM orders
+WHERE ($1::varchar IS NULL OR sla_state = $1)
+AND (COALESCE(cardinality($2::int[]), 0) = 0 OR branch_id = ANY($2::int[]))
 ORDER BY order_id DESC
-LIMIT $1
-OFFSET $2
+LIMIT $3
+OFFSET $4
 `
 
 type ListOrdersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	SlaState  sql.NullString `json:"sla_state"`
+	BranchIds []int32        `json:"branch_ids"`
+	Limit     int32          `json:"limit"`
+	Offset    int32          `json:"offset"`
 }
 
 func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error) {
-	rows, err := q.db.QueryContext(ctx, listOrders, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listOrders,
+		arg.SlaState,
+		pq.Array(arg.BranchIds),
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.UserID,
+			&i.ServiceIds,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOrderDelivered = `-- name: MarkOrderDelivered :many
+UPDATE orders
+SET order_status = $2,
+order_delivered = true,
+order_delivery_time = $3,
+modified_by = $4
+WHERE order_id = $1
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
+`
+
+type MarkOrderDeliveredParams struct {
+	OrderID           int64         `json:"order_id"`
+	OrderStatus       string        `json:"order_status"`
+	OrderDeliveryTime time.Time     `json:"order_delivery_time"`
+	ModifiedBy        sql.NullInt32 `json:"modified_by"`
+}
+
+func (q *Queries) MarkOrderDelivered(ctx context.Context, arg MarkOrderDeliveredParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, markOrderDelivered,
+		arg.OrderID,
+		arg.OrderStatus,
+		arg.OrderDeliveryTime,
+		arg.ModifiedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.UserID,
+			&i.ServiceIds,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setOrderDueAt = `-- name: SetOrderDueAt :many
+UPDATE orders
+SET due_at = $2
+WHERE order_id = $1
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
+`
+
+type SetOrderDueAtParams struct {
+	OrderID int64        `json:"order_id"`
+	DueAt   sql.NullTime `json:"due_at"`
+}
+
+func (q *Queries) SetOrderDueAt(ctx context.Context, arg SetOrderDueAtParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, setOrderDueAt, arg.OrderID, arg.DueAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.UserID,
+			&i.ServiceIds,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setOrderStatus = `-- name: SetOrderStatus :many
+UPDATE orders
+SET order_status = $2,
+modified_by = $3
+WHERE order_id = $1
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
+`
+
+type SetOrderStatusParams struct {
+	OrderID     int64         `json:"order_id"`
+	OrderStatus string        `json:"order_status"`
+	ModifiedBy  sql.NullInt32 `json:"modified_by"`
+}
+
+func (q *Queries) SetOrderStatus(ctx context.Context, arg SetOrderStatusParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, setOrderStatus, arg.OrderID, arg.OrderStatus, arg.ModifiedBy)
 	if err != nil {
 		return nil, err
 	}
@@ -233,6 +899,17 @@ func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order
 			&i.OrderDelivered,
 			&i.OrderDeliveryTime,
 			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
 		); err != nil {
 			return nil, err
 		}
@@ -251,7 +928,7 @@ const updateOrder = `-- name: UpdateOrder :one
 UPDATE orders 
 SET order_status = $2
 WHERE order_id = $1
-RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
 `
 
 type UpdateOrderParams struct {
@@ -272,6 +949,17 @@ func (q *Queries) UpdateOrder(ctx context.Context, arg UpdateOrderParams) (Order
 		&i.OrderDelivered,
 		&i.OrderDeliveryTime,
 		&i.ModifiedBy,
+		&i.PickupSlotID,
+		&i.DeliverySlotID,
+		&i.AssignedTo,
+		&i.AssignedAt,
+		&i.Priority,
+		&i.DueAt,
+		&i.SlaState,
+		&i.VariantID,
+		&i.UnitPrice,
+		&i.BundleID,
+		&i.BranchID,
 	)
 	return i, err
 }
@@ -281,7 +969,7 @@ UPDATE orders
 SET order_delivered = $2,
 order_delivery_time = $3
 WHERE order_id = $1
-RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
 `
 
 type UpdateOrderDeliveryParams struct {
@@ -303,15 +991,149 @@ func (q *Queries) UpdateOrderDelivery(ctx context.Context, arg UpdateOrderDelive
 		&i.OrderDelivered,
 		&i.OrderDeliveryTime,
 		&i.ModifiedBy,
+		&i.PickupSlotID,
+		&i.DeliverySlotID,
+		&i.AssignedTo,
+		&i.AssignedAt,
+		&i.Priority,
+		&i.DueAt,
+		&i.SlaState,
+		&i.VariantID,
+		&i.UnitPrice,
+		&i.BundleID,
+		&i.BranchID,
 	)
 	return i, err
 }
 
+const updateOrderPriority = `-- name: UpdateOrderPriority :many
+UPDATE orders
+SET priority = $2,
+modified_by = $3
+WHERE order_id = $1
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
+`
+
+type UpdateOrderPriorityParams struct {
+	OrderID    int64         `json:"order_id"`
+	Priority   int32         `json:"priority"`
+	ModifiedBy sql.NullInt32 `json:"modified_by"`
+}
+
+func (q *Queries) UpdateOrderPriority(ctx context.Context, arg UpdateOrderPriorityParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, updateOrderPriority, arg.OrderID, arg.Priority, arg.ModifiedBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.UserID,
+			&i.ServiceIds,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateOrderSlots = `-- name: UpdateOrderSlots :many
+UPDATE orders
+SET pickup_slot_id = $2,
+delivery_slot_id = $3,
+order_delivery_time = $4
+WHERE order_id = $1
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
+`
+
+type UpdateOrderSlotsParams struct {
+	OrderID           int64         `json:"order_id"`
+	PickupSlotID      sql.NullInt32 `json:"pickup_slot_id"`
+	DeliverySlotID    sql.NullInt32 `json:"delivery_slot_id"`
+	OrderDeliveryTime time.Time     `json:"order_delivery_time"`
+}
+
+func (q *Queries) UpdateOrderSlots(ctx context.Context, arg UpdateOrderSlotsParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, updateOrderSlots,
+		arg.OrderID,
+		arg.PickupSlotID,
+		arg.DeliverySlotID,
+		arg.OrderDeliveryTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.UserID,
+			&i.ServiceIds,
+			&i.OrderStatus,
+			&i.OrderStarted,
+			&i.OrderDelivered,
+			&i.OrderDeliveryTime,
+			&i.ModifiedBy,
+			&i.PickupSlotID,
+			&i.DeliverySlotID,
+			&i.AssignedTo,
+			&i.AssignedAt,
+			&i.Priority,
+			&i.DueAt,
+			&i.SlaState,
+			&i.VariantID,
+			&i.UnitPrice,
+			&i.BundleID,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateOrderStatus = `-- name: UpdateOrderStatus :one
 UPDATE orders 
 SET order_status = $2
 WHERE order_id = $1
-RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by
+RETURNING id, order_id, user_id, service_ids, order_status, order_started, order_delivered, order_delivery_time, modified_by, pickup_slot_id, delivery_slot_id, assigned_to, assigned_at, priority, due_at, sla_state, variant_id, unit_price, bundle_id, branch_id
 `
 
 type UpdateOrderStatusParams struct {
@@ -332,6 +1154,17 @@ func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusPa
 		&i.OrderDelivered,
 		&i.OrderDeliveryTime,
 		&i.ModifiedBy,
+		&i.PickupSlotID,
+		&i.DeliverySlotID,
+		&i.AssignedTo,
+		&i.AssignedAt,
+		&i.Priority,
+		&i.DueAt,
+		&i.SlaState,
+		&i.VariantID,
+		&i.UnitPrice,
+		&i.BundleID,
+		&i.BranchID,
 	)
 	return i, err
 }
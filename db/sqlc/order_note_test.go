@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOrderNotesVisibility(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+	admin := createRandomAdmin(t)
+
+	internal, err := testQueries.CreateOrderNote(context.Background(), CreateOrderNoteParams{
+		OrderID:    order.OrderID,
+		Body:       "stain on left sleeve",
+		Visibility: util.VisibilityInternal,
+		AdminID:    sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	shared, err := testQueries.CreateOrderNote(context.Background(), CreateOrderNoteParams{
+		OrderID:    order.OrderID,
+		Body:       util.RandomString(20),
+		Visibility: util.VisibilityCustomer,
+		UserID:     sql.NullInt32{Int32: order.UserID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	notes, err := testQueries.ListOrderNotes(context.Background(), ListOrderNotesParams{
+		OrderID:         order.OrderID,
+		IncludeInternal: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+	require.Equal(t, internal.NoteID, notes[0].NoteID)
+
+	notes, err = testQueries.ListOrderNotes(context.Background(), ListOrderNotesParams{
+		OrderID: order.OrderID,
+	})
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	require.Equal(t, shared.NoteID, notes[0].NoteID)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetServiceRatingSummary(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+	serviceID := sql.NullInt32{Int32: order.ServiceIds, Valid: true}
+
+	summary, err := testQueries.GetServiceRatingSummary(context.Background(), serviceID)
+	require.NoError(t, err)
+	require.Zero(t, summary.RatingCount)
+	require.Zero(t, summary.AverageRating)
+
+	rating, err := testQueries.CreateOrderRating(context.Background(), CreateOrderRatingParams{
+		OrderID:   order.OrderID,
+		UserID:    order.UserID,
+		ServiceID: serviceID,
+		Rating:    4,
+		Comment:   "crisp shirts",
+	})
+	require.NoError(t, err)
+
+	// The order itself is rated separately from its services
+	_, err = testQueries.CreateOrderRating(context.Background(), CreateOrderRatingParams{
+		OrderID: order.OrderID,
+		UserID:  order.UserID,
+		Rating:  2,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.CreateOrderRating(context.Background(), CreateOrderRatingParams{
+		OrderID:   order.OrderID,
+		UserID:    order.UserID,
+		ServiceID: serviceID,
+		Rating:    1,
+	})
+	require.Error(t, err)
+
+	rating, err = testQueries.UpdateOrderRating(context.Background(), UpdateOrderRatingParams{
+		RatingID: rating.RatingID,
+		Rating:   5,
+		Comment:  rating.Comment,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(5), rating.Rating)
+
+	summary, err = testQueries.GetServiceRatingSummary(context.Background(), serviceID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), summary.RatingCount)
+	require.Equal(t, float64(5), summary.AverageRating)
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 func createRandomOrder(t *testing.T) Order {
 	arg := CreateOrderParams{
 		UserID:      int32(util.RandomOrder()),
+		BranchID:    defaultBranch(t).BranchID,
 		ServiceIds:  int32(util.RandomOrder()),
 		OrderStatus: util.RandomOrderStatus(),
 	}
@@ -113,3 +115,107 @@ func TestDeleteOrder(t *testing.T) {
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 	require.Empty(t, res)
 }
+
+func TestUpdateOrderDeliveryTx(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+	require.False(t, order.OrderDelivered)
+
+	arg := UpdateOrderDeliveryParams{
+		OrderID:           order.OrderID,
+		OrderDelivered:    true,
+		OrderDeliveryTime: time.Now(),
+	}
+	result, err := store.UpdateOrderDeliveryTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.Order.OrderDelivered)
+	require.True(t, result.Delivered)
+
+	// Saving the delivery again does not deliver the order a second time
+	result, err = store.UpdateOrderDeliveryTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.Order.OrderDelivered)
+	require.False(t, result.Delivered)
+}
+
+func TestFlagOverdueOrders(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+	require.True(t, order.DueAt.Valid)
+	require.Equal(t, util.SLAOnTrack, order.SlaState)
+
+	atRisk, err := testQueries.FlagAtRiskOrders(context.Background(), FlagAtRiskOrdersParams{
+		Now:          order.DueAt.Time.Add(-time.Hour),
+		AtRiskBefore: order.DueAt.Time,
+	})
+	require.NoError(t, err)
+	requireOrderInState(t, atRisk, order.OrderID, util.SLAAtRisk)
+
+	overdue, err := testQueries.FlagOverdueOrders(context.Background(), order.DueAt.Time)
+	require.NoError(t, err)
+	requireOrderInState(t, overdue, order.OrderID, util.SLAOverdue)
+
+	// An overdue order is only flagged once
+	overdue, err = testQueries.FlagOverdueOrders(context.Background(), order.DueAt.Time)
+	require.NoError(t, err)
+	for _, o := range overdue {
+		require.NotEqual(t, order.OrderID, o.OrderID)
+	}
+}
+
+func TestFlagSLATxNotifyFails(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+
+	// An order whose event could not be published stays on track and is flagged on the next check
+	errNotify := errors.New("cannot publish")
+	err := store.FlagSLATx(context.Background(), FlagSLATxParams{
+		Now:          order.DueAt.Time,
+		AtRiskBefore: order.DueAt.Time,
+		Notify: func(atRisk, overdue []Order) error {
+			requireOrderInState(t, overdue, order.OrderID, util.SLAOverdue)
+			return errNotify
+		},
+	})
+	require.ErrorIs(t, err, errNotify)
+
+	rows, err := testQueries.GetOrder(context.Background(), order.OrderID)
+	require.NoError(t, err)
+	require.NotEmpty(t, rows)
+	for _, row := range rows {
+		require.Equal(t, util.SLAOnTrack, row.SlaState)
+	}
+}
+
+func requireOrderInState(t *testing.T, orders []Order, orderID int64, state string) {
+	for _, o := range orders {
+		if o.OrderID == orderID {
+			require.Equal(t, state, o.SlaState)
+			return
+		}
+	}
+	t.Fatalf("order %d was not flagged %s", orderID, state)
+}
+
+func TestListOrderExportRows(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+
+	rows, err := testQueries.ListOrderExportRows(context.Background(), ListOrderExportRowsParams{
+		AfterID: order.ID - 1,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, order.OrderID, rows[0].OrderID)
+	require.Equal(t, order.UserID, rows[0].UserID)
+	require.NotEmpty(t, rows[0].CustomerName)
+	require.Equal(t, order.ServiceIds, rows[0].ServiceID)
+	require.Equal(t, order.BranchID, rows[0].BranchID)
+
+	count, err := testQueries.CountOrderExportRows(context.Background(), CountOrderExportRowsParams{
+		SlaState: sql.NullString{String: util.SLAOverdue, Valid: true},
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, count, int64(0))
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPayment(t *testing.T, store *Store, orderID int64, amount int64, status string) Payment {
+	payment, err := store.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+		OrderID:   orderID,
+		Method:    util.PaymentMethodCash,
+		Amount:    amount,
+		Reference: util.RandomString(8),
+		Status:    status,
+	})
+	require.NoError(t, err)
+	require.Equal(t, orderID, payment.OrderID)
+	require.Equal(t, amount, payment.Amount)
+
+	return payment
+}
+
+func TestRecordPaymentTxUnknownOrder(t *testing.T) {
+	store := NewStore(testDB)
+
+	_, err := store.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+		OrderID: util.NewOrderID(),
+		Method:  util.PaymentMethodCash,
+		Amount:  100,
+		Status:  util.PaymentCaptured,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRefundPaymentTx(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+	payment := createRandomPayment(t, store, order.OrderID, 1000, util.PaymentCaptured)
+
+	refund, err := store.RefundPaymentTx(context.Background(), RefundPaymentTxParams{
+		PaymentID: payment.PaymentID,
+		Amount:    600,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-600), refund.Amount)
+	require.Equal(t, payment.PaymentID, refund.RefundOf.Int64)
+
+	// Only 400 is left to refund on the payment
+	_, err = store.RefundPaymentTx(context.Background(), RefundPaymentTxParams{
+		PaymentID: payment.PaymentID,
+		Amount:    401,
+	})
+	require.ErrorIs(t, err, ErrRefundTooLarge)
+
+	_, err = store.RefundPaymentTx(context.Background(), RefundPaymentTxParams{
+		PaymentID: payment.PaymentID,
+		Amount:    400,
+	})
+	require.NoError(t, err)
+
+	// A refund cannot be refunded itself
+	_, err = store.RefundPaymentTx(context.Background(), RefundPaymentTxParams{
+		PaymentID: refund.PaymentID,
+		Amount:    1,
+	})
+	require.ErrorIs(t, err, ErrPaymentNotRefundable)
+}
+
+func TestRefundPaymentTxNotCaptured(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+	payment := createRandomPayment(t, store, order.OrderID, 1000, util.PaymentPending)
+
+	_, err := store.RefundPaymentTx(context.Background(), RefundPaymentTxParams{
+		PaymentID: payment.PaymentID,
+		Amount:    100,
+	})
+	require.ErrorIs(t, err, ErrPaymentNotRefundable)
+}
+
+func TestRefundPaymentTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+	payment := createRandomPayment(t, store, order.OrderID, 1000, util.PaymentCaptured)
+
+	// Five refunds of 300 race for the same payment, only three fit in the amount paid
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.RefundPaymentTx(context.Background(), RefundPaymentTxParams{
+				PaymentID: payment.PaymentID,
+				Amount:    300,
+			})
+			errs <- err
+		}()
+	}
+
+	var refunded int
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			refunded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrRefundTooLarge)
+	}
+	require.Equal(t, 3, refunded)
+
+	amount, err := testQueries.GetRefundedAmount(context.Background(), sql.NullInt64{Int64: payment.PaymentID, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(900), amount)
+}
+
+func TestListOrderPaymentSummaries(t *testing.T) {
+	store := NewStore(testDB)
+	order1 := createRandomStoreOrder(t, store)
+	order2 := createRandomStoreOrder(t, store)
+
+	payment := createRandomPayment(t, store, order1.OrderID, 500, util.PaymentCaptured)
+	createRandomPayment(t, store, order1.OrderID, 700, util.PaymentPending)
+	_, err := store.RefundPaymentTx(context.Background(), RefundPaymentTxParams{
+		PaymentID: payment.PaymentID,
+		Amount:    200,
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.ListOrderPaymentSummaries(context.Background(), []int64{order1.OrderID, order2.OrderID})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	for _, row := range rows {
+		subtotal, err := testQueries.GetOrderSubtotal(context.Background(), row.OrderID)
+		require.NoError(t, err)
+		require.Equal(t, subtotal, row.Subtotal)
+		require.False(t, row.InvoiceTotal.Valid)
+
+		// Pending payments are not counted
+		if row.OrderID == order1.OrderID {
+			require.Equal(t, int64(500), row.Paid)
+			require.Equal(t, int64(200), row.Refunded)
+		} else {
+			require.Zero(t, row.Paid)
+			require.Zero(t, row.Refunded)
+		}
+	}
+}
+
+func TestStartOnlinePaymentTx(t *testing.T) {
+	store := NewStore(testDB)
+	order := createRandomStoreOrder(t, store)
+
+	intents := 0
+	arg := StartOnlinePaymentTxParams{
+		OrderID:  order.OrderID,
+		Provider: "fake",
+		Amount:   1000,
+		CreateIntent: func() (string, error) {
+			intents++
+			return util.RandomString(12), nil
+		},
+	}
+	first, err := store.StartOnlinePaymentTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, first.Created)
+	require.Equal(t, util.PaymentPending, first.Payment.Status)
+
+	// Starting again for the same amount continues the pending payment
+	again, err := store.StartOnlinePaymentTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, again.Created)
+	require.Equal(t, first.Payment.PaymentID, again.Payment.PaymentID)
+	require.Equal(t, 1, intents)
+
+	// A different amount supersedes it
+	arg.Amount = 1500
+	changed, err := store.StartOnlinePaymentTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, changed.Created)
+	require.Equal(t, 2, intents)
+
+	superseded, err := testQueries.GetPayment(context.Background(), first.Payment.PaymentID)
+	require.NoError(t, err)
+	require.Equal(t, util.PaymentFailed, superseded.Status)
+}
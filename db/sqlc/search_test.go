@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchUsers(t *testing.T) {
+	user := createRandomUser(t)
+
+	// Part of a name is enough to find a customer, the whole name ranks first
+	users, err := testQueries.SearchUsers(context.Background(), SearchUsersParams{
+		Query: user.Name[2:8],
+		Limit: 50,
+	})
+	require.NoError(t, err)
+	found := false
+	for _, result := range users {
+		found = found || result.UserID == user.UserID
+	}
+	require.True(t, found)
+
+	users, err = testQueries.SearchUsers(context.Background(), SearchUsersParams{
+		Query: user.Name,
+		Limit: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, user.UserID, users[0].UserID)
+}
+
+func TestSearchServicesAndOrders(t *testing.T) {
+	service := createRandomService(t)
+
+	services, err := testQueries.SearchServices(context.Background(), SearchServicesParams{
+		Query: service.ServiceName,
+		Limit: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	require.Equal(t, service.ServiceID, services[0].ServiceID)
+
+	order := createRandomStoreOrder(t, NewStore(testDB))
+	number := strconv.FormatInt(order.OrderID, 10)
+	orders, err := testQueries.SearchOrders(context.Background(), SearchOrdersParams{
+		Digits: util.SearchDigits(number),
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, order.OrderID, orders[0].OrderID)
+}
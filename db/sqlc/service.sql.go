@@ -7,47 +7,103 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
+const archiveService = `-- name: ArchiveService :one
+UPDATE services
+SET archived_at = COALESCE(archived_at, now())
+WHERE service_id = $1
+RETURNING service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version
+`
+
+func (q *Queries) ArchiveService(ctx context.Context, serviceID int32) (Service, error) {
+	row := q.db.QueryRowContext(ctx, archiveService, serviceID)
+	var i Service
+	err := row.Scan(
+		&i.ServiceID,
+		&i.ServiceName,
+		&i.ServicePrice,
+		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
+	)
+	return i, err
+}
+
+const countServices = `-- name: CountServices :one
+SELECT count(*) FROM services
+WHERE archived_at IS NULL
+`
+
+func (q *Queries) CountServices(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countServices)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countServicesWithImage = `-- name: CountServicesWithImage :one
+SELECT count(*) FROM services
+WHERE service_image = $1
+`
+
+func (q *Queries) CountServicesWithImage(ctx context.Context, serviceImage string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countServicesWithImage, serviceImage)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createService = `-- name: CreateService :one
 INSERT INTO services (
   service_name,
   service_price,
-  service_image 
+  service_image,
+  turnaround_hours
 ) VALUES (
-  $1, $2, $3
-) RETURNING service_id, service_name, service_price, service_image
+  $1, $2, $3, $4
+) RETURNING service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version
 `
 
 type CreateServiceParams struct {
-	ServiceName  string `json:"service_name"`
-	ServicePrice int64  `json:"service_price"`
-	ServiceImage string `json:"service_image"`
+	ServiceName     string `json:"service_name"`
+	ServicePrice    int64  `json:"service_price"`
+	ServiceImage    string `json:"service_image"`
+	TurnaroundHours int32  `json:"turnaround_hours"`
 }
 
 func (q *Queries) CreateService(ctx context.Context, arg CreateServiceParams) (Service, error) {
-	row := q.db.QueryRowContext(ctx, createService, arg.ServiceName, arg.ServicePrice, arg.ServiceImage)
+	row := q.db.QueryRowContext(ctx, createService,
+		arg.ServiceName,
+		arg.ServicePrice,
+		arg.ServiceImage,
+		arg.TurnaroundHours,
+	)
 	var i Service
 	err := row.Scan(
 		&i.ServiceID,
 		&i.ServiceName,
 		&i.ServicePrice,
 		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
 	)
 	return i, err
 }
 
-const deleteService = `-- name: DeleteService :exec
-DELETE FROM services WHERE service_id = $1
-`
-
-func (q *Queries) DeleteService(ctx context.Context, serviceID int32) error {
-	_, err := q.db.ExecContext(ctx, deleteService, serviceID)
-	return err
-}
-
 const getService = `-- name: GetService :one
-SELECT service_id, service_name, service_price, service_image FROM services
+SELECT service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version FROM services
 WHERE service_id = $1 LIMIT 1
 `
 
@@ -59,12 +115,44 @@ func (q *Queries) GetService(ctx context.Context, serviceID int32) (Service, err
 		&i.ServiceName,
 		&i.ServicePrice,
 		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getServiceByName = `-- name: GetServiceByName :one
+SELECT service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version FROM services
+WHERE service_name = $1 LIMIT 1
+`
+
+func (q *Queries) GetServiceByName(ctx context.Context, serviceName string) (Service, error) {
+	row := q.db.QueryRowContext(ctx, getServiceByName, serviceName)
+	var i Service
+	err := row.Scan(
+		&i.ServiceID,
+		&i.ServiceName,
+		&i.ServicePrice,
+		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
 	)
 	return i, err
 }
 
 const listAllServices = `-- name: ListAllServices :many
-SELECT service_id, service_name, service_price, service_image FROM services
+SELECT service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version FROM services
+WHERE archived_at IS NULL
 ORDER BY service_id
 `
 
@@ -82,6 +170,13 @@ func (q *Queries) ListAllServices(ctx context.Context) ([]Service, error) {
 			&i.ServiceName,
 			&i.ServicePrice,
 			&i.ServiceImage,
+			&i.TurnaroundHours,
+			&i.CategoryID,
+			&i.ArchivedAt,
+			&i.ImageThumbnail,
+			&i.ImageMedium,
+			&i.ImageWebp,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -97,19 +192,33 @@ func (q *Queries) ListAllServices(ctx context.Context) ([]Service, error) {
 }
 
 const listLimitedServices = `-- name: ListLimitedServices :many
-SELECT service_id, service_name, service_price, service_image FROM services
+SELECT service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version FROM services
+WHERE (archived_at IS NULL OR $1::boolean)
+AND ($2::int IS NULL OR NOT EXISTS (
+  SELECT 1 FROM service_branches
+  WHERE service_branches.service_id = services.service_id
+  AND service_branches.branch_id = $2
+  AND NOT service_branches.is_available
+))
 ORDER BY service_id
-LIMIT $1
-OFFSET $2
+LIMIT $3
+OFFSET $4
 `
 
 type ListLimitedServicesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	IncludeArchived bool          `json:"include_archived"`
+	BranchID        sql.NullInt32 `json:"branch_id"`
+	Limit           int32         `json:"limit"`
+	Offset          int32         `json:"offset"`
 }
 
 func (q *Queries) ListLimitedServices(ctx context.Context, arg ListLimitedServicesParams) ([]Service, error) {
-	rows, err := q.db.QueryContext(ctx, listLimitedServices, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listLimitedServices,
+		arg.IncludeArchived,
+		arg.BranchID,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -122,6 +231,13 @@ func (q *Queries) ListLimitedServices(ctx context.Context, arg ListLimitedServic
 			&i.ServiceName,
 			&i.ServicePrice,
 			&i.ServiceImage,
+			&i.TurnaroundHours,
+			&i.CategoryID,
+			&i.ArchivedAt,
+			&i.ImageThumbnail,
+			&i.ImageMedium,
+			&i.ImageWebp,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -136,28 +252,306 @@ func (q *Queries) ListLimitedServices(ctx context.Context, arg ListLimitedServic
 	return items, nil
 }
 
-const updateService = `-- name: UpdateService :one
-UPDATE services 
-SET service_name = $2,
-service_price = $3,
-service_image = $4
+const listServicesAfter = `-- name: ListServicesAfter :many
+SELECT service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version FROM services
+WHERE service_id > $1 AND archived_at IS NULL
+ORDER BY service_id
+LIMIT $2
+`
+
+type ListServicesAfterParams struct {
+	ServiceID int32 `json:"service_id"`
+	Limit     int32 `json:"limit"`
+}
+
+func (q *Queries) ListServicesAfter(ctx context.Context, arg ListServicesAfterParams) ([]Service, error) {
+	rows, err := q.db.QueryContext(ctx, listServicesAfter, arg.ServiceID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Service{}
+	for rows.Next() {
+		var i Service
+		if err := rows.Scan(
+			&i.ServiceID,
+			&i.ServiceName,
+			&i.ServicePrice,
+			&i.ServiceImage,
+			&i.TurnaroundHours,
+			&i.CategoryID,
+			&i.ArchivedAt,
+			&i.ImageThumbnail,
+			&i.ImageMedium,
+			&i.ImageWebp,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreService = `-- name: RestoreService :one
+UPDATE services
+SET archived_at = NULL
 WHERE service_id = $1
-RETURNING service_id, service_name, service_price, service_image
+RETURNING service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version
 `
 
-type UpdateServiceParams struct {
+func (q *Queries) RestoreService(ctx context.Context, serviceID int32) (Service, error) {
+	row := q.db.QueryRowContext(ctx, restoreService, serviceID)
+	var i Service
+	err := row.Scan(
+		&i.ServiceID,
+		&i.ServiceName,
+		&i.ServicePrice,
+		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
+	)
+	return i, err
+}
+
+const setServiceCategory = `-- name: SetServiceCategory :one
+UPDATE services
+SET category_id = $2,
+version = version + 1
+WHERE service_id = $1
+RETURNING service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version
+`
+
+type SetServiceCategoryParams struct {
+	ServiceID  int32         `json:"service_id"`
+	CategoryID sql.NullInt32 `json:"category_id"`
+}
+
+func (q *Queries) SetServiceCategory(ctx context.Context, arg SetServiceCategoryParams) (Service, error) {
+	row := q.db.QueryRowContext(ctx, setServiceCategory, arg.ServiceID, arg.CategoryID)
+	var i Service
+	err := row.Scan(
+		&i.ServiceID,
+		&i.ServiceName,
+		&i.ServicePrice,
+		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
+	)
+	return i, err
+}
+
+const setServiceImage = `-- name: SetServiceImage :one
+UPDATE services
+SET service_image = $2,
+image_thumbnail = '',
+image_medium = '',
+image_webp = '',
+version = version + 1
+WHERE service_id = $1
+AND version = $3
+AND archived_at IS NULL
+RETURNING service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version
+`
+
+type SetServiceImageParams struct {
 	ServiceID    int32  `json:"service_id"`
-	ServiceName  string `json:"service_name"`
-	ServicePrice int64  `json:"service_price"`
 	ServiceImage string `json:"service_image"`
+	Version      int32  `json:"version"`
+}
+
+func (q *Queries) SetServiceImage(ctx context.Context, arg SetServiceImageParams) (Service, error) {
+	row := q.db.QueryRowContext(ctx, setServiceImage, arg.ServiceID, arg.ServiceImage, arg.Version)
+	var i Service
+	err := row.Scan(
+		&i.ServiceID,
+		&i.ServiceName,
+		&i.ServicePrice,
+		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
+	)
+	return i, err
+}
+
+const setServiceImageVariants = `-- name: SetServiceImageVariants :one
+UPDATE services
+SET image_thumbnail = $3,
+image_medium = $4,
+image_webp = $5
+WHERE service_id = $1
+AND service_image = $2
+RETURNING service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version
+`
+
+type SetServiceImageVariantsParams struct {
+	ServiceID      int32  `json:"service_id"`
+	ServiceImage   string `json:"service_image"`
+	ImageThumbnail string `json:"image_thumbnail"`
+	ImageMedium    string `json:"image_medium"`
+	ImageWebp      string `json:"image_webp"`
+}
+
+func (q *Queries) SetServiceImageVariants(ctx context.Context, arg SetServiceImageVariantsParams) (Service, error) {
+	row := q.db.QueryRowContext(ctx, setServiceImageVariants,
+		arg.ServiceID,
+		arg.ServiceImage,
+		arg.ImageThumbnail,
+		arg.ImageMedium,
+		arg.ImageWebp,
+	)
+	var i Service
+	err := row.Scan(
+		&i.ServiceID,
+		&i.ServiceName,
+		&i.ServicePrice,
+		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
+	)
+	return i, err
+}
+
+const setServicePrice = `-- name: SetServicePrice :one
+UPDATE services
+SET service_price = $2,
+version = version + 1
+WHERE service_id = $1
+RETURNING service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version
+`
+
+type SetServicePriceParams struct {
+	ServiceID    int32 `json:"service_id"`
+	ServicePrice int64 `json:"service_price"`
+}
+
+func (q *Queries) SetServicePrice(ctx context.Context, arg SetServicePriceParams) (Service, error) {
+	row := q.db.QueryRowContext(ctx, setServicePrice, arg.ServiceID, arg.ServicePrice)
+	var i Service
+	err := row.Scan(
+		&i.ServiceID,
+		&i.ServiceName,
+		&i.ServicePrice,
+		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateService = `-- name: UpdateService :one
+UPDATE services
+SET service_name = COALESCE($1, service_name),
+service_price = COALESCE($2, service_price),
+turnaround_hours = COALESCE($3, turnaround_hours),
+service_image = COALESCE($4, service_image),
+image_thumbnail = CASE WHEN COALESCE($4, service_image) = service_image THEN image_thumbnail ELSE '' END,
+image_medium = CASE WHEN COALESCE($4, service_image) = service_image THEN image_medium ELSE '' END,
+image_webp = CASE WHEN COALESCE($4, service_image) = service_image THEN image_webp ELSE '' END,
+version = version + 1
+WHERE service_id = $5
+AND version = $6
+RETURNING service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version
+`
+
+type UpdateServiceParams struct {
+	ServiceName     sql.NullString `json:"service_name"`
+	ServicePrice    sql.NullInt64  `json:"service_price"`
+	TurnaroundHours sql.NullInt32  `json:"turnaround_hours"`
+	ServiceImage    sql.NullString `json:"service_image"`
+	ServiceID       int32          `json:"service_id"`
+	Version         int32          `json:"version"`
 }
 
 func (q *Queries) UpdateService(ctx context.Context, arg UpdateServiceParams) (Service, error) {
 	row := q.db.QueryRowContext(ctx, updateService,
+		arg.ServiceName,
+		arg.ServicePrice,
+		arg.TurnaroundHours,
+		arg.ServiceImage,
 		arg.ServiceID,
+		arg.Version,
+	)
+	var i Service
+	err := row.Scan(
+		&i.ServiceID,
+		&i.ServiceName,
+		&i.ServicePrice,
+		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
+	)
+	return i, err
+}
+
+const upsertService = `-- name: UpsertService :one
+INSERT INTO services (
+  service_name,
+  service_price,
+  service_image,
+  turnaround_hours
+) VALUES (
+  $1, $2, $3, $4
+) ON CONFLICT (service_name) DO UPDATE
+SET service_price = EXCLUDED.service_price,
+service_image = CASE WHEN EXCLUDED.service_image = '' THEN services.service_image ELSE EXCLUDED.service_image END,
+turnaround_hours = EXCLUDED.turnaround_hours,
+version = services.version + 1,
+image_thumbnail = CASE WHEN EXCLUDED.service_image IN ('', services.service_image) THEN services.image_thumbnail ELSE '' END,
+image_medium = CASE WHEN EXCLUDED.service_image IN ('', services.service_image) THEN services.image_medium ELSE '' END,
+image_webp = CASE WHEN EXCLUDED.service_image IN ('', services.service_image) THEN services.image_webp ELSE '' END
+RETURNING service_id, service_name, service_price, service_image, turnaround_hours, category_id, archived_at, image_thumbnail, image_medium, image_webp, version
+`
+
+type UpsertServiceParams struct {
+	ServiceName     string `json:"service_name"`
+	ServicePrice    int64  `json:"service_price"`
+	ServiceImage    string `json:"service_image"`
+	TurnaroundHours int32  `json:"turnaround_hours"`
+}
+
+func (q *Queries) UpsertService(ctx context.Context, arg UpsertServiceParams) (Service, error) {
+	row := q.db.QueryRowContext(ctx, upsertService,
 		arg.ServiceName,
 		arg.ServicePrice,
 		arg.ServiceImage,
+		arg.TurnaroundHours,
 	)
 	var i Service
 	err := row.Scan(
@@ -165,6 +559,13 @@ func (q *Queries) UpdateService(ctx context.Context, arg UpdateServiceParams) (S
 		&i.ServiceName,
 		&i.ServicePrice,
 		&i.ServiceImage,
+		&i.TurnaroundHours,
+		&i.CategoryID,
+		&i.ArchivedAt,
+		&i.ImageThumbnail,
+		&i.ImageMedium,
+		&i.ImageWebp,
+		&i.Version,
 	)
 	return i, err
 }
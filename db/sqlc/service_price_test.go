@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetServicePriceTx(t *testing.T) {
+	store := NewStore(testDB)
+	service := createRandomService(t)
+	now := time.Now()
+
+	// Without a timeline the service price is in effect
+	price, err := testQueries.GetEffectiveServicePrice(context.Background(), GetEffectiveServicePriceParams{
+		At:        now,
+		ServiceID: service.ServiceID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, service.ServicePrice, price)
+
+	current, err := store.SetServicePriceTx(context.Background(), SetServicePriceTxParams{
+		ServiceID:   service.ServiceID,
+		Price:       service.ServicePrice + 10,
+		EffectiveAt: now.Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, service.ServicePrice+10, current.Service.ServicePrice)
+
+	scheduled, err := store.SetServicePriceTx(context.Background(), SetServicePriceTxParams{
+		ServiceID:   service.ServiceID,
+		Price:       service.ServicePrice + 20,
+		EffectiveAt: now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, service.ServicePrice+10, scheduled.Service.ServicePrice)
+
+	price, err = testQueries.GetEffectiveServicePrice(context.Background(), GetEffectiveServicePriceParams{
+		At:        now.Add(2 * time.Hour),
+		ServiceID: service.ServiceID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, service.ServicePrice+20, price)
+
+	prices, err := testQueries.ListServicePrices(context.Background(), service.ServiceID)
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	require.Equal(t, current.Price.PriceID, prices[0].PriceID)
+
+	// Orders are priced at the price in effect when they are placed
+	user := createRandomUser(t)
+	result, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{
+		OrderID:     util.NewOrderID(),
+		UserID:      user.UserID,
+		BranchID:    defaultBranch(t).BranchID,
+		ServiceIDs:  []int32{service.ServiceID},
+		OrderStatus: util.OrderStatusPending,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sql.NullInt64{Int64: service.ServicePrice + 10, Valid: true}, result.Orders[0].UnitPrice)
+
+	// Only changes that have not taken effect can be cancelled
+	_, err = testQueries.DeleteScheduledServicePrice(context.Background(), current.Price.PriceID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.DeleteScheduledServicePrice(context.Background(), scheduled.Price.PriceID)
+	require.NoError(t, err)
+}
+
+func TestSetServicePriceTxBackdated(t *testing.T) {
+	store := NewStore(testDB)
+	service := createRandomService(t)
+	now := time.Now()
+
+	latest, err := store.SetServicePriceTx(context.Background(), SetServicePriceTxParams{
+		ServiceID:   service.ServiceID,
+		Price:       service.ServicePrice + 10,
+		EffectiveAt: now.Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, service.ServicePrice+10, latest.Service.ServicePrice)
+
+	// An entry older than the one in effect only fills in the timeline
+	backdated, err := store.SetServicePriceTx(context.Background(), SetServicePriceTxParams{
+		ServiceID:   service.ServiceID,
+		Price:       service.ServicePrice + 30,
+		EffectiveAt: now.Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, service.ServicePrice+10, backdated.Service.ServicePrice)
+
+	stored, err := testQueries.GetService(context.Background(), service.ServiceID)
+	require.NoError(t, err)
+	require.Equal(t, service.ServicePrice+10, stored.ServicePrice)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
@@ -10,8 +11,9 @@ import (
 
 func createRandomService(t *testing.T) Service {
 	arg := CreateServiceParams{
-		ServiceName:  util.RandomUser(),
-		ServicePrice: int64(util.RandomPrice()),
+		ServiceName:     util.RandomUser(),
+		ServicePrice:    int64(util.RandomPrice()),
+		TurnaroundHours: util.DefaultTurnaroundHours,
 	}
 
 	service, err := testQueries.CreateService(context.Background(), arg)
@@ -20,6 +22,7 @@ func createRandomService(t *testing.T) Service {
 
 	require.Equal(t, arg.ServiceName, service.ServiceName)
 	require.Equal(t, arg.ServicePrice, service.ServicePrice)
+	require.Equal(t, arg.TurnaroundHours, service.TurnaroundHours)
 
 	require.NotZero(t, service.ServiceID)
 
@@ -83,8 +86,9 @@ func TestUpdateService(t *testing.T) {
 
 	arg := UpdateServiceParams{
 		ServiceID:    service1.ServiceID,
-		ServiceName:  util.RandomUser(),
-		ServicePrice: 683,
+		ServiceName:  sql.NullString{String: util.RandomUser(), Valid: true},
+		ServicePrice: sql.NullInt64{Int64: 683, Valid: true},
+		Version:      service1.Version,
 	}
 
 	res, err := testQueries.UpdateService(context.Background(), arg)
@@ -93,9 +97,135 @@ func TestUpdateService(t *testing.T) {
 	require.NotEmpty(t, res)
 
 	require.Equal(t, service1.ServiceID, res.ServiceID)
-	require.Equal(t, arg.ServiceName, res.ServiceName)
-	require.Equal(t, arg.ServicePrice, res.ServicePrice)
+	require.Equal(t, arg.ServiceName.String, res.ServiceName)
+	require.Equal(t, arg.ServicePrice.Int64, res.ServicePrice)
+	require.Equal(t, service1.TurnaroundHours, res.TurnaroundHours)
+	require.Equal(t, service1.ServiceImage, res.ServiceImage)
+	require.Equal(t, service1.Version+1, res.Version)
 
+	// the version it was based on is gone
+	_, err = testQueries.UpdateService(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpdateServiceTx(t *testing.T) {
+	store := NewStore(testDB)
+	service := createRandomService(t)
+
+	arg := UpdateServiceParams{
+		ServiceID:    service.ServiceID,
+		ServicePrice: sql.NullInt64{Int64: service.ServicePrice + 100, Valid: true},
+		Version:      service.Version,
+	}
+	updated, err := store.UpdateServiceTx(context.Background(), arg, sql.NullInt32{})
+	require.NoError(t, err)
+	require.Equal(t, arg.ServicePrice.Int64, updated.ServicePrice)
+
+	prices, err := testQueries.ListServicePrices(context.Background(), service.ServiceID)
+	require.NoError(t, err)
+	require.NotEmpty(t, prices)
+
+	_, err = store.UpdateServiceTx(context.Background(), arg, sql.NullInt32{})
+	require.ErrorIs(t, err, ErrServiceModified)
+
+	arg.ServiceID = 0
+	_, err = store.UpdateServiceTx(context.Background(), arg, sql.NullInt32{})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestArchiveService(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	service := createRandomService(t)
+
+	archived, err := testQueries.ArchiveService(context.Background(), service.ServiceID)
+	require.NoError(t, err)
+	require.True(t, archived.ArchivedAt.Valid)
+
+	// Archived services still resolve for past orders but are no longer listed or orderable
+	res, err := testQueries.GetService(context.Background(), service.ServiceID)
+	require.NoError(t, err)
+	require.Equal(t, archived.ArchivedAt.Time, res.ArchivedAt.Time)
+
+	services, err := testQueries.ListAllServices(context.Background())
+	require.NoError(t, err)
+	for _, listed := range services {
+		require.NotEqual(t, service.ServiceID, listed.ServiceID)
+	}
+
+	_, err = store.CreateOrderTx(context.Background(), CreateOrderTxParams{
+		OrderID:     util.NewOrderID(),
+		UserID:      user.UserID,
+		BranchID:    defaultBranch(t).BranchID,
+		ServiceIDs:  []int32{service.ServiceID},
+		OrderStatus: util.OrderStatusPending,
+	})
+	require.ErrorIs(t, err, ErrServiceArchived)
+
+	restored, err := testQueries.RestoreService(context.Background(), service.ServiceID)
+	require.NoError(t, err)
+	require.False(t, restored.ArchivedAt.Valid)
+}
+
+func TestServiceImageVariants(t *testing.T) {
+	service := createRandomService(t)
+	image := "https://example.com/services/" + util.RandomString(12) + ".png"
+
+	service, err := testQueries.SetServiceImage(context.Background(), SetServiceImageParams{
+		ServiceID:    service.ServiceID,
+		ServiceImage: image,
+		Version:      service.Version,
+	})
+	require.NoError(t, err)
+
+	// an image for a version that has since changed is not stored
+	_, err = testQueries.SetServiceImage(context.Background(), SetServiceImageParams{
+		ServiceID:    service.ServiceID,
+		ServiceImage: image + ".stale",
+		Version:      service.Version - 1,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	count, err := testQueries.CountServicesWithImage(context.Background(), image)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	arg := SetServiceImageVariantsParams{
+		ServiceID:      service.ServiceID,
+		ServiceImage:   image,
+		ImageThumbnail: image + ".thumbnail.jpg",
+		ImageMedium:    image + ".medium.jpg",
+		ImageWebp:      image + ".webp",
+	}
+	updated, err := testQueries.SetServiceImageVariants(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ImageThumbnail, updated.ImageThumbnail)
+	require.Equal(t, arg.ImageMedium, updated.ImageMedium)
+	require.Equal(t, arg.ImageWebp, updated.ImageWebp)
+
+	// variants made from an image that has since been replaced are not stored
+	stale := arg
+	stale.ServiceImage = image + ".old"
+	_, err = testQueries.SetServiceImageVariants(context.Background(), stale)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	kept, err := testQueries.UpdateService(context.Background(), UpdateServiceParams{
+		ServiceID:    service.ServiceID,
+		ServiceImage: sql.NullString{String: image, Valid: true},
+		Version:      updated.Version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, arg.ImageThumbnail, kept.ImageThumbnail)
+
+	cleared, err := testQueries.SetServiceImage(context.Background(), SetServiceImageParams{
+		ServiceID:    service.ServiceID,
+		ServiceImage: image + ".new",
+		Version:      kept.Version,
+	})
+	require.NoError(t, err)
+	require.Empty(t, cleared.ImageThumbnail)
+	require.Empty(t, cleared.ImageMedium)
+	require.Empty(t, cleared.ImageWebp)
 }
 
 // func TestDeleteService(t *testing.T) {
@@ -111,3 +241,57 @@ func TestUpdateService(t *testing.T) {
 // 	require.EqualError(t, err, sql.ErrNoRows.Error())
 // 	require.Empty(t, res)
 // }
+
+func TestImportServicesTx(t *testing.T) {
+	store := NewStore(testDB)
+	existing := createRandomService(t)
+	admin := createRandomAdmin(t)
+
+	newService := UpsertServiceParams{
+		ServiceName:     util.RandomString(12),
+		ServicePrice:    int64(util.RandomPrice()),
+		TurnaroundHours: 24,
+	}
+	updated := UpsertServiceParams{
+		ServiceName:     existing.ServiceName,
+		ServicePrice:    existing.ServicePrice + 10,
+		TurnaroundHours: 12,
+	}
+	arg := ImportServicesTxParams{
+		Services:   []UpsertServiceParams{newService, updated},
+		DryRun:     true,
+		ImportedBy: sql.NullInt32{Int32: admin.AdminID, Valid: true},
+	}
+
+	// A dry run reports the changes without saving them
+	results, err := store.ImportServicesTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, util.ImportCreated, results[0].Action)
+	require.Equal(t, util.ImportUpdated, results[1].Action)
+
+	_, err = testQueries.GetServiceByName(context.Background(), newService.ServiceName)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	prices, err := testQueries.ListServicePrices(context.Background(), existing.ServiceID)
+	require.NoError(t, err)
+	require.Empty(t, prices)
+
+	arg.DryRun = false
+	results, err = store.ImportServicesTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, existing.ServiceID, results[1].ID)
+
+	// An empty image keeps the image of the existing service
+	service, err := testQueries.GetService(context.Background(), existing.ServiceID)
+	require.NoError(t, err)
+	require.Equal(t, updated.ServicePrice, service.ServicePrice)
+	require.Equal(t, updated.TurnaroundHours, service.TurnaroundHours)
+	require.Equal(t, existing.ServiceImage, service.ServiceImage)
+
+	// The price change is recorded against the importing admin
+	prices, err = testQueries.ListServicePrices(context.Background(), existing.ServiceID)
+	require.NoError(t, err)
+	require.Len(t, prices, 1)
+	require.Equal(t, updated.ServicePrice, prices[0].Price)
+	require.Equal(t, admin.AdminID, prices[0].CreatedBy.Int32)
+}
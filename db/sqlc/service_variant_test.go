@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomServiceVariant(t *testing.T, service Service) ServiceVariant {
+	arg := CreateServiceVariantParams{
+		ServiceID:       service.ServiceID,
+		Name:            util.RandomString(6),
+		Sku:             util.RandomString(10),
+		Price:           int64(util.RandomPrice()),
+		TurnaroundHours: 72,
+	}
+
+	variant, err := testQueries.CreateServiceVariant(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, variant.VariantID)
+	require.Equal(t, arg.ServiceID, variant.ServiceID)
+	require.Equal(t, arg.Sku, variant.Sku)
+	require.Equal(t, arg.Price, variant.Price)
+
+	return variant
+}
+
+func TestCreateOrderTxWithVariant(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	service := createRandomService(t)
+	variant := createRandomServiceVariant(t, service)
+
+	result, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{
+		OrderID:     util.NewOrderID(),
+		UserID:      user.UserID,
+		BranchID:    defaultBranch(t).BranchID,
+		ServiceIDs:  []int32{service.ServiceID},
+		Items:       []OrderItem{{ServiceID: service.ServiceID, VariantID: sql.NullInt32{Int32: variant.VariantID, Valid: true}}},
+		OrderStatus: util.OrderStatusPending,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Orders, 2)
+	require.Len(t, result.Variants, 1)
+
+	// Each row keeps the price it was ordered at, even after the variant price changes
+	prices := map[bool]int64{}
+	for _, order := range result.Orders {
+		prices[order.VariantID.Valid] = order.UnitPrice.Int64
+	}
+	require.Equal(t, service.ServicePrice, prices[false])
+	require.Equal(t, variant.Price, prices[true])
+	require.WithinDuration(t, result.Orders[0].OrderStarted.Add(72*time.Hour), result.Orders[0].DueAt.Time, time.Second)
+
+	_, err = testQueries.UpdateServiceVariant(context.Background(), UpdateServiceVariantParams{
+		VariantID:       variant.VariantID,
+		Name:            variant.Name,
+		Sku:             variant.Sku,
+		Price:           variant.Price + 100,
+		TurnaroundHours: variant.TurnaroundHours,
+	})
+	require.NoError(t, err)
+
+	subtotal, err := testQueries.GetOrderSubtotal(context.Background(), result.Orders[0].OrderID)
+	require.NoError(t, err)
+	require.Equal(t, service.ServicePrice+variant.Price, subtotal)
+
+	// An ordered variant cannot be deleted
+	err = testQueries.DeleteServiceVariant(context.Background(), variant.VariantID)
+	require.Error(t, err)
+}
+
+func TestCreateOrderTxVariantMismatch(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	variant := createRandomServiceVariant(t, createRandomService(t))
+
+	_, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{
+		OrderID:     util.NewOrderID(),
+		UserID:      user.UserID,
+		BranchID:    defaultBranch(t).BranchID,
+		Items:       []OrderItem{{ServiceID: createRandomService(t).ServiceID, VariantID: sql.NullInt32{Int32: variant.VariantID, Valid: true}}},
+		OrderStatus: util.OrderStatusPending,
+	})
+	require.ErrorIs(t, err, ErrVariantMismatch)
+}
@@ -12,6 +12,17 @@ import (
 	"github.com/google/uuid"
 )
 
+const blockSessions = `-- name: BlockSessions :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE email = $1
+`
+
+func (q *Queries) BlockSessions(ctx context.Context, email string) error {
+	_, err := q.db.ExecContext(ctx, blockSessions, email)
+	return err
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
   id,
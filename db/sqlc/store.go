@@ -3,7 +3,14 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
 )
 
 // This Store provides all the functions to execute db queries and transactions
@@ -39,6 +46,1932 @@ func (store *Store) execTx(ctx context.Context, fn func(*Queries) error) error {
 	return tx.Commit()
 }
 
+type IssueInvoiceTxParams struct {
+	OrderID  int64          `json:"order_id"`
+	TaxRates []util.TaxRate `json:"tax_rates"`
+}
+
+type IssueInvoiceTxResult struct {
+	Invoice Invoice       `json:"invoice"`
+	Items   []InvoiceItem `json:"items"`
+	Taxes   []InvoiceTax  `json:"taxes"`
+}
+
+// IssueInvoiceTx creates the invoice for a delivered order in a single DB transaction.
+// The invoice number is taken from the yearly counter inside the same transaction so
+// numbers are never skipped. If the order was already invoiced the existing invoice is returned.
+func (store *Store) IssueInvoiceTx(ctx context.Context, arg IssueInvoiceTxParams) (IssueInvoiceTxResult, error) {
+	var result IssueInvoiceTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Invoice, err = q.GetInvoiceByOrderID(ctx, arg.OrderID)
+		if err == nil {
+			result.Items, err = q.ListInvoiceItems(ctx, result.Invoice.InvoiceID)
+			if err != nil {
+				return err
+			}
+			result.Taxes, err = q.ListInvoiceTaxes(ctx, result.Invoice.InvoiceID)
+			return err
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		orders, err := q.GetOrder(ctx, arg.OrderID)
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return sql.ErrNoRows
+		}
+
+		user, err := q.GetUser(ctx, orders[0].UserID)
+		if err != nil {
+			return err
+		}
+
+		// Every order row is one service, so repeated items at the same price become the quantity of a single line
+		type lineKey struct {
+			serviceID int32
+			variantID int32
+			bundleID  int32
+			price     int64
+		}
+		var lines []CreateInvoiceItemParams
+		lineIndex := make(map[lineKey]int)
+		var subtotal int64
+		for _, order := range orders {
+			service, err := q.GetService(ctx, order.ServiceIds)
+			if err != nil {
+				return err
+			}
+
+			// Orders placed before prices were recorded are billed at the current price
+			description, price := service.ServiceName, service.ServicePrice
+			if order.VariantID.Valid {
+				variant, err := q.GetServiceVariant(ctx, order.VariantID.Int32)
+				if err != nil {
+					return err
+				}
+				description, price = service.ServiceName+" - "+variant.Name, variant.Price
+			}
+			if order.BundleID.Valid {
+				bundle, err := q.GetBundle(ctx, order.BundleID.Int32)
+				if err != nil {
+					return err
+				}
+				description += " (" + bundle.Name + ")"
+			}
+			if order.UnitPrice.Valid {
+				price = order.UnitPrice.Int64
+			}
+
+			subtotal += price
+			key := lineKey{serviceID: service.ServiceID, variantID: order.VariantID.Int32, bundleID: order.BundleID.Int32, price: price}
+			if i, exists := lineIndex[key]; exists {
+				lines[i].Quantity++
+				lines[i].Amount += price
+				continue
+			}
+			lineIndex[key] = len(lines)
+			lines = append(lines, CreateInvoiceItemParams{
+				ServiceID:   service.ServiceID,
+				Description: description,
+				Quantity:    1,
+				UnitPrice:   price,
+				Amount:      price,
+			})
+		}
+
+		var taxTotal int64
+		taxes := make([]CreateInvoiceTaxParams, len(arg.TaxRates))
+		for i, rate := range arg.TaxRates {
+			taxes[i] = CreateInvoiceTaxParams{
+				TaxName:       rate.Name,
+				RateBps:       rate.BasisPoints,
+				TaxableAmount: subtotal,
+				TaxAmount:     util.TaxAmount(subtotal, rate.BasisPoints),
+			}
+			taxTotal += taxes[i].TaxAmount
+		}
+
+		series := util.InvoiceSeries(time.Now())
+		number, err := q.NextInvoiceNumber(ctx, series)
+		if err != nil {
+			return err
+		}
+
+		result.Invoice, err = q.CreateInvoice(ctx, CreateInvoiceParams{
+			InvoiceNumber:   util.FormatInvoiceNumber(series, number),
+			OrderID:         arg.OrderID,
+			UserID:          user.UserID,
+			CustomerName:    user.Name,
+			CustomerEmail:   user.Email,
+			CustomerPhone:   user.Phone,
+			CustomerAddress: user.Address,
+			Subtotal:        subtotal,
+			TaxTotal:        taxTotal,
+			Total:           subtotal + taxTotal,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Items = make([]InvoiceItem, len(lines))
+		for i, line := range lines {
+			line.InvoiceID = result.Invoice.InvoiceID
+			result.Items[i], err = q.CreateInvoiceItem(ctx, line)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Taxes = make([]InvoiceTax, len(taxes))
+		for i, tax := range taxes {
+			tax.InvoiceID = result.Invoice.InvoiceID
+			result.Taxes[i], err = q.CreateInvoiceTax(ctx, tax)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+var (
+	ErrPaymentNotRefundable = errors.New("only captured payments can be refunded")
+	ErrRefundTooLarge       = errors.New("refund exceeds the amount left to refund on the payment")
+)
+
+type RecordPaymentTxParams struct {
+	OrderID    int64         `json:"order_id"`
+	Method     string        `json:"method"`
+	Amount     int64         `json:"amount"`
+	Reference  string        `json:"reference"`
+	Status     string        `json:"status"`
+	RecordedBy sql.NullInt32 `json:"recorded_by"`
+	Provider   string        `json:"provider"`
+}
+
+// RecordPaymentTx records a payment against an existing order in a single DB transaction
+func (store *Store) RecordPaymentTx(ctx context.Context, arg RecordPaymentTxParams) (Payment, error) {
+	var payment Payment
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		orders, err := q.GetOrder(ctx, arg.OrderID)
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return sql.ErrNoRows
+		}
+
+		payment, err = q.CreatePayment(ctx, CreatePaymentParams{
+			OrderID:    arg.OrderID,
+			Method:     arg.Method,
+			Amount:     arg.Amount,
+			Reference:  arg.Reference,
+			Status:     arg.Status,
+			RecordedBy: arg.RecordedBy,
+			Provider:   arg.Provider,
+		})
+		return err
+	})
+
+	return payment, err
+}
+
+type StartOnlinePaymentTxParams struct {
+	OrderID  int64  `json:"order_id"`
+	Provider string `json:"provider"`
+	Amount   int64  `json:"amount"`
+	// CreateIntent registers Amount with the gateway and returns the reference of the intent.
+	// It is only called when there is no pending payment of Amount to continue.
+	CreateIntent func() (string, error) `json:"-"`
+}
+
+type StartOnlinePaymentTxResult struct {
+	Payment Payment `json:"payment"`
+	// Created is false when the pending payment already started for the amount is returned
+	Created bool `json:"created"`
+}
+
+// StartOnlinePaymentTx starts a gateway payment of an order in a single DB transaction. An order has at most
+// one pending gateway payment: the one already started is returned while its amount still matches, otherwise
+// it is superseded by a new one so the customer is never asked to pay the same balance twice.
+// The order is locked, so concurrent requests cannot both start a payment.
+func (store *Store) StartOnlinePaymentTx(ctx context.Context, arg StartOnlinePaymentTxParams) (StartOnlinePaymentTxResult, error) {
+	var result StartOnlinePaymentTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		orders, err := q.GetOrderForUpdate(ctx, arg.OrderID)
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return sql.ErrNoRows
+		}
+
+		pending, err := q.GetPendingPayment(ctx, GetPendingPaymentParams{
+			OrderID:  arg.OrderID,
+			Provider: arg.Provider,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			if pending.Amount == arg.Amount {
+				result.Payment = pending
+				return nil
+			}
+			if err := q.SupersedePayment(ctx, pending.PaymentID); err != nil {
+				return err
+			}
+		}
+
+		reference, err := arg.CreateIntent()
+		if err != nil {
+			return err
+		}
+		result.Payment, err = q.CreatePayment(ctx, CreatePaymentParams{
+			OrderID:   arg.OrderID,
+			Method:    util.PaymentMethodOnline,
+			Amount:    arg.Amount,
+			Reference: reference,
+			Status:    util.PaymentPending,
+			Provider:  arg.Provider,
+		})
+		result.Created = err == nil
+		return err
+	})
+
+	return result, err
+}
+
+type RefundPaymentTxParams struct {
+	PaymentID  int64         `json:"payment_id"`
+	Amount     int64         `json:"amount"`
+	Reference  string        `json:"reference"`
+	RecordedBy sql.NullInt32 `json:"recorded_by"`
+}
+
+// RefundPaymentTx records a refund as a negative entry linked to the original payment.
+// The original payment row is locked so concurrent refunds cannot exceed the amount paid.
+func (store *Store) RefundPaymentTx(ctx context.Context, arg RefundPaymentTxParams) (Payment, error) {
+	var refund Payment
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		payment, err := q.GetPaymentForUpdate(ctx, arg.PaymentID)
+		if err != nil {
+			return err
+		}
+		if payment.RefundOf.Valid || payment.Status != util.PaymentCaptured {
+			return ErrPaymentNotRefundable
+		}
+
+		refunded, err := q.GetRefundedAmount(ctx, sql.NullInt64{Int64: payment.PaymentID, Valid: true})
+		if err != nil {
+			return err
+		}
+		if arg.Amount > payment.Amount-refunded {
+			return ErrRefundTooLarge
+		}
+
+		refund, err = q.CreatePayment(ctx, CreatePaymentParams{
+			OrderID:    payment.OrderID,
+			Method:     payment.Method,
+			Amount:     -arg.Amount,
+			Reference:  arg.Reference,
+			Status:     util.PaymentCaptured,
+			RefundOf:   sql.NullInt64{Int64: payment.PaymentID, Valid: true},
+			RecordedBy: arg.RecordedBy,
+		})
+		return err
+	})
+
+	return refund, err
+}
+
+var (
+	ErrSlotUnavailable     = errors.New("the selected time slot is full or no longer available")
+	ErrInvalidSchedule     = errors.New("delivery slot must start after the pickup slot ends")
+	ErrOrderDelivered      = errors.New("order has already been delivered")
+	ErrVariantMismatch     = errors.New("variant does not belong to the selected service")
+	ErrServiceArchived     = errors.New("service has been archived and can no longer be ordered")
+	ErrBundleInactive      = errors.New("bundle is not available for ordering")
+	ErrDuplicateBundleItem = errors.New("a service can only be listed once in a bundle, set its quantity instead")
+	ErrBranchInactive      = errors.New("branch is not taking orders")
+	ErrServiceUnavailable  = errors.New("service is not offered at the selected branch")
+)
+
+// OrderItem is one service of an order, optionally in one of its variants
+type OrderItem struct {
+	ServiceID int32         `json:"service_id"`
+	VariantID sql.NullInt32 `json:"variant_id"`
+}
+
+type CreateOrderTxParams struct {
+	OrderID        int64         `json:"order_id"`
+	UserID         int32         `json:"user_id"`
+	BranchID       int32         `json:"branch_id"`
+	ServiceIDs     []int32       `json:"service_ids"`
+	Items          []OrderItem   `json:"items"`
+	BundleIDs      []int32       `json:"bundle_ids"`
+	OrderStatus    string        `json:"order_status"`
+	PickupSlotID   sql.NullInt32 `json:"pickup_slot_id"`
+	DeliverySlotID sql.NullInt32 `json:"delivery_slot_id"`
+}
+
+type CreateOrderTxResult struct {
+	// Orders are the rows of the order, Orders[i] is the row of Services[i]
+	Orders   []Order          `json:"orders"`
+	Services []Service        `json:"services"`
+	Variants []ServiceVariant `json:"variants"`
+	Bundles  []Bundle         `json:"bundles"`
+}
+
+// CreateOrderTx creates one order row per service, sets the time it is due and books the chosen
+// pickup and delivery slots in a single DB transaction, so an order is never stored against a slot that is full
+func (store *Store) CreateOrderTx(ctx context.Context, arg CreateOrderTxParams) (CreateOrderTxResult, error) {
+	var result CreateOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = insertOrder(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// insertOrder holds the steps of creating an order so every transaction that creates orders shares them
+func insertOrder(ctx context.Context, q *Queries, arg CreateOrderTxParams) (CreateOrderTxResult, error) {
+	var result CreateOrderTxResult
+
+	items := make([]OrderItem, 0, len(arg.ServiceIDs)+len(arg.Items))
+	for _, serviceID := range arg.ServiceIDs {
+		items = append(items, OrderItem{ServiceID: serviceID})
+	}
+	items = append(items, arg.Items...)
+
+	branch, err := q.GetBranch(ctx, arg.BranchID)
+	if err != nil {
+		return result, err
+	}
+	if !branch.IsActive {
+		return result, ErrBranchInactive
+	}
+
+	lines := make([]orderLine, 0, len(items))
+	for _, item := range items {
+		line, err := priceOrderItem(ctx, q, item, arg.BranchID)
+		if err != nil {
+			return result, err
+		}
+		if line.variant != nil {
+			result.Variants = append(result.Variants, *line.variant)
+		}
+		lines = append(lines, line)
+	}
+
+	for _, bundleID := range arg.BundleIDs {
+		bundle, bundleLines, err := priceBundle(ctx, q, bundleID, arg.BranchID)
+		if err != nil {
+			return result, err
+		}
+		result.Bundles = append(result.Bundles, bundle)
+		lines = append(lines, bundleLines...)
+	}
+
+	var turnaround int32
+	for _, line := range lines {
+		order, err := q.CreateOrder(ctx, CreateOrderParams{
+			OrderID:     arg.OrderID,
+			UserID:      arg.UserID,
+			ServiceIds:  line.service.ServiceID,
+			OrderStatus: arg.OrderStatus,
+			VariantID:   line.item.VariantID,
+			UnitPrice:   sql.NullInt64{Int64: line.price, Valid: true},
+			BundleID:    line.bundleID,
+			BranchID:    arg.BranchID,
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Orders = append(result.Orders, order)
+		result.Services = append(result.Services, line.service)
+
+		// The order is due once its slowest item is done
+		if line.hours > turnaround {
+			turnaround = line.hours
+		}
+	}
+	dueAt := result.Orders[0].OrderStarted.Add(time.Duration(turnaround) * time.Hour)
+	result.Orders, err = q.SetOrderDueAt(ctx, SetOrderDueAtParams{
+		OrderID: arg.OrderID,
+		DueAt:   sql.NullTime{Time: dueAt, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+	sortOrderRows(result.Orders)
+
+	if !arg.PickupSlotID.Valid && !arg.DeliverySlotID.Valid {
+		return result, nil
+	}
+
+	var pickup, delivery TimeSlot
+	if arg.PickupSlotID.Valid {
+		pickup, err = reserveTimeSlot(ctx, q, arg.PickupSlotID.Int32, util.SlotPickup)
+		if err != nil {
+			return result, err
+		}
+	}
+	if arg.DeliverySlotID.Valid {
+		delivery, err = reserveTimeSlot(ctx, q, arg.DeliverySlotID.Int32, util.SlotDelivery)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	result.Orders, err = scheduleOrder(ctx, q, arg.OrderID, pickup, delivery, result.Orders[0].OrderDeliveryTime)
+	sortOrderRows(result.Orders)
+	return result, err
+}
+
+// sortOrderRows puts the rows of an order back in the order they were created, which
+// the rows returned by an UPDATE do not follow
+func sortOrderRows(orders []Order) {
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+}
+
+// orderLine is one row of an order with the price and turnaround it is ordered at
+type orderLine struct {
+	item     OrderItem
+	service  Service
+	variant  *ServiceVariant
+	bundleID sql.NullInt32
+	price    int64
+	hours    int32
+}
+
+// priceOrderItem resolves the price in effect at a branch and the turnaround of an item, those of a variant replace the ones of its service
+func priceOrderItem(ctx context.Context, q *Queries, item OrderItem, branchID int32) (orderLine, error) {
+	line := orderLine{item: item}
+
+	var err error
+	line.service, err = q.GetService(ctx, item.ServiceID)
+	if err != nil {
+		return line, err
+	}
+	if line.service.ArchivedAt.Valid {
+		return line, ErrServiceArchived
+	}
+	line.hours = line.service.TurnaroundHours
+
+	override, err := q.GetServiceBranch(ctx, GetServiceBranchParams{
+		ServiceID: line.service.ServiceID,
+		BranchID:  branchID,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		return line, err
+	}
+	if err == nil && !override.IsAvailable {
+		return line, ErrServiceUnavailable
+	}
+
+	if item.VariantID.Valid {
+		variant, err := q.GetServiceVariant(ctx, item.VariantID.Int32)
+		if err != nil {
+			return line, err
+		}
+		if variant.ServiceID != line.service.ServiceID {
+			return line, ErrVariantMismatch
+		}
+		line.variant = &variant
+		line.price, line.hours = variant.Price, variant.TurnaroundHours
+		return line, nil
+	}
+
+	// A branch price replaces the price of the service, scheduled changes included
+	if override.Price.Valid {
+		line.price = override.Price.Int64
+		return line, nil
+	}
+
+	// Scheduled price changes may not have been applied to the service yet
+	line.price, err = q.GetEffectiveServicePrice(ctx, GetEffectiveServicePriceParams{
+		At:        time.Now(),
+		ServiceID: line.service.ServiceID,
+	})
+	return line, err
+}
+
+// priceBundle expands a bundle into one line per unit of its services and spreads the bundle price
+// over them in proportion to their own prices, so the discount is shared by every line
+func priceBundle(ctx context.Context, q *Queries, bundleID int32, branchID int32) (Bundle, []orderLine, error) {
+	bundle, err := q.GetBundle(ctx, bundleID)
+	if err != nil {
+		return bundle, nil, err
+	}
+	if !bundle.IsActive {
+		return bundle, nil, ErrBundleInactive
+	}
+
+	items, err := q.ListBundleItems(ctx, bundleID)
+	if err != nil {
+		return bundle, nil, err
+	}
+	if len(items) == 0 {
+		return bundle, nil, ErrBundleInactive
+	}
+
+	var lines []orderLine
+	var weights []int64
+	for _, item := range items {
+		line, err := priceOrderItem(ctx, q, OrderItem{ServiceID: item.ServiceID}, branchID)
+		if err != nil {
+			return bundle, nil, err
+		}
+		line.bundleID = sql.NullInt32{Int32: bundle.BundleID, Valid: true}
+		for i := int32(0); i < item.Quantity; i++ {
+			lines = append(lines, line)
+			weights = append(weights, line.price)
+		}
+	}
+
+	for i, share := range util.SpreadAmount(bundle.Price, weights) {
+		lines[i].price = share
+	}
+	return bundle, lines, nil
+}
+
+type UpdateOrderDeliveryTxResult struct {
+	Order Order `json:"order"`
+	// Delivered is true when the update moved the order from not delivered to delivered
+	Delivered bool `json:"delivered"`
+}
+
+// UpdateOrderDeliveryTx updates the delivery of an order in a single DB transaction and tells whether
+// it is the update that delivered it, so what follows a delivery happens once per order
+func (store *Store) UpdateOrderDeliveryTx(ctx context.Context, arg UpdateOrderDeliveryParams) (UpdateOrderDeliveryTxResult, error) {
+	var result UpdateOrderDeliveryTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		orders, err := q.GetOrderForUpdate(ctx, arg.OrderID)
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return sql.ErrNoRows
+		}
+
+		result.Order, err = q.UpdateOrderDelivery(ctx, arg)
+		if err != nil {
+			return err
+		}
+		result.Delivered = !orders[0].OrderDelivered && result.Order.OrderDelivered
+		return nil
+	})
+
+	return result, err
+}
+
+type RescheduleOrderTxParams struct {
+	OrderID        int64         `json:"order_id"`
+	PickupSlotID   sql.NullInt32 `json:"pickup_slot_id"`
+	DeliverySlotID sql.NullInt32 `json:"delivery_slot_id"`
+	ChangedBy      sql.NullInt32 `json:"changed_by"`
+}
+
+type RescheduleOrderTxResult struct {
+	Orders  []Order      `json:"orders"`
+	History OrderHistory `json:"history"`
+}
+
+// RescheduleOrderTx moves an order to new pickup and/or delivery slots in a single DB transaction.
+// The previous slots are released, the new ones booked and the change is recorded in the order history.
+func (store *Store) RescheduleOrderTx(ctx context.Context, arg RescheduleOrderTxParams) (RescheduleOrderTxResult, error) {
+	var result RescheduleOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		orders, err := q.GetOrderForUpdate(ctx, arg.OrderID)
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return sql.ErrNoRows
+		}
+		current := orders[0]
+		if current.OrderDelivered {
+			return ErrOrderDelivered
+		}
+
+		pickup, err := moveTimeSlot(ctx, q, current.PickupSlotID, arg.PickupSlotID, util.SlotPickup)
+		if err != nil {
+			return err
+		}
+		delivery, err := moveTimeSlot(ctx, q, current.DeliverySlotID, arg.DeliverySlotID, util.SlotDelivery)
+		if err != nil {
+			return err
+		}
+
+		result.Orders, err = scheduleOrder(ctx, q, arg.OrderID, pickup, delivery, current.OrderDeliveryTime)
+		if err != nil {
+			return err
+		}
+
+		details, err := json.Marshal(map[string]interface{}{
+			"pickup_slot_id":   nullableChange(current.PickupSlotID, result.Orders[0].PickupSlotID),
+			"delivery_slot_id": nullableChange(current.DeliverySlotID, result.Orders[0].DeliverySlotID),
+		})
+		if err != nil {
+			return err
+		}
+
+		result.History, err = q.CreateOrderHistory(ctx, CreateOrderHistoryParams{
+			OrderID:   arg.OrderID,
+			Event:     util.OrderEventRescheduled,
+			Details:   details,
+			ChangedBy: arg.ChangedBy,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+var (
+	ErrOrderAlreadyAssigned = errors.New("order is already assigned, reassign it instead")
+	ErrOrderNotAssigned     = errors.New("order is not assigned to anyone")
+	ErrUnknownStrategy      = errors.New("unknown assignment strategy")
+	ErrStaffNotFound        = errors.New("no staff member found to assign the order to")
+	ErrStaffNotInBranch     = errors.New("staff member does not work at the branch of the order")
+)
+
+type AssignOrderTxParams struct {
+	OrderID int64 `json:"order_id"`
+	// AdminID is the staff member to assign, it is ignored when a Strategy is given
+	// and an invalid AdminID without a Strategy unassigns the order
+	AdminID   sql.NullInt32 `json:"admin_id"`
+	Strategy  string        `json:"strategy"`
+	Reassign  bool          `json:"reassign"`
+	ChangedBy sql.NullInt32 `json:"changed_by"`
+}
+
+type AssignOrderTxResult struct {
+	Orders  []Order      `json:"orders"`
+	History OrderHistory `json:"history"`
+}
+
+// AssignOrderTx assigns, reassigns or unassigns an order in a single DB transaction and
+// records the change in the order history. Nothing is recorded when the assignee does not change.
+func (store *Store) AssignOrderTx(ctx context.Context, arg AssignOrderTxParams) (AssignOrderTxResult, error) {
+	var result AssignOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = assignStaff(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// assignStaff applies an assignment inside the caller's transaction
+func assignStaff(ctx context.Context, q *Queries, arg AssignOrderTxParams) (AssignOrderTxResult, error) {
+	var result AssignOrderTxResult
+
+	orders, err := q.GetOrderForUpdate(ctx, arg.OrderID)
+	if err != nil {
+		return result, err
+	}
+	if len(orders) == 0 {
+		return result, sql.ErrNoRows
+	}
+	current := orders[0]
+	if current.OrderDelivered {
+		return result, ErrOrderDelivered
+	}
+
+	assignee := arg.AdminID
+	switch arg.Strategy {
+	case "":
+		if assignee.Valid {
+			var admin Admin
+			admin, err = q.GetAdmin(ctx, assignee.Int32)
+			if err == nil && admin.ArchivedAt.Valid {
+				err = sql.ErrNoRows
+			}
+			if err == nil {
+				err = checkStaffBranch(ctx, q, admin.AdminID, current.BranchID)
+			}
+		}
+	case util.AssignRoundRobin:
+		// Automatic assignments in a branch take turns until their transaction ends,
+		// otherwise concurrent ones would all pick the same staff member
+		if err = q.LockBranchAssignment(ctx, current.BranchID); err != nil {
+			return result, err
+		}
+		assignee.Int32, err = q.GetRoundRobinAdmin(ctx, current.BranchID)
+		assignee.Valid = true
+	case util.AssignLeastLoaded:
+		if err = q.LockBranchAssignment(ctx, current.BranchID); err != nil {
+			return result, err
+		}
+		assignee.Int32, err = q.GetLeastLoadedAdmin(ctx, current.BranchID)
+		assignee.Valid = true
+	default:
+		return result, ErrUnknownStrategy
+	}
+	if err == sql.ErrNoRows {
+		return result, ErrStaffNotFound
+	}
+	if err != nil {
+		return result, err
+	}
+
+	var event string
+	switch {
+	case !assignee.Valid && !current.AssignedTo.Valid:
+		return result, ErrOrderNotAssigned
+	case !assignee.Valid:
+		event = util.OrderEventUnassigned
+	case !current.AssignedTo.Valid:
+		event = util.OrderEventAssigned
+	case !arg.Reassign:
+		return result, ErrOrderAlreadyAssigned
+	default:
+		event = util.OrderEventReassigned
+	}
+
+	if assignee == current.AssignedTo {
+		result.Orders = orders
+		return result, nil
+	}
+
+	assignedAt := sql.NullTime{Time: time.Now(), Valid: assignee.Valid}
+	result.Orders, err = q.AssignOrder(ctx, AssignOrderParams{
+		OrderID:    arg.OrderID,
+		AssignedTo: assignee,
+		AssignedAt: assignedAt,
+		ModifiedBy: arg.ChangedBy,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	details, err := json.Marshal(map[string]interface{}{
+		"assigned_to": nullableChange(current.AssignedTo, assignee),
+		"strategy":    arg.Strategy,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.History, err = q.CreateOrderHistory(ctx, CreateOrderHistoryParams{
+		OrderID:   arg.OrderID,
+		Event:     event,
+		Details:   details,
+		ChangedBy: arg.ChangedBy,
+	})
+	return result, err
+}
+
+// checkStaffBranch returns ErrStaffNotInBranch when the admin is limited to branches other than the given one
+func checkStaffBranch(ctx context.Context, q *Queries, adminID int32, branchID int32) error {
+	branchIDs, err := q.ListAdminBranchIDs(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if len(branchIDs) == 0 {
+		return nil
+	}
+	for _, id := range branchIDs {
+		if id == branchID {
+			return nil
+		}
+	}
+	return ErrStaffNotInBranch
+}
+
+var (
+	ErrInvalidTransition = errors.New("order cannot move to the requested status")
+	ErrBatchRejected     = errors.New("no order was changed, some orders in the batch failed")
+	// ErrOrderNotApplied is the result of an order that could be changed in a batch that was rejected
+	ErrOrderNotApplied  = errors.New("not applied, other orders in the batch failed")
+	ErrBulkStatusAction = errors.New("use the deliver or cancel action to deliver or cancel orders")
+)
+
+// Actions a bulk order update can apply
+const (
+	BulkActionStatus  = "status"
+	BulkActionDeliver = "deliver"
+	BulkActionAssign  = "assign"
+	BulkActionCancel  = "cancel"
+)
+
+type FlagSLATxParams struct {
+	Now          time.Time `json:"now"`
+	AtRiskBefore time.Time `json:"at_risk_before"`
+	// Notify is called with the orders that changed state before the change is committed
+	Notify func(atRisk, overdue []Order) error `json:"-"`
+}
+
+// FlagSLATx moves open orders to the at risk and overdue states in a single DB transaction.
+// The change is rolled back when Notify fails, so the orders are flagged again on the next check.
+func (store *Store) FlagSLATx(ctx context.Context, arg FlagSLATxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		atRisk, err := q.FlagAtRiskOrders(ctx, FlagAtRiskOrdersParams{
+			Now:          arg.Now,
+			AtRiskBefore: arg.AtRiskBefore,
+		})
+		if err != nil {
+			return err
+		}
+
+		overdue, err := q.FlagOverdueOrders(ctx, arg.Now)
+		if err != nil {
+			return err
+		}
+
+		return arg.Notify(atRisk, overdue)
+	})
+}
+
+type BulkUpdateOrdersTxParams struct {
+	OrderIDs []int64 `json:"order_ids"`
+	Action   string  `json:"action"`
+	// Status is the new status for the status action
+	Status string `json:"status"`
+	// AdminID and Strategy pick the staff member for the assign action, as in AssignOrderTx
+	AdminID   sql.NullInt32 `json:"admin_id"`
+	Strategy  string        `json:"strategy"`
+	ChangedBy sql.NullInt32 `json:"changed_by"`
+	// BranchIDs limits the batch to orders of these branches, orders of other branches fail with
+	// ErrOrderNotInScope. An empty list stands for every branch.
+	BranchIDs []int32 `json:"branch_ids"`
+}
+
+// ErrOrderNotInScope is the result of an order of a branch a bulk update is not allowed to change
+var ErrOrderNotInScope = errors.New("order belongs to a branch you do not work at")
+
+type BulkOrderResult struct {
+	OrderID int64   `json:"order_id"`
+	Success bool    `json:"success"`
+	Error   string  `json:"error,omitempty"`
+	Orders  []Order `json:"orders,omitempty"`
+}
+
+type BulkUpdateOrdersTxResult struct {
+	Results []BulkOrderResult `json:"results"`
+}
+
+// BulkUpdateOrdersTx applies the same action to every order in a single DB transaction. Every
+// order is checked and gets its own result, but the batch is only committed when all of them
+// succeed, otherwise ErrBatchRejected is returned with the results telling which orders failed
+// and the other orders marked as not applied.
+func (store *Store) BulkUpdateOrdersTx(ctx context.Context, arg BulkUpdateOrdersTxParams) (BulkUpdateOrdersTxResult, error) {
+	var result BulkUpdateOrdersTxResult
+
+	// Delivering and cancelling do more than change the status, such as
+	// recording the delivery time or releasing the time slots
+	if arg.Action == BulkActionStatus && (arg.Status == util.OrderStatusDelivered || arg.Status == util.OrderStatusCancelled) {
+		return result, ErrBulkStatusAction
+	}
+
+	// Orders are locked in a fixed order so two overlapping batches cannot deadlock
+	orderIDs := make([]int64, 0, len(arg.OrderIDs))
+	seen := make(map[int64]bool)
+	for _, orderID := range arg.OrderIDs {
+		if !seen[orderID] {
+			seen[orderID] = true
+			orderIDs = append(orderIDs, orderID)
+		}
+	}
+	sort.Slice(orderIDs, func(i, j int) bool { return orderIDs[i] < orderIDs[j] })
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result.Results = make([]BulkOrderResult, 0, len(orderIDs))
+		failed := false
+		for _, orderID := range orderIDs {
+			orders, err := bulkUpdateOrder(ctx, q, orderID, arg)
+			if err != nil && !isOrderError(err) {
+				return err
+			}
+
+			res := BulkOrderResult{OrderID: orderID, Success: err == nil, Orders: orders}
+			switch {
+			case err == sql.ErrNoRows:
+				res.Error = "order not found"
+			case err != nil:
+				res.Error = err.Error()
+			}
+			failed = failed || err != nil
+			result.Results = append(result.Results, res)
+		}
+
+		if failed {
+			for i := range result.Results {
+				if result.Results[i].Success {
+					result.Results[i] = BulkOrderResult{
+						OrderID: result.Results[i].OrderID,
+						Error:   ErrOrderNotApplied.Error(),
+					}
+				}
+			}
+			return ErrBatchRejected
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// bulkUpdateOrder applies the action of a bulk update to a single order
+func bulkUpdateOrder(ctx context.Context, q *Queries, orderID int64, arg BulkUpdateOrdersTxParams) ([]Order, error) {
+	if len(arg.BranchIDs) > 0 {
+		orders, err := q.GetOrderForUpdate(ctx, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if len(orders) == 0 {
+			return nil, sql.ErrNoRows
+		}
+		inScope := false
+		for _, branchID := range arg.BranchIDs {
+			inScope = inScope || branchID == orders[0].BranchID
+		}
+		if !inScope {
+			return nil, ErrOrderNotInScope
+		}
+	}
+
+	if arg.Action == BulkActionAssign {
+		res, err := assignStaff(ctx, q, AssignOrderTxParams{
+			OrderID:   orderID,
+			AdminID:   arg.AdminID,
+			Strategy:  arg.Strategy,
+			Reassign:  true,
+			ChangedBy: arg.ChangedBy,
+		})
+		return res.Orders, err
+	}
+
+	orders, err := q.GetOrderForUpdate(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	current := orders[0]
+
+	var status, event string
+	switch arg.Action {
+	case BulkActionStatus:
+		status, event = arg.Status, util.OrderEventStatusChanged
+	case BulkActionDeliver:
+		status, event = util.OrderStatusDelivered, util.OrderEventDelivered
+	case BulkActionCancel:
+		status, event = util.OrderStatusCancelled, util.OrderEventCancelled
+	default:
+		return nil, fmt.Errorf("unknown bulk action %q", arg.Action)
+	}
+
+	if current.OrderDelivered {
+		return nil, ErrOrderDelivered
+	}
+	if !util.CanTransitionOrder(current.OrderStatus, status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, current.OrderStatus, status)
+	}
+
+	details := map[string]interface{}{
+		"order_status": map[string]string{"from": current.OrderStatus, "to": status},
+	}
+
+	if arg.Action == BulkActionCancel {
+		// A cancelled order gives its pickup and delivery slots back
+		var released []int32
+		for _, slotID := range []sql.NullInt32{current.PickupSlotID, current.DeliverySlotID} {
+			if !slotID.Valid {
+				continue
+			}
+			if err := q.ReleaseTimeSlot(ctx, slotID.Int32); err != nil {
+				return nil, err
+			}
+			released = append(released, slotID.Int32)
+		}
+		if len(released) > 0 {
+			_, err = q.UpdateOrderSlots(ctx, UpdateOrderSlotsParams{
+				OrderID:           orderID,
+				OrderDeliveryTime: current.OrderDeliveryTime,
+			})
+			if err != nil {
+				return nil, err
+			}
+			details["released_slots"] = released
+		}
+	}
+
+	if arg.Action == BulkActionDeliver {
+		orders, err = q.MarkOrderDelivered(ctx, MarkOrderDeliveredParams{
+			OrderID:           orderID,
+			OrderStatus:       status,
+			OrderDeliveryTime: time.Now(),
+			ModifiedBy:        arg.ChangedBy,
+		})
+	} else {
+		orders, err = q.SetOrderStatus(ctx, SetOrderStatusParams{
+			OrderID:     orderID,
+			OrderStatus: status,
+			ModifiedBy:  arg.ChangedBy,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+	_, err = q.CreateOrderHistory(ctx, CreateOrderHistoryParams{
+		OrderID:   orderID,
+		Event:     event,
+		Details:   encoded,
+		ChangedBy: arg.ChangedBy,
+	})
+	return orders, err
+}
+
+// isOrderError reports whether err is caused by the state of a single order rather than by the database
+func isOrderError(err error) bool {
+	for _, target := range []error{
+		sql.ErrNoRows, ErrOrderDelivered, ErrInvalidTransition, ErrOrderAlreadyAssigned,
+		ErrOrderNotAssigned, ErrUnknownStrategy, ErrStaffNotFound, ErrStaffNotInBranch, ErrOrderNotInScope,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrSubscriptionNotDue    = errors.New("subscription is not due to run")
+	ErrSubscriptionCancelled = errors.New("subscription has been cancelled")
+	ErrSubscriptionEmpty     = errors.New("subscription has no services")
+)
+
+type CreateSubscriptionTxParams struct {
+	UserID     int32     `json:"user_id"`
+	BranchID   int32     `json:"branch_id"`
+	Frequency  string    `json:"frequency"`
+	CronExpr   string    `json:"cron_expr"`
+	NextRunAt  time.Time `json:"next_run_at"`
+	ServiceIDs []int32   `json:"service_ids"`
+	// AnchorAt sets the day of the month monthly subscriptions run on
+	AnchorAt time.Time `json:"anchor_at"`
+}
+
+type SubscriptionTxResult struct {
+	Subscription Subscription          `json:"subscription"`
+	Services     []SubscriptionService `json:"services"`
+}
+
+// CreateSubscriptionTx creates a subscription with its services in a single DB transaction.
+// A service listed more than once is stored once with its quantity.
+func (store *Store) CreateSubscriptionTx(ctx context.Context, arg CreateSubscriptionTxParams) (SubscriptionTxResult, error) {
+	var result SubscriptionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Subscription, err = q.CreateSubscription(ctx, CreateSubscriptionParams{
+			UserID:    arg.UserID,
+			Frequency: arg.Frequency,
+			CronExpr:  arg.CronExpr,
+			NextRunAt: arg.NextRunAt,
+			BranchID:  arg.BranchID,
+			AnchorAt:  arg.AnchorAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		var serviceIDs []int32
+		quantity := make(map[int32]int32)
+		for _, serviceID := range arg.ServiceIDs {
+			if quantity[serviceID] == 0 {
+				serviceIDs = append(serviceIDs, serviceID)
+			}
+			quantity[serviceID]++
+		}
+
+		for _, serviceID := range serviceIDs {
+			// Services that do not exist are rejected by the foreign key
+			service, err := q.GetService(ctx, serviceID)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			if service.ArchivedAt.Valid {
+				return ErrServiceArchived
+			}
+
+			subscriptionService, err := q.AddSubscriptionService(ctx, AddSubscriptionServiceParams{
+				SubscriptionID: result.Subscription.SubscriptionID,
+				ServiceID:      serviceID,
+				Quantity:       quantity[serviceID],
+			})
+			if err != nil {
+				return err
+			}
+			result.Services = append(result.Services, subscriptionService)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+type RunSubscriptionTxParams struct {
+	SubscriptionID int64          `json:"subscription_id"`
+	Now            time.Time      `json:"now"`
+	Location       *time.Location `json:"-"`
+}
+
+type RunSubscriptionTxResult struct {
+	Subscription Subscription        `json:"subscription"`
+	Order        CreateOrderTxResult `json:"order"`
+	Run          SubscriptionRun     `json:"run"`
+}
+
+// RunSubscriptionTx creates the order for a due subscription through the same steps as CreateOrderTx
+// and moves the subscription on to its next run in a single DB transaction, so a run never creates
+// more than one order even when several schedulers pick it up
+func (store *Store) RunSubscriptionTx(ctx context.Context, arg RunSubscriptionTxParams) (RunSubscriptionTxResult, error) {
+	var result RunSubscriptionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		sub, err := q.GetSubscriptionForUpdate(ctx, arg.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if sub.Status != util.SubscriptionActive || sub.NextRunAt.After(arg.Now) {
+			return ErrSubscriptionNotDue
+		}
+
+		services, err := q.ListSubscriptionServices(ctx, sub.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		var serviceIDs []int32
+		for _, service := range services {
+			for i := int32(0); i < service.Quantity; i++ {
+				serviceIDs = append(serviceIDs, service.ServiceID)
+			}
+		}
+		if len(serviceIDs) == 0 {
+			return ErrSubscriptionEmpty
+		}
+
+		result.Order, err = insertOrder(ctx, q, CreateOrderTxParams{
+			OrderID:     util.NewOrderID(),
+			UserID:      sub.UserID,
+			BranchID:    sub.BranchID,
+			ServiceIDs:  serviceIDs,
+			OrderStatus: util.OrderStatusStarted,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Run, err = q.CreateSubscriptionRun(ctx, CreateSubscriptionRunParams{
+			SubscriptionID: sub.SubscriptionID,
+			ScheduledFor:   sub.NextRunAt,
+			Status:         util.SubscriptionRunCreated,
+			OrderID:        sql.NullInt64{Int64: result.Order.Orders[0].OrderID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		next, err := followingRun(sub, arg.Now, arg.Location)
+		if err != nil {
+			return err
+		}
+		result.Subscription, err = q.UpdateSubscriptionSchedule(ctx, UpdateSubscriptionScheduleParams{
+			SubscriptionID: sub.SubscriptionID,
+			NextRunAt:      next,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+type RecordSubscriptionFailureTxParams struct {
+	SubscriptionID int64          `json:"subscription_id"`
+	Error          string         `json:"error"`
+	MaxAttempts    int32          `json:"max_attempts"`
+	Now            time.Time      `json:"now"`
+	Location       *time.Location `json:"-"`
+}
+
+type RecordSubscriptionFailureTxResult struct {
+	Subscription Subscription    `json:"subscription"`
+	Run          SubscriptionRun `json:"run"`
+	// GaveUp is set when the run failed MaxAttempts times and was recorded as failed
+	GaveUp bool `json:"gave_up"`
+}
+
+// RecordSubscriptionFailureTx counts a failed attempt at the current run. The run is retried on
+// the next pass of the scheduler until it has failed MaxAttempts times, then it is recorded as
+// failed and the subscription moves on to its next run.
+func (store *Store) RecordSubscriptionFailureTx(ctx context.Context, arg RecordSubscriptionFailureTxParams) (RecordSubscriptionFailureTxResult, error) {
+	var result RecordSubscriptionFailureTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		sub, err := q.GetSubscriptionForUpdate(ctx, arg.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if sub.Status != util.SubscriptionActive {
+			return ErrSubscriptionNotDue
+		}
+
+		attempts := sub.FailureCount + 1
+		if attempts < arg.MaxAttempts {
+			result.Subscription, err = q.UpdateSubscriptionSchedule(ctx, UpdateSubscriptionScheduleParams{
+				SubscriptionID: sub.SubscriptionID,
+				NextRunAt:      sub.NextRunAt,
+				FailureCount:   attempts,
+				LastError:      arg.Error,
+			})
+			return err
+		}
+
+		result.GaveUp = true
+		result.Run, err = q.CreateSubscriptionRun(ctx, CreateSubscriptionRunParams{
+			SubscriptionID: sub.SubscriptionID,
+			ScheduledFor:   sub.NextRunAt,
+			Status:         util.SubscriptionRunFailed,
+			Error:          arg.Error,
+		})
+		if err != nil {
+			return err
+		}
+
+		next, err := followingRun(sub, arg.Now, arg.Location)
+		if err != nil {
+			return err
+		}
+		result.Subscription, err = q.UpdateSubscriptionSchedule(ctx, UpdateSubscriptionScheduleParams{
+			SubscriptionID: sub.SubscriptionID,
+			NextRunAt:      next,
+			LastError:      arg.Error,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+type SkipSubscriptionRunTxParams struct {
+	SubscriptionID int64          `json:"subscription_id"`
+	Location       *time.Location `json:"-"`
+}
+
+type SkipSubscriptionRunTxResult struct {
+	Subscription Subscription    `json:"subscription"`
+	Run          SubscriptionRun `json:"run"`
+}
+
+// SkipSubscriptionRunTx records the upcoming run as skipped and moves the subscription on to the run after it
+func (store *Store) SkipSubscriptionRunTx(ctx context.Context, arg SkipSubscriptionRunTxParams) (SkipSubscriptionRunTxResult, error) {
+	var result SkipSubscriptionRunTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		sub, err := q.GetSubscriptionForUpdate(ctx, arg.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if sub.Status == util.SubscriptionCancelled {
+			return ErrSubscriptionCancelled
+		}
+
+		result.Run, err = q.CreateSubscriptionRun(ctx, CreateSubscriptionRunParams{
+			SubscriptionID: sub.SubscriptionID,
+			ScheduledFor:   sub.NextRunAt,
+			Status:         util.SubscriptionRunSkipped,
+		})
+		if err != nil {
+			return err
+		}
+
+		next, err := util.NextRun(sub.Frequency, sub.CronExpr, sub.NextRunAt, sub.AnchorAt, arg.Location)
+		if err != nil {
+			return err
+		}
+		result.Subscription, err = q.UpdateSubscriptionSchedule(ctx, UpdateSubscriptionScheduleParams{
+			SubscriptionID: sub.SubscriptionID,
+			NextRunAt:      next,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+type SetSubscriptionStatusTxParams struct {
+	SubscriptionID int64          `json:"subscription_id"`
+	Status         string         `json:"status"`
+	Now            time.Time      `json:"now"`
+	Location       *time.Location `json:"-"`
+}
+
+// SetSubscriptionStatusTx pauses, resumes or cancels a subscription. Runs that were missed
+// while it was paused are not made up for, a resumed subscription continues with its next future run.
+func (store *Store) SetSubscriptionStatusTx(ctx context.Context, arg SetSubscriptionStatusTxParams) (Subscription, error) {
+	var sub Subscription
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		sub, err = q.GetSubscriptionForUpdate(ctx, arg.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if sub.Status == util.SubscriptionCancelled {
+			return ErrSubscriptionCancelled
+		}
+
+		next := sub.NextRunAt
+		if arg.Status == util.SubscriptionActive && !next.After(arg.Now) {
+			next, err = followingRun(sub, arg.Now, arg.Location)
+			if err != nil {
+				return err
+			}
+		}
+
+		sub, err = q.UpdateSubscriptionStatus(ctx, UpdateSubscriptionStatusParams{
+			SubscriptionID: sub.SubscriptionID,
+			Status:         arg.Status,
+			NextRunAt:      next,
+		})
+		return err
+	})
+
+	return sub, err
+}
+
+// followingRun returns the first run of the subscription after now. Runs missed while the
+// scheduler was down are skipped rather than creating a burst of orders.
+func followingRun(sub Subscription, now time.Time, loc *time.Location) (time.Time, error) {
+	next := sub.NextRunAt
+	for !next.After(now) {
+		var err error
+		next, err = util.NextRun(sub.Frequency, sub.CronExpr, next, sub.AnchorAt, loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return next, nil
+}
+
+// errDryRun rolls back an import that was only run to see what it would do
+var errDryRun = errors.New("dry run")
+
+type ImportRowResult struct {
+	// Action is util.ImportCreated or util.ImportUpdated
+	Action string `json:"action"`
+	ID     int32  `json:"id"`
+}
+
+type ImportUsersTxParams struct {
+	Users  []UpsertUserParams `json:"users"`
+	DryRun bool               `json:"dry_run"`
+}
+
+// ImportUsersTx creates or updates users by email in a single DB transaction. Existing users
+// keep their password, only their name, phone and address are updated. A dry run reports
+// what would happen and rolls everything back.
+func (store *Store) ImportUsersTx(ctx context.Context, arg ImportUsersTxParams) ([]ImportRowResult, error) {
+	var results []ImportRowResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		results = make([]ImportRowResult, 0, len(arg.Users))
+		for _, user := range arg.Users {
+			_, err := q.GetUserByEmail(ctx, user.Email)
+			action, err := importAction(err)
+			if err != nil {
+				return err
+			}
+
+			upserted, err := q.UpsertUser(ctx, user)
+			if err != nil {
+				return err
+			}
+			results = append(results, ImportRowResult{Action: action, ID: upserted.UserID})
+		}
+
+		if arg.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err == errDryRun {
+		err = nil
+	}
+
+	return results, err
+}
+
+type ImportServicesTxParams struct {
+	// An empty ServiceImage keeps the image of an existing service
+	Services []UpsertServiceParams `json:"services"`
+	DryRun   bool                  `json:"dry_run"`
+	// ImportedBy is recorded on the price changes the import makes
+	ImportedBy sql.NullInt32 `json:"imported_by"`
+}
+
+// ImportServicesTx creates or updates services by name in a single DB transaction.
+// A dry run reports what would happen and rolls everything back.
+func (store *Store) ImportServicesTx(ctx context.Context, arg ImportServicesTxParams) ([]ImportRowResult, error) {
+	var results []ImportRowResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		results = make([]ImportRowResult, 0, len(arg.Services))
+		for _, service := range arg.Services {
+			existing, err := q.GetServiceByName(ctx, service.ServiceName)
+			action, err := importAction(err)
+			if err != nil {
+				return err
+			}
+
+			upserted, err := q.UpsertService(ctx, service)
+			if err != nil {
+				return err
+			}
+			if action == util.ImportUpdated {
+				err = recordServicePrice(ctx, q, upserted, existing.ServicePrice, arg.ImportedBy)
+				if err != nil {
+					return err
+				}
+			}
+			results = append(results, ImportRowResult{Action: action, ID: upserted.ServiceID})
+		}
+
+		if arg.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err == errDryRun {
+		err = nil
+	}
+
+	return results, err
+}
+
+// importAction tells from the error of looking up an existing row whether an import creates or updates it
+func importAction(err error) (string, error) {
+	switch err {
+	case nil:
+		return util.ImportUpdated, nil
+	case sql.ErrNoRows:
+		return util.ImportCreated, nil
+	default:
+		return "", err
+	}
+}
+
+// reserveTimeSlot takes one place in a slot, failing with ErrSlotUnavailable when the slot
+// does not exist, is of the wrong type, has already started or is full
+func reserveTimeSlot(ctx context.Context, q *Queries, slotID int32, slotType string) (TimeSlot, error) {
+	slot, err := q.BookTimeSlot(ctx, BookTimeSlotParams{
+		SlotID:   slotID,
+		SlotType: slotType,
+	})
+	if err == sql.ErrNoRows {
+		return slot, ErrSlotUnavailable
+	}
+	return slot, err
+}
+
+// moveTimeSlot releases the current slot and books the requested one. When no slot is
+// requested, or it is the current one, the current slot is kept.
+func moveTimeSlot(ctx context.Context, q *Queries, current, requested sql.NullInt32, slotType string) (TimeSlot, error) {
+	if !requested.Valid || requested == current {
+		if !current.Valid {
+			return TimeSlot{}, nil
+		}
+		return q.GetTimeSlot(ctx, current.Int32)
+	}
+
+	if current.Valid {
+		err := q.ReleaseTimeSlot(ctx, current.Int32)
+		if err != nil {
+			return TimeSlot{}, err
+		}
+	}
+	return reserveTimeSlot(ctx, q, requested.Int32, slotType)
+}
+
+// slotChange describes a slot change for the order history, with null for no slot
+func nullableChange(from, to sql.NullInt32) map[string]*int32 {
+	change := map[string]*int32{"from": nil, "to": nil}
+	if from.Valid {
+		change["from"] = &from.Int32
+	}
+	if to.Valid {
+		change["to"] = &to.Int32
+	}
+	return change
+}
+
+// scheduleOrder stores the slots on every row of the order. The expected delivery time
+// becomes the end of the delivery slot, or stays deliveryTime when there is none.
+func scheduleOrder(ctx context.Context, q *Queries, orderID int64, pickup, delivery TimeSlot, deliveryTime time.Time) ([]Order, error) {
+	if pickup.SlotID != 0 && delivery.SlotID != 0 && delivery.StartsAt.Before(pickup.EndsAt) {
+		return nil, ErrInvalidSchedule
+	}
+
+	arg := UpdateOrderSlotsParams{
+		OrderID:           orderID,
+		OrderDeliveryTime: deliveryTime,
+	}
+	if pickup.SlotID != 0 {
+		arg.PickupSlotID = sql.NullInt32{Int32: pickup.SlotID, Valid: true}
+	}
+	if delivery.SlotID != 0 {
+		arg.DeliverySlotID = sql.NullInt32{Int32: delivery.SlotID, Valid: true}
+		arg.OrderDeliveryTime = delivery.EndsAt
+	}
+	return q.UpdateOrderSlots(ctx, arg)
+}
+
+type SetServicePriceTxParams struct {
+	ServiceID   int32         `json:"service_id"`
+	Price       int64         `json:"price"`
+	EffectiveAt time.Time     `json:"effective_at"`
+	CreatedBy   sql.NullInt32 `json:"created_by"`
+}
+
+type SetServicePriceTxResult struct {
+	Service Service             `json:"service"`
+	Price   ServicePriceHistory `json:"price"`
+}
+
+// SetServicePriceTx adds a price to the timeline of a service in a single DB transaction.
+// A price that is already in effect replaces the service price at once unless a newer entry is in effect,
+// later ones are applied by ApplyDueServicePrices.
+func (store *Store) SetServicePriceTx(ctx context.Context, arg SetServicePriceTxParams) (SetServicePriceTxResult, error) {
+	var result SetServicePriceTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Service, err = q.GetService(ctx, arg.ServiceID)
+		if err != nil {
+			return err
+		}
+
+		result.Price, err = q.CreateServicePrice(ctx, CreateServicePriceParams{
+			ServiceID:   arg.ServiceID,
+			Price:       arg.Price,
+			EffectiveAt: arg.EffectiveAt,
+			CreatedBy:   arg.CreatedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if arg.EffectiveAt.After(now) {
+			return nil
+		}
+		// A back-dated price may be older than the one in effect, so the current price is looked up again
+		price, err := q.GetEffectiveServicePrice(ctx, GetEffectiveServicePriceParams{
+			At:        now,
+			ServiceID: arg.ServiceID,
+		})
+		if err != nil {
+			return err
+		}
+		if price == result.Service.ServicePrice {
+			return nil
+		}
+		result.Service, err = q.SetServicePrice(ctx, SetServicePriceParams{
+			ServiceID:    arg.ServiceID,
+			ServicePrice: price,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ErrServiceModified is returned when a service was changed since the version an update was based on
+var ErrServiceModified = errors.New("service was changed by someone else, reload it and try again")
+
+// UpdateServiceTx updates the fields of a service that are set in arg and records a changed price in its price timeline in a single DB transaction.
+// The update only goes through when the service is still at arg.Version.
+func (store *Store) UpdateServiceTx(ctx context.Context, arg UpdateServiceParams, changedBy sql.NullInt32) (Service, error) {
+	var service Service
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		previous, err := q.GetService(ctx, arg.ServiceID)
+		if err != nil {
+			return err
+		}
+		if previous.Version != arg.Version {
+			return ErrServiceModified
+		}
+
+		service, err = q.UpdateService(ctx, arg)
+		if err == sql.ErrNoRows {
+			// changed between reading and updating it
+			return ErrServiceModified
+		}
+		if err != nil {
+			return err
+		}
+		return recordServicePrice(ctx, q, service, previous.ServicePrice, changedBy)
+	})
+
+	return service, err
+}
+
+// recordServicePrice adds the price of a service to its timeline, effective now, when it differs from the previous one.
+// Without it ApplyDueServicePrices would put back the last price of the timeline.
+func recordServicePrice(ctx context.Context, q *Queries, service Service, previous int64, changedBy sql.NullInt32) error {
+	if service.ServicePrice == previous {
+		return nil
+	}
+	_, err := q.CreateServicePrice(ctx, CreateServicePriceParams{
+		ServiceID:   service.ServiceID,
+		Price:       service.ServicePrice,
+		EffectiveAt: time.Now(),
+		CreatedBy:   changedBy,
+	})
+	return err
+}
+
+type BundleTxParams struct {
+	BundleID    int32        `json:"bundle_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       int64        `json:"price"`
+	IsActive    bool         `json:"is_active"`
+	Items       []BundleItem `json:"items"`
+}
+
+type BundleTxResult struct {
+	Bundle Bundle       `json:"bundle"`
+	Items  []BundleItem `json:"items"`
+}
+
+// CreateBundleTx creates a bundle with its services in a single DB transaction.
+// A service listed more than once is rejected with ErrDuplicateBundleItem.
+func (store *Store) CreateBundleTx(ctx context.Context, arg BundleTxParams) (BundleTxResult, error) {
+	var result BundleTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Bundle, err = q.CreateBundle(ctx, CreateBundleParams{
+			Name:        arg.Name,
+			Description: arg.Description,
+			Price:       arg.Price,
+			IsActive:    arg.IsActive,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Items, err = addBundleItems(ctx, q, result.Bundle.BundleID, arg.Items)
+		return err
+	})
+
+	return result, err
+}
+
+// UpdateBundleTx updates a bundle and replaces its services in a single DB transaction.
+// Orders already placed keep the prices they were placed at.
+func (store *Store) UpdateBundleTx(ctx context.Context, arg BundleTxParams) (BundleTxResult, error) {
+	var result BundleTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Bundle, err = q.UpdateBundle(ctx, UpdateBundleParams{
+			BundleID:    arg.BundleID,
+			Name:        arg.Name,
+			Description: arg.Description,
+			Price:       arg.Price,
+			IsActive:    arg.IsActive,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteBundleItems(ctx, arg.BundleID)
+		if err != nil {
+			return err
+		}
+		result.Items, err = addBundleItems(ctx, q, arg.BundleID, arg.Items)
+		return err
+	})
+
+	return result, err
+}
+
+func addBundleItems(ctx context.Context, q *Queries, bundleID int32, items []BundleItem) ([]BundleItem, error) {
+	listed := make(map[int32]bool)
+	for _, item := range items {
+		if listed[item.ServiceID] {
+			return nil, ErrDuplicateBundleItem
+		}
+		listed[item.ServiceID] = true
+	}
+
+	added := make([]BundleItem, 0, len(items))
+	for _, bundleItem := range items {
+		item, err := q.AddBundleItem(ctx, AddBundleItemParams{
+			BundleID:  bundleID,
+			ServiceID: bundleItem.ServiceID,
+			Quantity:  bundleItem.Quantity,
+		})
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, item)
+	}
+	return added, nil
+}
+
+// ArchiveUserTx archives a customer and blocks their sessions in a single DB transaction,
+// so they can neither log in nor renew a token afterwards. Their orders are kept.
+func (store *Store) ArchiveUserTx(ctx context.Context, userID int32) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.ArchiveUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		return q.BlockSessions(ctx, user.Email)
+	})
+
+	return user, err
+}
+
+// ArchiveAdminTx archives a staff member and blocks their sessions in a single DB transaction.
+// Orders stay assigned to them, the assignment strategies no longer pick them.
+func (store *Store) ArchiveAdminTx(ctx context.Context, adminID int32) (Admin, error) {
+	var admin Admin
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		admin, err = q.ArchiveAdmin(ctx, adminID)
+		if err != nil {
+			return err
+		}
+		return q.BlockSessions(ctx, admin.Email)
+	})
+
+	return admin, err
+}
+
+var ErrUploadUnavailable = errors.New("upload has already been confirmed or has expired")
+
+// ConfirmUploadTxParams attach an upload stored at FileURL to what its purpose names
+type ConfirmUploadTxParams struct {
+	UploadID uuid.UUID `json:"upload_id"`
+	FileURL  string    `json:"file_url"`
+	// ServiceID is the service a service image is for, Version the version of it the image replaces
+	ServiceID int32 `json:"service_id"`
+	Version   int32 `json:"version"`
+	// OrderID, NoteID and Visibility place an order attachment
+	OrderID    int64         `json:"order_id"`
+	NoteID     sql.NullInt64 `json:"note_id"`
+	Visibility string        `json:"visibility"`
+}
+
+// ConfirmUploadTxResult holds the upload and the one row it was attached to
+type ConfirmUploadTxResult struct {
+	Upload     Upload
+	Service    *Service
+	Attachment *OrderAttachment
+	User       *User
+	Admin      *Admin
+}
+
+// ConfirmUploadTx confirms an upload and attaches it in a single DB transaction, so an upload is attached at most once
+func (store *Store) ConfirmUploadTx(ctx context.Context, arg ConfirmUploadTxParams) (ConfirmUploadTxResult, error) {
+	var result ConfirmUploadTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Upload, err = q.ConfirmUpload(ctx, arg.UploadID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrUploadUnavailable
+			}
+			return err
+		}
+		upload := result.Upload
+
+		switch upload.Purpose {
+		case util.UploadServiceImage:
+			service, err := q.SetServiceImage(ctx, SetServiceImageParams{
+				ServiceID:    arg.ServiceID,
+				ServiceImage: arg.FileURL,
+				Version:      arg.Version,
+			})
+			if err == sql.ErrNoRows {
+				// changed or archived since the upload was checked
+				return ErrServiceModified
+			}
+			result.Service = &service
+			return err
+		case util.UploadOrderAttachment:
+			attachment, err := q.CreateOrderAttachment(ctx, CreateOrderAttachmentParams{
+				OrderID:     arg.OrderID,
+				NoteID:      arg.NoteID,
+				FileUrl:     arg.FileURL,
+				FileName:    upload.FileName,
+				ContentType: upload.ContentType,
+				SizeBytes:   upload.SizeBytes,
+				Visibility:  arg.Visibility,
+				AdminID:     upload.AdminID,
+				UserID:      upload.UserID,
+			})
+			result.Attachment = &attachment
+			return err
+		case util.UploadProfileImage:
+			// A profile image belongs to whoever uploaded it
+			if upload.AdminID.Valid {
+				admin, err := q.SetAdminProfileImage(ctx, SetAdminProfileImageParams{
+					AdminID:      upload.AdminID.Int32,
+					ProfileImage: arg.FileURL,
+				})
+				result.Admin = &admin
+				return err
+			}
+			user, err := q.SetUserProfileImage(ctx, SetUserProfileImageParams{
+				UserID:       upload.UserID.Int32,
+				ProfileImage: arg.FileURL,
+			})
+			result.User = &user
+			return err
+		default:
+			return fmt.Errorf("unknown upload purpose %q", upload.Purpose)
+		}
+	})
+
+	return result, err
+}
+
+// SetAdminBranchesTx replaces the branches an admin works at in a single DB transaction.
+// An admin without branches sees every branch.
+func (store *Store) SetAdminBranchesTx(ctx context.Context, adminID int32, branchIDs []int32) ([]int32, error) {
+	var result []int32
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if _, err := q.GetAdmin(ctx, adminID); err != nil {
+			return err
+		}
+		if err := q.DeleteAdminBranches(ctx, adminID); err != nil {
+			return err
+		}
+		added := make(map[int32]bool, len(branchIDs))
+		for _, branchID := range branchIDs {
+			if added[branchID] {
+				continue
+			}
+			added[branchID] = true
+
+			err := q.AddAdminBranch(ctx, AddAdminBranchParams{
+				AdminID:  adminID,
+				BranchID: branchID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		result, err = q.ListAdminBranchIDs(ctx, adminID)
+		return err
+	})
+
+	return result, err
+}
+
 // type OrderTxParams struct {
 // 	CustomerID  int64   `json:"customer_id"`
 // 	ServiceIds  []int32 `json:"service_ids"`
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSubscription(t *testing.T, store *Store, nextRunAt time.Time) SubscriptionTxResult {
+	user := createRandomUser(t)
+	service1 := createRandomService(t)
+	service2 := createRandomService(t)
+
+	result, err := store.CreateSubscriptionTx(context.Background(), CreateSubscriptionTxParams{
+		UserID:     user.UserID,
+		BranchID:   defaultBranch(t).BranchID,
+		Frequency:  util.FrequencyWeekly,
+		NextRunAt:  nextRunAt,
+		AnchorAt:   nextRunAt,
+		ServiceIDs: []int32{service1.ServiceID, service2.ServiceID, service1.ServiceID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.SubscriptionActive, result.Subscription.Status)
+	require.Len(t, result.Services, 2)
+	require.Equal(t, int32(2), result.Services[0].Quantity)
+
+	return result
+}
+
+func TestRunSubscriptionTx(t *testing.T) {
+	store := NewStore(testDB)
+	now := time.Now()
+	sub := createRandomSubscription(t, store, now.Add(-time.Minute)).Subscription
+
+	// Only one of several concurrent runs creates an order
+	n := 5
+	errs := make(chan error, n)
+	results := make(chan RunSubscriptionTxResult, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := store.RunSubscriptionTx(context.Background(), RunSubscriptionTxParams{
+				SubscriptionID: sub.SubscriptionID,
+				Now:            now,
+				Location:       time.UTC,
+			})
+			errs <- err
+			if err == nil {
+				results <- result
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	close(results)
+
+	created := 0
+	for err := range errs {
+		if err != nil {
+			require.ErrorIs(t, err, ErrSubscriptionNotDue)
+			continue
+		}
+		created++
+	}
+	require.Equal(t, 1, created)
+
+	result := <-results
+	require.Len(t, result.Order.Orders, 3)
+	require.Equal(t, util.SubscriptionRunCreated, result.Run.Status)
+	require.Equal(t, result.Order.Orders[0].OrderID, result.Run.OrderID.Int64)
+	require.WithinDuration(t, sub.NextRunAt.AddDate(0, 0, 7), result.Subscription.NextRunAt, time.Second)
+}
+
+func TestRecordSubscriptionFailureTx(t *testing.T) {
+	store := NewStore(testDB)
+	now := time.Now()
+	sub := createRandomSubscription(t, store, now.Add(-time.Minute)).Subscription
+
+	arg := RecordSubscriptionFailureTxParams{
+		SubscriptionID: sub.SubscriptionID,
+		Error:          "service unavailable",
+		MaxAttempts:    2,
+		Now:            now,
+		Location:       time.UTC,
+	}
+
+	result, err := store.RecordSubscriptionFailureTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, result.GaveUp)
+	require.Equal(t, int32(1), result.Subscription.FailureCount)
+	require.WithinDuration(t, sub.NextRunAt, result.Subscription.NextRunAt, time.Second)
+
+	result, err = store.RecordSubscriptionFailureTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.GaveUp)
+	require.Equal(t, util.SubscriptionRunFailed, result.Run.Status)
+	require.Zero(t, result.Subscription.FailureCount)
+	require.True(t, result.Subscription.NextRunAt.After(now))
+}
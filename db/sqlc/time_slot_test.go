@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTimeSlot(t *testing.T, slotType string, capacity int32) TimeSlot {
+	// Slots are unique per type and start time, so start somewhere in the next year
+	startsAt := time.Now().Add(time.Hour + time.Duration(rand.Int63n(int64(365*24*time.Hour)))).Truncate(time.Second)
+	arg := CreateTimeSlotParams{
+		SlotType: slotType,
+		StartsAt: startsAt,
+		EndsAt:   startsAt.Add(2 * time.Hour),
+		Capacity: capacity,
+	}
+
+	slot, err := testQueries.CreateTimeSlot(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, slot)
+
+	require.Equal(t, arg.SlotType, slot.SlotType)
+	require.WithinDuration(t, arg.StartsAt, slot.StartsAt, time.Second)
+	require.WithinDuration(t, arg.EndsAt, slot.EndsAt, time.Second)
+	require.Equal(t, arg.Capacity, slot.Capacity)
+	require.Zero(t, slot.Booked)
+
+	return slot
+}
+
+func TestCreateTimeSlot(t *testing.T) {
+	createRandomTimeSlot(t, util.SlotPickup, 3)
+}
+
+func TestCreateOrderTxSlotCapacity(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	service := createRandomService(t)
+	slot := createRandomTimeSlot(t, util.SlotPickup, 2)
+	branch := defaultBranch(t)
+
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{
+				OrderID:      util.NewOrderID(),
+				UserID:       user.UserID,
+				BranchID:     branch.BranchID,
+				ServiceIDs:   []int32{service.ServiceID},
+				OrderStatus:  "Started",
+				PickupSlotID: sql.NullInt32{Int32: slot.SlotID, Valid: true},
+			})
+			errs <- err
+		}()
+	}
+
+	booked := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			booked++
+			continue
+		}
+		require.True(t, errors.Is(err, ErrSlotUnavailable))
+	}
+	require.Equal(t, int(slot.Capacity), booked)
+
+	slot, err := testQueries.GetTimeSlot(context.Background(), slot.SlotID)
+	require.NoError(t, err)
+	require.Equal(t, slot.Capacity, slot.Booked)
+}
+
+func TestRescheduleOrderTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	service := createRandomService(t)
+	pickup1 := createRandomTimeSlot(t, util.SlotPickup, 1)
+	pickup2 := createRandomTimeSlot(t, util.SlotPickup, 1)
+
+	created, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{
+		OrderID:      util.NewOrderID(),
+		UserID:       user.UserID,
+		BranchID:     defaultBranch(t).BranchID,
+		ServiceIDs:   []int32{service.ServiceID},
+		OrderStatus:  "Started",
+		PickupSlotID: sql.NullInt32{Int32: pickup1.SlotID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, created.Orders, 1)
+
+	orderID := created.Orders[0].OrderID
+	result, err := store.RescheduleOrderTx(context.Background(), RescheduleOrderTxParams{
+		OrderID:      orderID,
+		PickupSlotID: sql.NullInt32{Int32: pickup2.SlotID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, pickup2.SlotID, result.Orders[0].PickupSlotID.Int32)
+	require.Equal(t, util.OrderEventRescheduled, result.History.Event)
+
+	pickup1, err = testQueries.GetTimeSlot(context.Background(), pickup1.SlotID)
+	require.NoError(t, err)
+	require.Zero(t, pickup1.Booked)
+
+	pickup2, err = testQueries.GetTimeSlot(context.Background(), pickup2.SlotID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), pickup2.Booked)
+
+	history, err := testQueries.ListOrderHistory(context.Background(), orderID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUpload(t *testing.T, purpose string, adminID, userID sql.NullInt32) Upload {
+	uploadID := uuid.New()
+	arg := CreateUploadParams{
+		UploadID:    uploadID,
+		ObjectKey:   fmt.Sprintf("uploads/%s.jpg", uploadID),
+		FileName:    "photo.jpg",
+		ContentType: "image/jpeg",
+		SizeBytes:   int64(util.RandomPrice()),
+		Purpose:     purpose,
+		AdminID:     adminID,
+		UserID:      userID,
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+
+	upload, err := testQueries.CreateUpload(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ObjectKey, upload.ObjectKey)
+	require.False(t, upload.ConfirmedAt.Valid)
+
+	return upload
+}
+
+func TestConfirmUploadTx(t *testing.T) {
+	store := NewStore(testDB)
+	admin := createRandomAdmin(t)
+	service := createRandomService(t)
+	staff := sql.NullInt32{Int32: admin.AdminID, Valid: true}
+
+	upload := createRandomUpload(t, util.UploadServiceImage, staff, sql.NullInt32{})
+
+	// An image for a stale version of the service is rejected and the upload left unconfirmed
+	_, err := store.ConfirmUploadTx(context.Background(), ConfirmUploadTxParams{
+		UploadID:  upload.UploadID,
+		FileURL:   "https://example.com/services/stale.jpg",
+		ServiceID: service.ServiceID,
+		Version:   service.Version + 1,
+	})
+	require.ErrorIs(t, err, ErrServiceModified)
+
+	result, err := store.ConfirmUploadTx(context.Background(), ConfirmUploadTxParams{
+		UploadID:  upload.UploadID,
+		FileURL:   "https://example.com/services/photo.jpg",
+		ServiceID: service.ServiceID,
+		Version:   service.Version,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Upload.ConfirmedAt.Valid)
+	require.Equal(t, "https://example.com/services/photo.jpg", result.Service.ServiceImage)
+
+	// An upload is only attached once
+	_, err = store.ConfirmUploadTx(context.Background(), ConfirmUploadTxParams{
+		UploadID:  upload.UploadID,
+		FileURL:   "https://example.com/services/other.jpg",
+		ServiceID: service.ServiceID,
+	})
+	require.ErrorIs(t, err, ErrUploadUnavailable)
+
+	order := createRandomStoreOrder(t, store)
+	upload = createRandomUpload(t, util.UploadOrderAttachment, staff, sql.NullInt32{})
+	result, err = store.ConfirmUploadTx(context.Background(), ConfirmUploadTxParams{
+		UploadID:   upload.UploadID,
+		FileURL:    "https://example.com/orders/photo.jpg",
+		OrderID:    order.OrderID,
+		Visibility: "internal",
+	})
+	require.NoError(t, err)
+	require.Equal(t, order.OrderID, result.Attachment.OrderID)
+	require.Equal(t, upload.SizeBytes, result.Attachment.SizeBytes)
+	require.Equal(t, staff, result.Attachment.AdminID)
+
+	user := createRandomUser(t)
+	upload = createRandomUpload(t, util.UploadProfileImage, sql.NullInt32{}, sql.NullInt32{Int32: user.UserID, Valid: true})
+	result, err = store.ConfirmUploadTx(context.Background(), ConfirmUploadTxParams{
+		UploadID: upload.UploadID,
+		FileURL:  "https://example.com/profiles/photo.jpg",
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Admin)
+	require.Equal(t, "https://example.com/profiles/photo.jpg", result.User.ProfileImage)
+}
+
+func TestListExpiredUploads(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	owner := sql.NullInt32{Int32: user.UserID, Valid: true}
+
+	pending := createRandomUpload(t, util.UploadProfileImage, sql.NullInt32{}, owner)
+	confirmed := createRandomUpload(t, util.UploadProfileImage, sql.NullInt32{}, owner)
+	_, err := store.ConfirmUploadTx(context.Background(), ConfirmUploadTxParams{
+		UploadID: confirmed.UploadID,
+		FileURL:  "https://example.com/profiles/photo.jpg",
+	})
+	require.NoError(t, err)
+
+	// Both uploads expire within a minute, only the unconfirmed one is swept
+	expired, err := testQueries.ListExpiredUploads(context.Background(), ListExpiredUploadsParams{
+		ExpiredBefore: time.Now().Add(time.Hour),
+		Limit:         1000,
+	})
+	require.NoError(t, err)
+	var ids []uuid.UUID
+	for _, upload := range expired {
+		ids = append(ids, upload.UploadID)
+	}
+	require.Contains(t, ids, pending.UploadID)
+	require.NotContains(t, ids, confirmed.UploadID)
+
+	require.NoError(t, testQueries.DeleteUpload(context.Background(), pending.UploadID))
+	_, err = testQueries.GetUpload(context.Background(), pending.UploadID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Confirmed uploads are kept
+	require.NoError(t, testQueries.DeleteUpload(context.Background(), confirmed.UploadID))
+	_, err = testQueries.GetUpload(context.Background(), confirmed.UploadID)
+	require.NoError(t, err)
+}
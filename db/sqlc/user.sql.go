@@ -7,26 +7,71 @@ package db
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
 )
 
+const archiveUser = `-- name: ArchiveUser :one
+UPDATE users
+SET archived_at = COALESCE(archived_at, now())
+WHERE user_id = $1
+RETURNING user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id
+`
+
+func (q *Queries) ArchiveUser(ctx context.Context, userID int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, archiveUser, userID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.TotalOrders,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+		&i.BranchID,
+	)
+	return i, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT count(*) FROM users
+WHERE archived_at IS NULL
+AND (COALESCE(cardinality($1::int[]), 0) = 0 OR branch_id = ANY($1::int[]))
+`
+
+func (q *Queries) CountUsers(ctx context.Context, branchIds []int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers, pq.Array(branchIds))
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
   name,
   email,
   phone,
   address,
-  hashed_password 
+  hashed_password,
+  branch_id
 ) VALUES (
-  $1, $2, $3, $4, $5
-) RETURNING user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at
+  $1, $2, $3, $4, $5, $6
+) RETURNING user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id
 `
 
 type CreateUserParams struct {
-	Name           string `json:"name"`
-	Email          string `json:"email"`
-	Phone          string `json:"phone"`
-	Address        string `json:"address"`
-	HashedPassword string `json:"hashed_password"`
+	Name           string        `json:"name"`
+	Email          string        `json:"email"`
+	Phone          string        `json:"phone"`
+	Address        string        `json:"address"`
+	HashedPassword string        `json:"hashed_password"`
+	BranchID       sql.NullInt32 `json:"branch_id"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -36,6 +81,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.Phone,
 		arg.Address,
 		arg.HashedPassword,
+		arg.BranchID,
 	)
 	var i User
 	err := row.Scan(
@@ -48,21 +94,40 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.HashedPassword,
 		&i.CreatedAt,
 		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+		&i.BranchID,
 	)
 	return i, err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
-DELETE FROM users WHERE user_id = $1
+const getActiveUser = `-- name: GetActiveUser :one
+SELECT user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id FROM users
+WHERE user_id = $1 AND archived_at IS NULL LIMIT 1
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, userID int32) error {
-	_, err := q.db.ExecContext(ctx, deleteUser, userID)
-	return err
+func (q *Queries) GetActiveUser(ctx context.Context, userID int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, getActiveUser, userID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.TotalOrders,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+		&i.BranchID,
+	)
+	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at FROM users
+SELECT user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id FROM users
 WHERE user_id = $1 LIMIT 1
 `
 
@@ -79,12 +144,15 @@ func (q *Queries) GetUser(ctx context.Context, userID int32) (User, error) {
 		&i.HashedPassword,
 		&i.CreatedAt,
 		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+		&i.BranchID,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at FROM users
+SELECT user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id FROM users
 WHERE email = $1 LIMIT 1
 `
 
@@ -101,17 +169,22 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.HashedPassword,
 		&i.CreatedAt,
 		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+		&i.BranchID,
 	)
 	return i, err
 }
 
 const listAllUsers = `-- name: ListAllUsers :many
-SELECT user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at FROM users
+SELECT user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id FROM users
+WHERE archived_at IS NULL
+AND ($1::int IS NULL OR branch_id = $1)
 ORDER BY user_id
 `
 
-func (q *Queries) ListAllUsers(ctx context.Context) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, listAllUsers)
+func (q *Queries) ListAllUsers(ctx context.Context, branchID sql.NullInt32) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listAllUsers, branchID)
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +202,9 @@ func (q *Queries) ListAllUsers(ctx context.Context) ([]User, error) {
 			&i.HashedPassword,
 			&i.CreatedAt,
 			&i.PasswordChangedAt,
+			&i.ArchivedAt,
+			&i.ProfileImage,
+			&i.BranchID,
 		); err != nil {
 			return nil, err
 		}
@@ -144,19 +220,78 @@ func (q *Queries) ListAllUsers(ctx context.Context) ([]User, error) {
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at FROM users
+SELECT user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id FROM users
+WHERE (archived_at IS NULL OR $1::boolean)
+AND ($2::int IS NULL OR branch_id = $2)
 ORDER BY user_id
-LIMIT $1
-OFFSET $2
+LIMIT $3
+OFFSET $4
 `
 
 type ListUsersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	IncludeArchived bool          `json:"include_archived"`
+	BranchID        sql.NullInt32 `json:"branch_id"`
+	Limit           int32         `json:"limit"`
+	Offset          int32         `json:"offset"`
 }
 
 func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listUsers,
+		arg.IncludeArchived,
+		arg.BranchID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Name,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.TotalOrders,
+			&i.HashedPassword,
+			&i.CreatedAt,
+			&i.PasswordChangedAt,
+			&i.ArchivedAt,
+			&i.ProfileImage,
+			&i.BranchID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersAfter = `-- name: ListUsersAfter :many
+SELECT user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id FROM users
+WHERE user_id > $1 AND archived_at IS NULL
+AND (COALESCE(cardinality($2::int[]), 0) = 0 OR branch_id = ANY($2::int[]))
+ORDER BY user_id
+LIMIT $3
+`
+
+type ListUsersAfterParams struct {
+	UserID    int32   `json:"user_id"`
+	BranchIds []int32 `json:"branch_ids"`
+	Limit     int32   `json:"limit"`
+}
+
+func (q *Queries) ListUsersAfter(ctx context.Context, arg ListUsersAfterParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersAfter, arg.UserID, pq.Array(arg.BranchIds), arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -174,6 +309,9 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.HashedPassword,
 			&i.CreatedAt,
 			&i.PasswordChangedAt,
+			&i.ArchivedAt,
+			&i.ProfileImage,
+			&i.BranchID,
 		); err != nil {
 			return nil, err
 		}
@@ -188,6 +326,65 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const restoreUser = `-- name: RestoreUser :one
+UPDATE users
+SET archived_at = NULL
+WHERE user_id = $1
+RETURNING user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id
+`
+
+func (q *Queries) RestoreUser(ctx context.Context, userID int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, restoreUser, userID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.TotalOrders,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+		&i.BranchID,
+	)
+	return i, err
+}
+
+const setUserProfileImage = `-- name: SetUserProfileImage :one
+UPDATE users
+SET profile_image = $2
+WHERE user_id = $1
+RETURNING user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id
+`
+
+type SetUserProfileImageParams struct {
+	UserID       int32  `json:"user_id"`
+	ProfileImage string `json:"profile_image"`
+}
+
+func (q *Queries) SetUserProfileImage(ctx context.Context, arg SetUserProfileImageParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserProfileImage, arg.UserID, arg.ProfileImage)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.TotalOrders,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+		&i.BranchID,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users 
 SET email = $2,
@@ -196,7 +393,7 @@ phone = $4,
 total_orders = $5,
 hashed_password = $6
 WHERE user_id = $1
-RETURNING user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at
+RETURNING user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id
 `
 
 type UpdateUserParams struct {
@@ -228,6 +425,9 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.HashedPassword,
 		&i.CreatedAt,
 		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+		&i.BranchID,
 	)
 	return i, err
 }
@@ -236,7 +436,7 @@ const updateUserOrder = `-- name: UpdateUserOrder :one
 UPDATE users 
 SET total_orders = $2
 WHERE user_id = $1
-RETURNING user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at
+RETURNING user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id
 `
 
 type UpdateUserOrderParams struct {
@@ -257,6 +457,59 @@ func (q *Queries) UpdateUserOrder(ctx context.Context, arg UpdateUserOrderParams
 		&i.HashedPassword,
 		&i.CreatedAt,
 		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+		&i.BranchID,
+	)
+	return i, err
+}
+
+const upsertUser = `-- name: UpsertUser :one
+INSERT INTO users (
+  name,
+  email,
+  phone,
+  address,
+  hashed_password
+) VALUES (
+  $1, $2, $3, $4, $5
+) ON CONFLICT (email) DO UPDATE
+SET name = EXCLUDED.name,
+phone = EXCLUDED.phone,
+address = EXCLUDED.address
+RETURNING user_id, name, email, phone, address, total_orders, hashed_password, created_at, password_changed_at, archived_at, profile_image, branch_id
+`
+
+type UpsertUserParams struct {
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	Address        string `json:"address"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+func (q *Queries) UpsertUser(ctx context.Context, arg UpsertUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, upsertUser,
+		arg.Name,
+		arg.Email,
+		arg.Phone,
+		arg.Address,
+		arg.HashedPassword,
+	)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.TotalOrders,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.ArchivedAt,
+		&i.ProfileImage,
+		&i.BranchID,
 	)
 	return i, err
 }
@@ -2,9 +2,11 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
 	"github.com/stretchr/testify/require"
 )
@@ -110,3 +112,76 @@ func TestListUsers(t *testing.T) {
 	}
 
 }
+
+func TestImportUsersTx(t *testing.T) {
+	store := NewStore(testDB)
+	existing := createRandomUser(t)
+
+	newUser := UpsertUserParams{
+		Name:           util.RandomUser(),
+		Email:          util.RandomEmail(),
+		Phone:          util.RandomPhone(),
+		Address:        util.RandomAddress(),
+		HashedPassword: existing.HashedPassword,
+	}
+	updated := UpsertUserParams{
+		Name:           util.RandomUser(),
+		Email:          existing.Email,
+		Phone:          util.RandomPhone(),
+		Address:        util.RandomAddress(),
+		HashedPassword: "not-used",
+	}
+	arg := ImportUsersTxParams{Users: []UpsertUserParams{newUser, updated}, DryRun: true}
+
+	// A dry run reports the changes without saving them
+	results, err := store.ImportUsersTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, util.ImportCreated, results[0].Action)
+	require.Equal(t, util.ImportUpdated, results[1].Action)
+
+	_, err = testQueries.GetUserByEmail(context.Background(), newUser.Email)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	arg.DryRun = false
+	results, err = store.ImportUsersTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, existing.UserID, results[1].ID)
+
+	user, err := testQueries.GetUserByEmail(context.Background(), existing.Email)
+	require.NoError(t, err)
+	require.Equal(t, updated.Name, user.Name)
+	require.Equal(t, updated.Address, user.Address)
+	require.Equal(t, existing.HashedPassword, user.HashedPassword)
+}
+
+func TestArchiveUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	session, err := testQueries.CreateSession(context.Background(), CreateSessionParams{
+		ID:           uuid.New(),
+		Email:        user.Email,
+		RefreshToken: util.RandomString(32),
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	archived, err := store.ArchiveUserTx(context.Background(), user.UserID)
+	require.NoError(t, err)
+	require.True(t, archived.ArchivedAt.Valid)
+
+	session, err = testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	// Archived customers keep their orders but are left out of listings
+	_, err = testQueries.GetUser(context.Background(), user.UserID)
+	require.NoError(t, err)
+
+	users, err := testQueries.ListAllUsers(context.Background(), sql.NullInt32{})
+	require.NoError(t, err)
+	for _, listed := range users {
+		require.NotEqual(t, user.UserID, listed.UserID)
+	}
+}
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write([]string{"name", "amount"}))
+	require.NoError(t, w.Write([]string{"=HYPERLINK(\"x\")", "-120"}))
+	require.NoError(t, w.Write([]string{"Asha, Corp", "300"}))
+	require.NoError(t, w.Close())
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"name", "amount"},
+		{"'=HYPERLINK(\"x\")", "-120"},
+		{"Asha, Corp", "300"},
+	}, records)
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write([]string{"name", "note"}))
+	require.NoError(t, w.Write([]string{"Asha & Co", "<fragile>"}))
+	require.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	var sheet string
+	names := make([]string, len(zr.File))
+	for i, f := range zr.File {
+		names[i] = f.Name
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			r, err := f.Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(r)
+			require.NoError(t, err)
+			sheet = string(content)
+		}
+	}
+	require.Contains(t, names, "[Content_Types].xml")
+	require.Contains(t, names, "xl/workbook.xml")
+	require.True(t, strings.HasSuffix(sheet, sheetEnd))
+	require.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">Asha &amp; Co</t></is></c>`)
+	require.Contains(t, sheet, `&lt;fragile&gt;`)
+}
+
+func TestColumnName(t *testing.T) {
+	require.Equal(t, "A", columnName(0))
+	require.Equal(t, "Z", columnName(25))
+	require.Equal(t, "AA", columnName(26))
+	require.Equal(t, "AZ", columnName(51))
+	require.Equal(t, "BA", columnName(52))
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewWriter("pdf", io.Discard)
+	require.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	// Formats images are uploaded in
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const jpegQuality = 85
+
+// Variant is a smaller copy of an image made for clients that do not need the original
+type Variant struct {
+	Name string
+	// MaxSize is the longest side of the variant in pixels, smaller images are not scaled up
+	MaxSize     int
+	ContentType string
+	Extension   string
+}
+
+// ServiceImageVariants are made of every service image
+var ServiceImageVariants = []Variant{
+	{Name: "thumbnail", MaxSize: 200, ContentType: "image/jpeg", Extension: ".jpg"},
+	{Name: "medium", MaxSize: 800, ContentType: "image/jpeg", Extension: ".jpg"},
+	{Name: "webp", MaxSize: 800, ContentType: "image/webp", Extension: ".webp"},
+}
+
+// Decode decodes a JPEG, PNG or WebP image
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// Fit scales img down until its longest side is at most maxSize pixels, keeping its aspect ratio
+func Fit(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*maxSize/width)
+		width = maxSize
+	} else {
+		width = max(1, width*maxSize/height)
+		height = maxSize
+	}
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
+
+// Render makes a variant of img
+func Render(img image.Image, variant Variant) ([]byte, error) {
+	scaled := Fit(img, variant.MaxSize)
+
+	var buf bytes.Buffer
+	var err error
+	switch variant.ContentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, flatten(scaled), &jpeg.Options{Quality: jpegQuality})
+	case "image/webp":
+		err = EncodeWebP(&buf, scaled)
+	default:
+		err = fmt.Errorf("cannot render images as %s", variant.ContentType)
+	}
+	return buf.Bytes(), err
+}
+
+// flatten puts transparent images on a white background, JPEG has no transparency and would show it black
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+	return flat
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
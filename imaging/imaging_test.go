@@ -0,0 +1,50 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFit(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1000, 500))
+	require.Equal(t, image.Pt(200, 100), Fit(img, 200).Bounds().Size())
+
+	img = image.NewNRGBA(image.Rect(0, 0, 300, 1200))
+	require.Equal(t, image.Pt(200, 800), Fit(img, 800).Bounds().Size())
+
+	// Small images are not scaled up
+	img = image.NewNRGBA(image.Rect(0, 0, 120, 80))
+	require.Same(t, img, Fit(img, 200))
+}
+
+func TestRender(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1200, 900))
+	for y := 0; y < 900; y++ {
+		for x := 0; x < 1200; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: uint8(x + y)})
+		}
+	}
+
+	var original bytes.Buffer
+	require.NoError(t, png.Encode(&original, img))
+	decoded, err := Decode(&original)
+	require.NoError(t, err)
+
+	for _, variant := range ServiceImageVariants {
+		data, err := Render(decoded, variant)
+		require.NoError(t, err)
+
+		rendered, format, err := image.Decode(bytes.NewReader(data))
+		require.NoError(t, err, variant.Name)
+		require.Equal(t, variant.Extension[1:], map[string]string{"jpeg": "jpg", "webp": "webp"}[format])
+
+		size := rendered.Bounds().Size()
+		require.Equal(t, variant.MaxSize, size.X)
+		require.Equal(t, variant.MaxSize*3/4, size.Y)
+	}
+}
@@ -0,0 +1,419 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"sort"
+)
+
+// VP8L limits
+const (
+	maxWebPSize        = 1 << 14
+	maxCodeLength      = 15
+	maxCodeLengthCode  = 7
+	greenAlphabetSize  = 256 + 24
+	distanceAlphabet   = 40
+	predictorSizeBits  = 9
+	predictorSelect    = 11
+	transformPredictor = 0
+	transformSubGreen  = 2
+)
+
+// codeLengthOrder is the order code length code lengths are written in
+var codeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebP writes img as a lossless WebP image.
+// The encoder is kept simple: it subtracts green and predicts every pixel from its left or top neighbour,
+// then Huffman codes the residuals without backward references.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > maxWebPSize || height > maxWebPSize {
+		return errors.New("webp images must be between 1 and 16384 pixels wide and high")
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	pixels := make([]uint32, width*height)
+	hasAlpha := false
+	for i := range pixels {
+		p := nrgba.Pix[i*4 : i*4+4]
+		c := color.NRGBA{R: p[0], G: p[1], B: p[2], A: p[3]}
+		if c.A != 0xff {
+			hasAlpha = true
+		}
+		// Red and blue go with green in most images, keeping only their difference to green makes them cheaper to code
+		pixels[i] = argb(c.A, c.R-c.G, c.G, c.B-c.G)
+	}
+
+	bw := &bitWriter{}
+	bw.write(0x2f, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	bw.writeBool(hasAlpha)
+	bw.write(0, 3)
+
+	// The decoder undoes transforms in reverse order, so the subtract green transform is undone last
+	bw.writeBool(true)
+	bw.write(transformSubGreen, 2)
+
+	bw.writeBool(true)
+	bw.write(transformPredictor, 2)
+	bw.write(predictorSizeBits-2, 3)
+	blocksWide := subSampleSize(width, predictorSizeBits)
+	blocksHigh := subSampleSize(height, predictorSizeBits)
+	modes := make([]uint32, blocksWide*blocksHigh)
+	for i := range modes {
+		modes[i] = argb(0xff, 0, predictorSelect, 0)
+	}
+	bw.writeImageData(modes, false)
+
+	bw.writeBool(false)
+	bw.writeImageData(predictSelect(pixels, width, height), true)
+
+	data := bw.bytes()
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+len(data)+len(data)%2))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func argb(a, r, g, b uint8) uint32 {
+	return uint32(a)<<24 | uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+}
+
+func subSampleSize(size, bits int) int {
+	return (size + 1<<bits - 1) >> bits
+}
+
+// subPixels subtracts every channel of b from a, modulo 256
+func subPixels(a, b uint32) uint32 {
+	var res uint32
+	for shift := 0; shift < 32; shift += 8 {
+		res |= uint32(uint8(a>>shift)-uint8(b>>shift)) << shift
+	}
+	return res
+}
+
+// channelDistance is the sum of the differences between the channels of a and b
+func channelDistance(a, b uint32) int {
+	var distance int
+	for shift := 0; shift < 32; shift += 8 {
+		d := int(uint8(a>>shift)) - int(uint8(b>>shift))
+		if d < 0 {
+			d = -d
+		}
+		distance += d
+	}
+	return distance
+}
+
+// predictSelect returns the residuals of the select predictor: the left or top neighbour,
+// whichever is closer to the gradient of the neighbourhood. The first row and column use the only neighbour they have.
+func predictSelect(pixels []uint32, width, height int) []uint32 {
+	residuals := make([]uint32, len(pixels))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			var prediction uint32
+			switch {
+			case x == 0 && y == 0:
+				prediction = 0xff000000
+			case y == 0:
+				prediction = pixels[i-1]
+			case x == 0:
+				prediction = pixels[i-width]
+			default:
+				left, top, topLeft := pixels[i-1], pixels[i-width], pixels[i-width-1]
+				if channelDistance(top, topLeft) < channelDistance(left, topLeft) {
+					prediction = left
+				} else {
+					prediction = top
+				}
+			}
+			residuals[i] = subPixels(pixels[i], prediction)
+		}
+	}
+	return residuals
+}
+
+// bitWriter writes bits least significant first, the way VP8L reads them
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nBits uint
+}
+
+func (bw *bitWriter) write(value uint32, n uint) {
+	bw.acc |= uint64(value) << bw.nBits
+	bw.nBits += n
+	for bw.nBits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc >>= 8
+		bw.nBits -= 8
+	}
+}
+
+func (bw *bitWriter) writeBool(value bool) {
+	if value {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+}
+
+func (bw *bitWriter) bytes() []byte {
+	if bw.nBits > 0 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc, bw.nBits = 0, 0
+	}
+	return bw.buf
+}
+
+// writeImageData writes an entropy coded image of ARGB pixels without color cache or backward references.
+// Only the main image can have meta prefix codes, which are left out as well.
+func (bw *bitWriter) writeImageData(pixels []uint32, isMain bool) {
+	bw.writeBool(false)
+	if isMain {
+		bw.writeBool(false)
+	}
+
+	green := make([]uint32, greenAlphabetSize)
+	red := make([]uint32, 256)
+	blue := make([]uint32, 256)
+	alpha := make([]uint32, 256)
+	for _, p := range pixels {
+		green[uint8(p>>8)]++
+		red[uint8(p>>16)]++
+		blue[uint8(p)]++
+		alpha[uint8(p>>24)]++
+	}
+
+	greenCode := bw.writePrefixCode(green)
+	redCode := bw.writePrefixCode(red)
+	blueCode := bw.writePrefixCode(blue)
+	alphaCode := bw.writePrefixCode(alpha)
+	bw.writePrefixCode(make([]uint32, distanceAlphabet))
+
+	for _, p := range pixels {
+		greenCode.write(bw, int(uint8(p>>8)))
+		redCode.write(bw, int(uint8(p>>16)))
+		blueCode.write(bw, int(uint8(p)))
+		alphaCode.write(bw, int(uint8(p>>24)))
+	}
+}
+
+// prefixCode holds the code of every symbol, bit reversed so it can be written least significant bit first
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+}
+
+func (code prefixCode) write(bw *bitWriter, symbol int) {
+	if length := code.lengths[symbol]; length > 0 {
+		bw.write(uint32(code.codes[symbol]), uint(length))
+	}
+}
+
+// writePrefixCode writes the prefix code for the symbol counts of an alphabet and returns it
+func (bw *bitWriter) writePrefixCode(counts []uint32) prefixCode {
+	var used []int
+	for symbol, count := range counts {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+
+	// One or two symbols are written as a simple code, a single symbol takes no bits at all
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		if len(used) == 0 {
+			used = []int{0}
+		}
+		code := prefixCode{lengths: make([]uint8, len(counts)), codes: make([]uint16, len(counts))}
+		bw.writeBool(true)
+		bw.write(uint32(len(used)-1), 1)
+		if used[0] <= 1 {
+			bw.write(0, 1)
+			bw.write(uint32(used[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			bw.write(uint32(used[1]), 8)
+			code.lengths[used[0]], code.lengths[used[1]] = 1, 1
+			code.codes[used[1]] = 1
+		}
+		return code
+	}
+
+	lengths := huffmanLengths(counts, maxCodeLength)
+
+	// The code lengths are coded with a prefix code of their own
+	codeLengthCounts := make([]uint32, len(codeLengthOrder))
+	for _, length := range lengths {
+		codeLengthCounts[length]++
+	}
+	ensureTwoSymbols(codeLengthCounts)
+	codeLengthLengths := huffmanLengths(codeLengthCounts, maxCodeLengthCode)
+	codeLengthCodes := canonicalCodes(codeLengthLengths)
+
+	numCodes := 4
+	for i, symbol := range codeLengthOrder {
+		if codeLengthLengths[symbol] > 0 && i+1 > numCodes {
+			numCodes = i + 1
+		}
+	}
+
+	bw.writeBool(false)
+	bw.write(uint32(numCodes-4), 4)
+	for _, symbol := range codeLengthOrder[:numCodes] {
+		bw.write(uint32(codeLengthLengths[symbol]), 3)
+	}
+	// Every symbol of the alphabet has a code length
+	bw.writeBool(false)
+	for _, length := range lengths {
+		bw.write(uint32(codeLengthCodes[length]), uint(codeLengthLengths[length]))
+	}
+
+	return prefixCode{lengths: lengths, codes: canonicalCodes(lengths)}
+}
+
+// ensureTwoSymbols gives a second symbol a count when only one is used, decoders expect complete codes
+func ensureTwoSymbols(counts []uint32) {
+	used := 0
+	for _, count := range counts {
+		if count > 0 {
+			used++
+		}
+	}
+	if used != 1 {
+		return
+	}
+	if counts[0] == 0 {
+		counts[0] = 1
+	} else {
+		counts[1] = 1
+	}
+}
+
+// huffmanLengths returns the code length of every symbol for a Huffman code no longer than maxLength.
+// Codes that come out too long are rebuilt from flattened counts. At least two symbols have to be used.
+func huffmanLengths(counts []uint32, maxLength int) []uint8 {
+	weights := make([]uint64, len(counts))
+	for i, count := range counts {
+		weights[i] = uint64(count)
+	}
+
+	for {
+		lengths, depth := huffmanTree(weights)
+		if depth <= maxLength {
+			return lengths
+		}
+		for i, weight := range weights {
+			if weight > 0 {
+				weights[i] = weight/2 + 1
+			}
+		}
+	}
+}
+
+type huffmanNode struct {
+	weight      uint64
+	symbol      int
+	left, right *huffmanNode
+}
+
+// huffmanTree builds a Huffman tree with two queues, merged nodes come out in order of weight
+// so the lightest two nodes are always at the heads of the queues
+func huffmanTree(weights []uint64) ([]uint8, int) {
+	var leaves, merged []*huffmanNode
+	for symbol, weight := range weights {
+		if weight > 0 {
+			leaves = append(leaves, &huffmanNode{weight: weight, symbol: symbol})
+		}
+	}
+	sort.SliceStable(leaves, func(i, j int) bool {
+		return leaves[i].weight < leaves[j].weight
+	})
+
+	lightest := func() *huffmanNode {
+		if len(merged) == 0 || (len(leaves) > 0 && leaves[0].weight <= merged[0].weight) {
+			node := leaves[0]
+			leaves = leaves[1:]
+			return node
+		}
+		node := merged[0]
+		merged = merged[1:]
+		return node
+	}
+	for len(leaves)+len(merged) > 1 {
+		left, right := lightest(), lightest()
+		merged = append(merged, &huffmanNode{weight: left.weight + right.weight, left: left, right: right})
+	}
+
+	lengths := make([]uint8, len(weights))
+	maxDepth := 0
+	var walk func(node *huffmanNode, depth int)
+	walk = func(node *huffmanNode, depth int) {
+		if node.left == nil {
+			lengths[node.symbol] = uint8(depth)
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+			return
+		}
+		walk(node.left, depth+1)
+		walk(node.right, depth+1)
+	}
+	walk(lightest(), 0)
+	return lengths, maxDepth
+}
+
+// canonicalCodes assigns codes in order of length and symbol, then reverses their bits for writing
+func canonicalCodes(lengths []uint8) []uint16 {
+	var lengthCounts [maxCodeLength + 1]int
+	for _, length := range lengths {
+		if length > 0 {
+			lengthCounts[length]++
+		}
+	}
+
+	var nextCode [maxCodeLength + 1]int
+	code := 0
+	for length := 1; length <= maxCodeLength; length++ {
+		code = (code + lengthCounts[length-1]) << 1
+		nextCode[length] = code
+	}
+
+	codes := make([]uint16, len(lengths))
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		code := nextCode[length]
+		nextCode[length]++
+
+		var reversed int
+		for i := uint8(0); i < length; i++ {
+			reversed = reversed<<1 | (code>>i)&1
+		}
+		codes[symbol] = uint16(reversed)
+	}
+	return codes
+}
@@ -0,0 +1,105 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+func requireWebPRoundTrip(t *testing.T, img *image.NRGBA) {
+	var buf bytes.Buffer
+	require.NoError(t, EncodeWebP(&buf, img))
+	require.Equal(t, "RIFF", buf.String()[:4])
+
+	decoded, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, img.Bounds().Size(), decoded.Bounds().Size())
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			want := img.NRGBAAt(x, y)
+			got := color.NRGBAModel.Convert(decoded.At(x-bounds.Min.X, y-bounds.Min.Y)).(color.NRGBA)
+			// Fully transparent pixels have no color to keep
+			if want.A == 0 {
+				require.Zero(t, got.A)
+				continue
+			}
+			require.Equal(t, want, got, "pixel %d,%d", x, y)
+		}
+	}
+}
+
+func TestEncodeWebP(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	testCases := []struct {
+		name          string
+		width, height int
+		pixel         func(x, y int) color.NRGBA
+	}{
+		{"single pixel", 1, 1, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: 10, G: 20, B: 30, A: 255}
+		}},
+		{"solid", 16, 9, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: 200, G: 100, B: 50, A: 255}
+		}},
+		{"gradient", 64, 48, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x * 4), G: uint8(y * 5), B: uint8(x + y), A: 255}
+		}},
+		{"transparent", 20, 20, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x * 12), G: 80, B: uint8(y * 12), A: uint8(x * y)}
+		}},
+		{"noise", 33, 17, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(rnd.Intn(256)), G: uint8(rnd.Intn(256)), B: uint8(rnd.Intn(256)), A: 255}
+		}},
+		// Predictor modes are kept for blocks of 512 pixels
+		{"several predictor blocks", 530, 3, func(x, y int) color.NRGBA {
+			return color.NRGBA{R: uint8(x), G: uint8(x / 3), B: uint8(y * 40), A: 255}
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			img := image.NewNRGBA(image.Rect(0, 0, tc.width, tc.height))
+			for y := 0; y < tc.height; y++ {
+				for x := 0; x < tc.width; x++ {
+					img.SetNRGBA(x, y, tc.pixel(x, y))
+				}
+			}
+			requireWebPRoundTrip(t, img)
+		})
+	}
+}
+
+func TestEncodeWebPSubImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	requireWebPRoundTrip(t, img.SubImage(image.Rect(3, 2, 9, 7)).(*image.NRGBA))
+}
+
+func TestHuffmanLengthsAreLimited(t *testing.T) {
+	// Fibonacci counts make the deepest possible Huffman trees
+	counts := make([]uint32, 30)
+	a, b := uint32(1), uint32(1)
+	for i := range counts {
+		counts[i] = a
+		a, b = b, a+b
+	}
+
+	lengths := huffmanLengths(counts, maxCodeLength)
+	kraft := 0.0
+	for _, length := range lengths {
+		require.NotZero(t, length)
+		require.LessOrEqual(t, length, uint8(maxCodeLength))
+		kraft += 1 / float64(uint(1)<<length)
+	}
+	require.Equal(t, 1.0, kraft)
+}
@@ -0,0 +1,128 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+)
+
+const (
+	marginLeft   = 50.0
+	marginRight  = pageWidth - 50.0
+	marginTop    = pageHeight - 60.0
+	marginBottom = 80.0
+	lineHeight   = 16.0
+)
+
+// Right edges of the numeric columns of the line item table
+const (
+	columnQuantity  = 360.0
+	columnUnitPrice = 455.0
+	columnAmount    = marginRight
+)
+
+// RenderPDF renders an issued invoice with its lines and tax breakdown as a PDF document
+func RenderPDF(inv db.Invoice, items []db.InvoiceItem, taxes []db.InvoiceTax) ([]byte, error) {
+	doc := newDocument()
+	y := marginTop
+
+	doc.text(marginLeft, y, fontBold, 18, "TAX INVOICE")
+	y -= 2 * lineHeight
+
+	doc.text(marginLeft, y, fontRegular, 10, "Invoice No: "+inv.InvoiceNumber)
+	doc.textRight(marginRight, y, fontRegular, 10, "Date: "+inv.IssuedAt.Format("02 Jan 2006"))
+	y -= lineHeight
+	doc.text(marginLeft, y, fontRegular, 10, "Order ID: "+strconv.FormatInt(inv.OrderID, 10))
+	y -= 2 * lineHeight
+
+	doc.text(marginLeft, y, fontBold, 10, "Bill To")
+	y -= lineHeight
+	for _, s := range []string{inv.CustomerName, inv.CustomerEmail, inv.CustomerPhone, inv.CustomerAddress} {
+		if s == "" {
+			continue
+		}
+		doc.text(marginLeft, y, fontRegular, 10, s)
+		y -= lineHeight
+	}
+	y -= lineHeight
+
+	header := func() {
+		doc.text(marginLeft, y, fontBold, 10, "Description")
+		doc.textRight(columnQuantity, y, fontBold, 10, "Qty")
+		doc.textRight(columnUnitPrice, y, fontBold, 10, "Unit Price")
+		doc.textRight(columnAmount, y, fontBold, 10, "Amount")
+		y -= 6
+		doc.line(marginLeft, y, marginRight, y)
+		y -= lineHeight
+	}
+	header()
+
+	for _, item := range items {
+		if y < marginBottom {
+			doc.addPage()
+			y = marginTop
+			header()
+		}
+		doc.text(marginLeft, y, fontRegular, 10, truncate(item.Description, 38))
+		doc.textRight(columnQuantity, y, fontRegular, 10, strconv.Itoa(int(item.Quantity)))
+		doc.textRight(columnUnitPrice, y, fontRegular, 10, formatAmount(item.UnitPrice))
+		doc.textRight(columnAmount, y, fontRegular, 10, formatAmount(item.Amount))
+		y -= lineHeight
+	}
+
+	// The totals block is kept on one page
+	if y-float64(len(taxes)+3)*lineHeight < marginBottom {
+		doc.addPage()
+		y = marginTop
+	}
+
+	y += lineHeight - 6
+	doc.line(marginLeft, y, marginRight, y)
+	y -= lineHeight
+
+	totalRow := func(label string, amount int64, font string) {
+		doc.textRight(columnUnitPrice, y, font, 10, label)
+		doc.textRight(columnAmount, y, font, 10, formatAmount(amount))
+		y -= lineHeight
+	}
+
+	totalRow("Subtotal", inv.Subtotal, fontRegular)
+	for _, tax := range taxes {
+		totalRow(fmt.Sprintf("%s (%s%%)", tax.TaxName, formatRate(tax.RateBps)), tax.TaxAmount, fontRegular)
+	}
+	totalRow("Total", inv.Total, fontBold)
+
+	var buf bytes.Buffer
+	if err := doc.writeTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// formatAmount groups thousands with commas, e.g. 1234567 -> 1,234,567
+func formatAmount(amount int64) string {
+	s := strconv.FormatInt(amount, 10)
+	sign := ""
+	if amount < 0 {
+		sign, s = "-", s[1:]
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return sign + s
+}
+
+// formatRate renders basis points as a percentage, e.g. 900 -> 9, 250 -> 2.5
+func formatRate(bps int32) string {
+	return strconv.FormatFloat(float64(bps)/100, 'f', -1, 64)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
@@ -0,0 +1,55 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	db "github.com/nexpictora-pvt-ltd/cnx-backend/db/sqlc"
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPDF(t *testing.T) {
+	inv := db.Invoice{
+		InvoiceID:     1,
+		InvoiceNumber: "INV-2026-000001",
+		OrderID:       2610191200001,
+		CustomerName:  "Asha (Corporate)",
+		CustomerEmail: util.RandomEmail(),
+		Subtotal:      1500,
+		TaxTotal:      270,
+		Total:         1770,
+		IssuedAt:      time.Now(),
+	}
+
+	var items []db.InvoiceItem
+	for i := 0; i < 60; i++ {
+		items = append(items, db.InvoiceItem{
+			Description: fmt.Sprintf("Service %d", i),
+			Quantity:    1,
+			UnitPrice:   25,
+			Amount:      25,
+		})
+	}
+	taxes := []db.InvoiceTax{
+		{TaxName: "CGST", RateBps: 900, TaxableAmount: 1500, TaxAmount: 135},
+		{TaxName: "SGST", RateBps: 900, TaxableAmount: 1500, TaxAmount: 135},
+	}
+
+	pdf, err := RenderPDF(inv, items, taxes)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	require.Contains(t, string(pdf), "(Invoice No: INV-2026-000001)")
+	require.Contains(t, string(pdf), `Asha \(Corporate\)`)
+	require.Contains(t, string(pdf), "/Count 2")
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "0", formatAmount(0))
+	require.Equal(t, "999", formatAmount(999))
+	require.Equal(t, "1,000", formatAmount(1000))
+	require.Equal(t, "-1,234,567", formatAmount(-1234567))
+}
@@ -0,0 +1,138 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 page size in PDF points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+)
+
+// Courier is used so text width is predictable without font metrics: every glyph is 600/1000 em wide
+const glyphWidth = 0.6
+
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// document is a minimal PDF 1.4 writer that supports text and lines with the
+// standard Courier fonts. That is all an invoice needs and keeps us free of a PDF dependency.
+type document struct {
+	pages []*bytes.Buffer
+}
+
+func newDocument() *document {
+	d := &document{}
+	d.addPage()
+	return d
+}
+
+func (d *document) addPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// text draws s with its baseline starting at x, y measured from the bottom left corner of the page
+func (d *document) text(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escapeText(s))
+}
+
+// textRight draws s so that it ends at x
+func (d *document) textRight(x, y float64, font string, size float64, s string) {
+	d.text(x-textWidth(s, size), y, font, size, s)
+}
+
+func (d *document) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.page(), "%.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+func textWidth(s string, size float64) float64 {
+	return float64(len([]rune(s))) * glyphWidth * size
+}
+
+// escapeText escapes a string for a PDF literal. Characters outside Latin-1 cannot be
+// shown with the standard fonts and are replaced with '?'.
+func escapeText(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			sb.WriteByte(' ')
+		case r < 0x20 || r > 0xff:
+			sb.WriteByte('?')
+		case r > 0x7e:
+			fmt.Fprintf(&sb, "\\%03o", r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// writeTo serialises the document. Objects are laid out as catalog, page tree, the two
+// fonts and then a page/content pair for every page, followed by the cross-reference table.
+func (d *document) writeTo(w io.Writer) error {
+	var buf bytes.Buffer
+	var offsets []int
+
+	beginObject := func() int {
+		offsets = append(offsets, buf.Len())
+		n := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n", n)
+		return n
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	const firstPageObject = 5
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObject+2*i)
+	}
+
+	beginObject()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+
+	beginObject()
+	fmt.Fprintf(&buf, "<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n", strings.Join(kids, " "), len(d.pages))
+
+	beginObject()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>\nendobj\n")
+
+	beginObject()
+	buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>\nendobj\n")
+
+	for _, content := range d.pages {
+		page := beginObject()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>\nendobj\n",
+			pageWidth, pageHeight, fontRegular, fontBold, page+1)
+
+		beginObject()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", content.Len())
+		buf.Write(content.Bytes())
+		buf.WriteString("endstream\nendobj\n")
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n", len(offsets)+1)
+	buf.WriteString("0000000000 65535 f \n")
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
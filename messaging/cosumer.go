@@ -66,3 +66,22 @@ func (c *Consumer) ConsumeMessages(queueName string) (<-chan amqp.Delivery, erro
 
 	return messages, nil
 }
+
+// ConsumeMessagesWithAck consumes messages that stay on the queue until they are acknowledged,
+// at most prefetch of them are delivered before earlier ones are acknowledged
+func (c *Consumer) ConsumeMessagesWithAck(queueName string, prefetch int) (<-chan amqp.Delivery, error) {
+	err := c.channel.Qos(prefetch, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.channel.Consume(
+		queueName, // queue name
+		"",        // consumer
+		false,     // auto-ack
+		false,     // exclusive
+		false,     // no-local
+		false,     // no-wait
+		nil,       // arguments
+	)
+}
@@ -3,7 +3,8 @@
 package messaging
 
 import (
-	"github.com/gin-gonic/gin"
+	"context"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -37,7 +38,7 @@ func (p *Publisher) Close() {
 	p.conn.Close()
 }
 
-func (p *Publisher) PublishMessage(queueName string, message []byte, ctx *gin.Context) error {
+func (p *Publisher) PublishMessage(queueName string, message []byte, ctx context.Context) error {
 	// Declare a queue
 	_, err := p.channel.QueueDeclare(
 		queueName, // queue name
@@ -0,0 +1,110 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// FakeSignatureHeader carries the signature of webhooks sent to the fake provider
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeProvider is an in-process gateway for tests and local development. Intents are
+// accepted without any network call and webhooks are signed with a shared secret.
+type FakeProvider struct {
+	webhookSecret string
+
+	mutex   sync.Mutex
+	intents int
+}
+
+// NewFakeProvider creates a new fake provider
+func NewFakeProvider(webhookSecret string) Provider {
+	return &FakeProvider{webhookSecret: webhookSecret}
+}
+
+func (provider *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (provider *FakeProvider) CreateIntent(ctx context.Context, arg IntentParams) (*Intent, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	provider.intents++
+	return &Intent{
+		Provider: provider.Name(),
+		ID:       fmt.Sprintf("fake_intent_%d_%d", arg.OrderID, provider.intents),
+		Amount:   arg.Amount,
+		Currency: arg.Currency,
+	}, nil
+}
+
+func (provider *FakeProvider) ResumeIntent(id string, amount int64, currency string) *Intent {
+	return &Intent{
+		Provider: provider.Name(),
+		ID:       id,
+		Amount:   amount,
+		Currency: currency,
+	}
+}
+
+type fakeWebhook struct {
+	IntentID  string `json:"intent_id"`
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status"`
+	Method    string `json:"method"`
+	Amount    int64  `json:"amount"`
+}
+
+// Sign returns the signature the fake provider expects for body
+func (provider *FakeProvider) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(provider.webhookSecret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Webhook builds a signed webhook body for event, as the gateway would deliver it
+func (provider *FakeProvider) Webhook(event Event) (http.Header, []byte, error) {
+	body, err := json.Marshal(fakeWebhook{
+		IntentID:  event.IntentID,
+		PaymentID: event.PaymentID,
+		Status:    event.Status,
+		Method:    event.Method,
+		Amount:    event.Amount,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set(FakeSignatureHeader, provider.Sign(body))
+	return header, body, nil
+}
+
+func (provider *FakeProvider) ParseWebhook(header http.Header, body []byte) (*Event, error) {
+	if !hmac.Equal([]byte(header.Get(FakeSignatureHeader)), []byte(provider.Sign(body))) {
+		return nil, ErrInvalidSignature
+	}
+
+	var webhook fakeWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, err
+	}
+	if webhook.Status != StatusCaptured && webhook.Status != StatusFailed {
+		return nil, ErrUnhandledEvent
+	}
+
+	return &Event{
+		IntentID:  webhook.IntentID,
+		PaymentID: webhook.PaymentID,
+		Status:    webhook.Status,
+		Method:    webhook.Method,
+		Amount:    webhook.Amount,
+	}, nil
+}
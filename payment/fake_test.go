@@ -0,0 +1,51 @@
+package payment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeProvider(t *testing.T) {
+	provider := NewFakeProvider(util.RandomString(32)).(*FakeProvider)
+
+	intent, err := provider.CreateIntent(context.Background(), IntentParams{
+		OrderID:  23101912,
+		Amount:   1770,
+		Currency: "INR",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "fake", intent.Provider)
+	require.NotEmpty(t, intent.ID)
+	require.Equal(t, int64(1770), intent.Amount)
+
+	header, body, err := provider.Webhook(Event{
+		IntentID:  intent.ID,
+		PaymentID: "fake_pay_1",
+		Status:    StatusCaptured,
+		Method:    "upi",
+		Amount:    intent.Amount,
+	})
+	require.NoError(t, err)
+
+	event, err := provider.ParseWebhook(header, body)
+	require.NoError(t, err)
+	require.Equal(t, intent.ID, event.IntentID)
+	require.Equal(t, "fake_pay_1", event.PaymentID)
+	require.Equal(t, StatusCaptured, event.Status)
+	require.Equal(t, "upi", event.Method)
+}
+
+func TestFakeProviderInvalidSignature(t *testing.T) {
+	provider := NewFakeProvider(util.RandomString(32)).(*FakeProvider)
+	other := NewFakeProvider(util.RandomString(32)).(*FakeProvider)
+
+	header, body, err := other.Webhook(Event{IntentID: "fake_intent_1", Status: StatusCaptured})
+	require.NoError(t, err)
+
+	event, err := provider.ParseWebhook(header, body)
+	require.EqualError(t, err, ErrInvalidSignature.Error())
+	require.Nil(t, event)
+}
@@ -0,0 +1,58 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrUnhandledEvent   = errors.New("webhook event is not handled")
+)
+
+// Status reported by a gateway for a payment attempt
+const (
+	StatusCaptured = "captured"
+	StatusFailed   = "failed"
+)
+
+// Provider is an online payment gateway
+type Provider interface {
+	// Name identifies the provider on the payments stored in the ledger
+	Name() string
+
+	// CreateIntent registers an amount to be collected for an order with the gateway
+	CreateIntent(ctx context.Context, arg IntentParams) (*Intent, error)
+
+	// ResumeIntent describes an intent created earlier so a client can complete it
+	ResumeIntent(id string, amount int64, currency string) *Intent
+
+	// ParseWebhook verifies the signature of a webhook delivery and extracts the payment event from it
+	ParseWebhook(header http.Header, body []byte) (*Event, error)
+}
+
+type IntentParams struct {
+	OrderID  int64
+	Amount   int64
+	Currency string
+}
+
+// Intent is what a client needs to complete the payment with the gateway's checkout
+type Intent struct {
+	Provider string `json:"provider"`
+	ID       string `json:"id"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	// PublicKey is the publishable key the checkout has to be opened with, if the gateway needs one
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+// Event is a change in the state of a payment reported by a gateway
+type Event struct {
+	IntentID  string
+	PaymentID string
+	Status    string
+	Method    string
+	Amount    int64
+}
@@ -0,0 +1,170 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const razorpayAPI = "https://api.razorpay.com/v1"
+
+// RazorpayProvider collects payments through Razorpay Orders
+type RazorpayProvider struct {
+	keyID         string
+	keySecret     string
+	webhookSecret string
+	baseURL       string
+	client        *http.Client
+}
+
+// NewRazorpayProvider creates a new Razorpay provider
+func NewRazorpayProvider(keyID, keySecret, webhookSecret string) (Provider, error) {
+	if keyID == "" || keySecret == "" || webhookSecret == "" {
+		return nil, errors.New("razorpay key id, key secret and webhook secret are required")
+	}
+
+	provider := &RazorpayProvider{
+		keyID:         keyID,
+		keySecret:     keySecret,
+		webhookSecret: webhookSecret,
+		baseURL:       razorpayAPI,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+	return provider, nil
+}
+
+func (provider *RazorpayProvider) Name() string {
+	return "razorpay"
+}
+
+type razorpayOrder struct {
+	ID       string `json:"id"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type razorpayError struct {
+	Error struct {
+		Code        string `json:"code"`
+		Description string `json:"description"`
+	} `json:"error"`
+}
+
+// CreateIntent creates a Razorpay order. Razorpay reads the amount in the smallest currency unit,
+// which is the unit prices are stored in.
+func (provider *RazorpayProvider) CreateIntent(ctx context.Context, arg IntentParams) (*Intent, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"amount":   arg.Amount,
+		"currency": arg.Currency,
+		"receipt":  strconv.FormatInt(arg.OrderID, 10),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.baseURL+"/orders", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(provider.keyID, provider.keySecret)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := provider.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var apiErr razorpayError
+		if err := json.NewDecoder(res.Body).Decode(&apiErr); err != nil || apiErr.Error.Description == "" {
+			return nil, fmt.Errorf("razorpay: unexpected status %d", res.StatusCode)
+		}
+		return nil, fmt.Errorf("razorpay: %s", apiErr.Error.Description)
+	}
+
+	var order razorpayOrder
+	if err := json.NewDecoder(res.Body).Decode(&order); err != nil {
+		return nil, err
+	}
+
+	return &Intent{
+		Provider:  provider.Name(),
+		ID:        order.ID,
+		Amount:    order.Amount,
+		Currency:  order.Currency,
+		PublicKey: provider.keyID,
+	}, nil
+}
+
+// ResumeIntent describes a Razorpay order created earlier, its checkout can be opened again until it is paid
+func (provider *RazorpayProvider) ResumeIntent(id string, amount int64, currency string) *Intent {
+	return &Intent{
+		Provider:  provider.Name(),
+		ID:        id,
+		Amount:    amount,
+		Currency:  currency,
+		PublicKey: provider.keyID,
+	}
+}
+
+type razorpayWebhook struct {
+	Event   string `json:"event"`
+	Payload struct {
+		Payment struct {
+			Entity struct {
+				ID      string `json:"id"`
+				OrderID string `json:"order_id"`
+				Amount  int64  `json:"amount"`
+				Method  string `json:"method"`
+			} `json:"entity"`
+		} `json:"payment"`
+	} `json:"payload"`
+}
+
+// ParseWebhook verifies the X-Razorpay-Signature header, a hex encoded HMAC-SHA256 of the
+// raw body keyed with the webhook secret, and handles payment.captured and payment.failed events
+func (provider *RazorpayProvider) ParseWebhook(header http.Header, body []byte) (*Event, error) {
+	signature, err := hex.DecodeString(header.Get("X-Razorpay-Signature"))
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(provider.webhookSecret))
+	mac.Write(body)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var webhook razorpayWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, err
+	}
+
+	var status string
+	switch webhook.Event {
+	case "payment.captured":
+		status = StatusCaptured
+	case "payment.failed":
+		status = StatusFailed
+	default:
+		return nil, ErrUnhandledEvent
+	}
+
+	entity := webhook.Payload.Payment.Entity
+	return &Event{
+		IntentID:  entity.OrderID,
+		PaymentID: entity.ID,
+		Status:    status,
+		Method:    entity.Method,
+		Amount:    entity.Amount,
+	}, nil
+}
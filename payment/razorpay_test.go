@@ -0,0 +1,78 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nexpictora-pvt-ltd/cnx-backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRazorpayCreateIntent(t *testing.T) {
+	keyID, keySecret := "rzp_test_"+util.RandomString(8), util.RandomString(16)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/orders", r.URL.Path)
+
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, keyID, user)
+		require.Equal(t, keySecret, pass)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, float64(1770), body["amount"])
+		require.Equal(t, "42", body["receipt"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"order_abc","amount":1770,"currency":"INR","status":"created"}`))
+	}))
+	defer server.Close()
+
+	maker, err := NewRazorpayProvider(keyID, keySecret, util.RandomString(16))
+	require.NoError(t, err)
+	provider := maker.(*RazorpayProvider)
+	provider.baseURL = server.URL
+
+	intent, err := provider.CreateIntent(context.Background(), IntentParams{OrderID: 42, Amount: 1770, Currency: "INR"})
+	require.NoError(t, err)
+	require.Equal(t, "order_abc", intent.ID)
+	require.Equal(t, "razorpay", intent.Provider)
+	require.Equal(t, keyID, intent.PublicKey)
+}
+
+func TestRazorpayParseWebhook(t *testing.T) {
+	secret := util.RandomString(16)
+	provider, err := NewRazorpayProvider("rzp_test_key", "secret", secret)
+	require.NoError(t, err)
+
+	body := []byte(`{"event":"payment.captured","payload":{"payment":{"entity":{"id":"pay_1","order_id":"order_abc","amount":1770,"method":"upi"}}}}`)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	header := http.Header{}
+	header.Set("X-Razorpay-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	event, err := provider.ParseWebhook(header, body)
+	require.NoError(t, err)
+	require.Equal(t, "order_abc", event.IntentID)
+	require.Equal(t, "pay_1", event.PaymentID)
+	require.Equal(t, StatusCaptured, event.Status)
+	require.Equal(t, int64(1770), event.Amount)
+
+	header.Set("X-Razorpay-Signature", hex.EncodeToString([]byte("tampered")))
+	_, err = provider.ParseWebhook(header, body)
+	require.EqualError(t, err, ErrInvalidSignature.Error())
+}
+
+func TestNewRazorpayProviderMissingKeys(t *testing.T) {
+	provider, err := NewRazorpayProvider("", "secret", "webhook")
+	require.Error(t, err)
+	require.Nil(t, provider)
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage keeps objects as files in a directory for local development.
+// The server serves the directory itself at the base URL.
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStorage creates a storage in dir, creating the directory if needed
+func NewLocalStorage(dir, baseURL string) (Storage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir: dir, baseURL: baseURL}, nil
+}
+
+func (storage *LocalStorage) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(storage.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first, so readers never see a partly written object
+func (storage *LocalStorage) Put(ctx context.Context, key string, contentType string, body io.Reader) error {
+	name, err := storage.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), name)
+}
+
+func (storage *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := storage.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (storage *LocalStorage) Delete(ctx context.Context, key string) error {
+	name, err := storage.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (storage *LocalStorage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	name, err := storage.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	info, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Size: info.Size()}, nil
+}
+
+func (storage *LocalStorage) URL(key string) string {
+	return objectURL(storage.baseURL, key)
+}
+
+func (storage *LocalStorage) Key(url string) (string, bool) {
+	return objectKey(storage.baseURL, url)
+}
+
+// Dir is the directory the objects are kept in
+func (storage *LocalStorage) Dir() string {
+	return storage.dir
+}
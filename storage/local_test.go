@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/files/")
+	require.NoError(t, err)
+
+	key := "orders/1/invoice copy.pdf"
+	require.NoError(t, store.Put(context.Background(), key, "application/pdf", strings.NewReader("first")))
+	require.NoError(t, store.Put(context.Background(), key, "application/pdf", strings.NewReader("second")))
+
+	body, err := store.Get(context.Background(), key)
+	require.NoError(t, err)
+	content, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	require.Equal(t, "second", string(content))
+
+	info, err := store.Stat(context.Background(), key)
+	require.NoError(t, err)
+	require.Equal(t, int64(len("second")), info.Size)
+
+	require.Equal(t, "http://localhost:8080/files/orders/1/invoice%20copy.pdf", store.URL(key))
+	storedKey, ok := store.Key(store.URL(key))
+	require.True(t, ok)
+	require.Equal(t, key, storedKey)
+	_, ok = store.Key("https://example.com/orders/1/invoice.pdf")
+	require.False(t, ok)
+	_, ok = store.Key("http://localhost:8080/files/orders/../../app.env")
+	require.False(t, ok)
+
+	require.NoError(t, store.Delete(context.Background(), key))
+	_, err = store.Get(context.Background(), key)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Stat(context.Background(), key)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting twice is fine
+	require.NoError(t, store.Delete(context.Background(), key))
+}
+
+func TestLocalStorageInvalidKey(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "/files")
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../app.env", "orders/../../app.env", "orders//1", "orders\\1"} {
+		err := store.Put(context.Background(), key, "", strings.NewReader("x"))
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+
+		_, err = store.Get(context.Background(), key)
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+type S3Config struct {
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	// Endpoint points the client at an S3 compatible server such as MinIO, empty means AWS
+	Endpoint string
+	// PublicURL is the base URL objects are downloaded from, it defaults to the bucket URL
+	PublicURL string
+	// PresignEndpoint is the endpoint presigned URLs point at when clients reach the server
+	// under another address than the API does, such as MinIO in docker compose
+	PresignEndpoint string
+}
+
+// S3Storage keeps objects in an S3 bucket
+type S3Storage struct {
+	client *s3.S3
+	// presignClient only signs URLs, it never sends requests itself
+	presignClient *s3.S3
+	uploader      *s3manager.Uploader
+	bucket        string
+	publicURL     string
+}
+
+// NewS3Storage creates a storage for the configured bucket
+func NewS3Storage(config S3Config) (Storage, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+
+	awsConfig := aws.Config{
+		Region:      aws.String(config.Region),
+		Credentials: credentials.NewStaticCredentials(config.AccessKey, config.SecretKey, ""),
+	}
+	publicURL := fmt.Sprintf("https://%s.s3.amazonaws.com", config.Bucket)
+	if config.Endpoint != "" {
+		// S3 compatible servers are addressed by path rather than by bucket host name
+		awsConfig.Endpoint = aws.String(config.Endpoint)
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+		publicURL = objectURL(config.Endpoint, config.Bucket)
+	}
+	if config.PublicURL != "" {
+		publicURL = config.PublicURL
+	}
+
+	awsSession, err := session.NewSessionWithOptions(session.Options{Config: awsConfig})
+	if err != nil {
+		return nil, err
+	}
+
+	presignClient := s3.New(awsSession)
+	if config.PresignEndpoint != "" {
+		presignClient = s3.New(awsSession, &aws.Config{
+			Endpoint:         aws.String(config.PresignEndpoint),
+			S3ForcePathStyle: aws.Bool(true),
+		})
+	}
+
+	return &S3Storage{
+		client:        s3.New(awsSession),
+		presignClient: presignClient,
+		uploader:      s3manager.NewUploader(awsSession),
+		bucket:        config.Bucket,
+		publicURL:     publicURL,
+	}, nil
+}
+
+func (storage *S3Storage) Put(ctx context.Context, key string, contentType string, body io.Reader) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(storage.bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	_, err := storage.uploader.UploadWithContext(ctx, input)
+	return err
+}
+
+func (storage *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	output, err := storage.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(storage.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func (storage *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	_, err := storage.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(storage.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (storage *S3Storage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	if err := checkKey(key); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	output, err := storage.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(storage.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		// HEAD responses have no body, so a missing object only comes back as a 404
+		var awsErr awserr.RequestFailure
+		if errors.As(err, &awsErr) && awsErr.StatusCode() == http.StatusNotFound {
+			return ObjectInfo{}, ErrNotFound
+		}
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Size: aws.Int64Value(output.ContentLength)}, nil
+}
+
+// PresignPut signs the content type and length into the URL, so S3 rejects any other upload
+func (storage *S3Storage) PresignPut(key string, contentType string, size int64, expires time.Duration) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+
+	req, _ := storage.presignClient.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:        aws.String(storage.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	})
+	return req.Presign(expires)
+}
+
+func (storage *S3Storage) URL(key string) string {
+	return objectURL(storage.publicURL, key)
+}
+
+func (storage *S3Storage) Key(url string) (string, bool) {
+	return objectKey(storage.publicURL, url)
+}
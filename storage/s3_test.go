@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3StorageURL(t *testing.T) {
+	store, err := NewS3Storage(S3Config{Bucket: "ctt-test-001", Region: "ap-south-1"})
+	require.NoError(t, err)
+	require.Equal(t, "https://ctt-test-001.s3.amazonaws.com/services/a.jpg", store.URL("services/a.jpg"))
+
+	store, err = NewS3Storage(S3Config{Bucket: "ctt-test-001", Region: "us-east-1", Endpoint: "http://minio:9000"})
+	require.NoError(t, err)
+	require.Equal(t, "http://minio:9000/ctt-test-001/services/a.jpg", store.URL("services/a.jpg"))
+
+	_, err = NewS3Storage(S3Config{Region: "ap-south-1"})
+	require.Error(t, err)
+}
+
+func TestS3StoragePresignPut(t *testing.T) {
+	store, err := NewS3Storage(S3Config{
+		Bucket:          "ctt-test-001",
+		Region:          "us-east-1",
+		AccessKey:       "minio",
+		SecretKey:       "minio-secret",
+		Endpoint:        "http://minio:9000",
+		PresignEndpoint: "http://localhost:9000",
+	})
+	require.NoError(t, err)
+
+	presignURL, err := store.(Presigner).PresignPut("uploads/a.jpg", "image/jpeg", 1024, 15*time.Minute)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(presignURL, "http://localhost:9000/ctt-test-001/uploads/a.jpg?"), presignURL)
+
+	query, err := url.Parse(presignURL)
+	require.NoError(t, err)
+	signedHeaders := query.Query().Get("X-Amz-SignedHeaders")
+	require.Contains(t, signedHeaders, "content-length")
+	require.Contains(t, signedHeaders, "content-type")
+	require.Equal(t, "900", query.Query().Get("X-Amz-Expires"))
+
+	_, err = store.(Presigner).PresignPut("../a.jpg", "image/jpeg", 1024, time.Minute)
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Storage keeps files such as service images, order attachments, invoices and exports
+type Storage interface {
+	// Put stores body under key, replacing the object already stored under it
+	Put(ctx context.Context, key string, contentType string, body io.Reader) error
+
+	// Get opens the object stored under key, the caller has to close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object stored under key, deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+
+	// Stat describes the object stored under key without reading it
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+
+	// URL is where clients download the object stored under key from
+	URL(key string) string
+
+	// Key returns the key of the object at a URL returned by URL, it is false for URLs of anything else
+	Key(url string) (string, bool)
+}
+
+type ObjectInfo struct {
+	Size int64
+}
+
+// Presigner is implemented by storages clients can upload to directly, without going through the API
+type Presigner interface {
+	// PresignPut returns a URL that accepts a single PUT of exactly size bytes with the given content type
+	PresignPut(key string, contentType string, size int64, expires time.Duration) (string, error)
+}
+
+// checkKey rejects keys that are empty, absolute or reach outside of the storage, such as "../app.env".
+// Keys are slash separated on every backend.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// objectKey is the inverse of objectURL
+func objectKey(baseURL, objectURL string) (string, bool) {
+	prefix := strings.TrimRight(baseURL, "/") + "/"
+	if !strings.HasPrefix(objectURL, prefix) {
+		return "", false
+	}
+
+	key, err := url.PathUnescape(strings.TrimPrefix(objectURL, prefix))
+	if err != nil || checkKey(key) != nil {
+		return "", false
+	}
+	return key, true
+}
+
+// objectURL joins the base URL of a storage and a key, escaping every segment of the key
+func objectURL(baseURL, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + strings.Join(segments, "/")
+}
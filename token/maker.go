@@ -5,8 +5,8 @@ import (
 )
 
 type Maker interface {
-	//CreateToken creates a new token for a specific email and duration
-	CreateToken(email string, duration time.Duration) (string, *Payload, error)
+	//CreateToken creates a new token for a specific account and duration
+	CreateToken(email string, accountKind string, accountID int32, duration time.Duration) (string, *Payload, error)
 
 	//VerifyToken checks if the input token is valid or not
 	VerifyToken(token string) (*Payload, error)
@@ -28,9 +28,9 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 
 }
 
-// CreateToken creates a new token for a specific email and duration
-func (maker *PasetoMaker) CreateToken(email string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(email, duration)
+// CreateToken creates a new token for a specific account and duration
+func (maker *PasetoMaker) CreateToken(email string, accountKind string, accountID int32, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(email, accountKind, accountID, duration)
 	if err != nil {
 		return "", payload, err
 	}
@@ -13,26 +13,37 @@ var (
 	ErrInvalidToken = errors.New("token is invalid")
 )
 
+// Kinds of accounts a token can be issued to, admins and customers are kept in separate tables
+// and can share an email, so the kind and the ID of the account identify who the token belongs to
+const (
+	AccountAdmin = "admin"
+	AccountUser  = "user"
+)
+
 // Payload contains the payload data of the token
 type Payload struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	IssuedAt  time.Time `json:"issued_at"`
-	ExpiredAt time.Time `json:"expired_at"`
+	ID          uuid.UUID `json:"id"`
+	Email       string    `json:"email"`
+	AccountKind string    `json:"account_kind"`
+	AccountID   int32     `json:"account_id"`
+	IssuedAt    time.Time `json:"issued_at"`
+	ExpiredAt   time.Time `json:"expired_at"`
 }
 
-// NewPayload creates a new token payload with specific email and duration
-func NewPayload(email string, duration time.Duration) (*Payload, error) {
+// NewPayload creates a new token payload for a specific account and duration
+func NewPayload(email string, accountKind string, accountID int32, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
 
 	payload := &Payload{
-		ID:        tokenID,
-		Email:     email,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		ID:          tokenID,
+		Email:       email,
+		AccountKind: accountKind,
+		AccountID:   accountID,
+		IssuedAt:    time.Now(),
+		ExpiredAt:   time.Now().Add(duration),
 	}
 	return payload, nil
 }
@@ -0,0 +1,46 @@
+package util
+
+import "sort"
+
+// SpreadAmount splits amount over lines in proportion to their weights, such as the list prices
+// of the services of a bundle. The shares always add up to amount, the cents left over by rounding
+// go to the lines with the largest remainders. Lines share equally when no line has a weight.
+func SpreadAmount(amount int64, weights []int64) []int64 {
+	shares := make([]int64, len(weights))
+	if len(weights) == 0 {
+		return shares
+	}
+
+	var total int64
+	for _, weight := range weights {
+		total += weight
+	}
+	if total == 0 {
+		weights = make([]int64, len(weights))
+		for i := range weights {
+			weights[i] = 1
+		}
+		total = int64(len(weights))
+	}
+
+	remainders := make([]int64, len(weights))
+	var spread int64
+	for i, weight := range weights {
+		shares[i] = amount * weight / total
+		remainders[i] = amount * weight % total
+		spread += shares[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for i := 0; spread < amount; i++ {
+		shares[order[i%len(order)]]++
+		spread++
+	}
+	return shares
+}
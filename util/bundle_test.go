@@ -0,0 +1,39 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpreadAmount(t *testing.T) {
+	testCases := []struct {
+		name    string
+		amount  int64
+		weights []int64
+		shares  []int64
+	}{
+		{"proportional", 800, []int64{500, 500}, []int64{400, 400}},
+		{"discount", 900, []int64{300, 300, 400}, []int64{270, 270, 360}},
+		{"rounding", 100, []int64{1, 1, 1}, []int64{34, 33, 33}},
+		{"largest remainder", 10, []int64{1, 2}, []int64{3, 7}},
+		{"no weights", 10, []int64{0, 0, 0, 0}, []int64{3, 3, 2, 2}},
+		{"free", 0, []int64{100, 200}, []int64{0, 0}},
+		{"empty", 100, nil, []int64{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shares := SpreadAmount(tc.amount, tc.weights)
+			require.Equal(t, tc.shares, shares)
+
+			var sum int64
+			for _, share := range shares {
+				sum += share
+			}
+			if len(tc.weights) > 0 {
+				require.Equal(t, tc.amount, sum)
+			}
+		})
+	}
+}
@@ -7,15 +7,40 @@ import (
 )
 
 type Config struct {
-	DBDriver             string        `mapstructure:"DB_DRIVER"`
-	DBSource             string        `mapstructure:"DB_SOURCE"`
-	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
-	AWSS3Bucket          string        `mapstructure:"AWS_S3_BUCKET"`
-	AWSAccessKey         string        `mapstructure:"AWS_ACCESS_KEY"`
-	AWSSecretKey         string        `mapstructure:"AWS_SECRET_KEY"`
+	DBDriver                  string        `mapstructure:"DB_DRIVER"`
+	DBSource                  string        `mapstructure:"DB_SOURCE"`
+	ServerAddress             string        `mapstructure:"SERVER_ADDRESS"`
+	TokenSymmetricKey         string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration       time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration      time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	AWSS3Bucket               string        `mapstructure:"AWS_S3_BUCKET"`
+	AWSAccessKey              string        `mapstructure:"AWS_ACCESS_KEY"`
+	AWSSecretKey              string        `mapstructure:"AWS_SECRET_KEY"`
+	AWSRegion                 string        `mapstructure:"AWS_REGION"`
+	AWSS3Endpoint             string        `mapstructure:"AWS_S3_ENDPOINT"`
+	StorageBackend            string        `mapstructure:"STORAGE_BACKEND"`
+	StorageLocalDir           string        `mapstructure:"STORAGE_LOCAL_DIR"`
+	StoragePublicURL          string        `mapstructure:"STORAGE_PUBLIC_URL"`
+	UploadMaxImageSize        int64         `mapstructure:"UPLOAD_MAX_IMAGE_SIZE"`
+	UploadMaxAttachmentSize   int64         `mapstructure:"UPLOAD_MAX_ATTACHMENT_SIZE"`
+	UploadURLExpiry           time.Duration `mapstructure:"UPLOAD_URL_EXPIRY"`
+	AWSS3PresignEndpoint      string        `mapstructure:"AWS_S3_PRESIGN_ENDPOINT"`
+	InvoiceTaxRates           string        `mapstructure:"INVOICE_TAX_RATES"`
+	RabbitMQURL               string        `mapstructure:"RABBITMQ_URL"`
+	PaymentProvider           string        `mapstructure:"PAYMENT_PROVIDER"`
+	PaymentCurrency           string        `mapstructure:"PAYMENT_CURRENCY"`
+	PaymentWebhookSecret      string        `mapstructure:"PAYMENT_WEBHOOK_SECRET"`
+	RazorpayKeyID             string        `mapstructure:"RAZORPAY_KEY_ID"`
+	RazorpayKeySecret         string        `mapstructure:"RAZORPAY_KEY_SECRET"`
+	TimeZone                  string        `mapstructure:"TIME_ZONE"`
+	SLACheckInterval          time.Duration `mapstructure:"SLA_CHECK_INTERVAL"`
+	SLAAtRiskWindow           time.Duration `mapstructure:"SLA_AT_RISK_WINDOW"`
+	RatingEditWindow          time.Duration `mapstructure:"RATING_EDIT_WINDOW"`
+	SubscriptionCheckInterval time.Duration `mapstructure:"SUBSCRIPTION_CHECK_INTERVAL"`
+	PriceCheckInterval        time.Duration `mapstructure:"PRICE_CHECK_INTERVAL"`
+	ImageWorkerEnabled        bool          `mapstructure:"IMAGE_WORKER_ENABLED"`
+	ExportAsyncThreshold      int64         `mapstructure:"EXPORT_ASYNC_THRESHOLD"`
+	IdempotencyKeyTTL         time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
 }
 
 // LoadConfig reads configurations from file or environment variable
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "30 9 * * 1", "0 8 1,15 * *", "*/15 9-17 * * 1-5", "0 0 * * 7"} {
+		_, err := ParseCron(expr)
+		require.NoError(t, err, expr)
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := ParseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Monday 19 October 2026
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 19, 10, 1, 0, 0, time.UTC)},
+		{"30 9 * * 1", time.Date(2026, 10, 26, 9, 30, 0, 0, time.UTC)},
+		{"0 8 1,15 * *", time.Date(2026, 11, 1, 8, 0, 0, 0, time.UTC)},
+		{"*/15 9-17 * * 1-5", time.Date(2026, 10, 19, 10, 15, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted
+		{"0 12 20 * 5", time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			schedule, err := ParseCron(tc.expr)
+			require.NoError(t, err)
+			require.Equal(t, tc.next, schedule.Next(start))
+		})
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	loc := time.FixedZone("IST", 5*60*60+30*60)
+	last := time.Date(2026, 1, 31, 9, 0, 0, 0, loc)
+
+	next, err := NextRun(FrequencyWeekly, "", last, last, loc)
+	require.NoError(t, err)
+	require.True(t, next.Equal(time.Date(2026, 2, 7, 9, 0, 0, 0, loc)))
+
+	next, err = NextRun(FrequencyBiweekly, "", last, last, loc)
+	require.NoError(t, err)
+	require.True(t, next.Equal(time.Date(2026, 2, 14, 9, 0, 0, 0, loc)))
+
+	// A subscription anchored on the 31st runs on the last day of shorter months and gets back to the 31st
+	next, err = NextRun(FrequencyMonthly, "", last, last, loc)
+	require.NoError(t, err)
+	require.True(t, next.Equal(time.Date(2026, 2, 28, 9, 0, 0, 0, loc)))
+
+	next, err = NextRun(FrequencyMonthly, "", next, last, loc)
+	require.NoError(t, err)
+	require.True(t, next.Equal(time.Date(2026, 3, 31, 9, 0, 0, 0, loc)))
+
+	next, err = NextRun(FrequencyMonthly, "", time.Date(2026, 3, 31, 9, 0, 0, 0, loc), last, loc)
+	require.NoError(t, err)
+	require.True(t, next.Equal(time.Date(2026, 4, 30, 9, 0, 0, 0, loc)))
+
+	next, err = NextRun(FrequencyMonthly, "", time.Date(2027, 12, 15, 9, 0, 0, 0, loc), time.Date(2027, 1, 15, 9, 0, 0, 0, loc), loc)
+	require.NoError(t, err)
+	require.True(t, next.Equal(time.Date(2028, 1, 15, 9, 0, 0, 0, loc)))
+
+	next, err = NextRun(FrequencyCron, "0 9 * * 1", last, last, loc)
+	require.NoError(t, err)
+	require.True(t, next.Equal(time.Date(2026, 2, 2, 9, 0, 0, 0, loc)))
+
+	_, err = NextRun("daily", "", last, last, loc)
+	require.Error(t, err)
+
+	require.NoError(t, ValidateSchedule(FrequencyCron, "0 9 * * 1"))
+	require.Error(t, ValidateSchedule(FrequencyCron, "0 0 31 2 *"))
+	require.Error(t, ValidateSchedule(FrequencyCron, ""))
+}
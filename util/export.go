@@ -0,0 +1,22 @@
+package util
+
+// Data that can be exported
+const (
+	ExportOrders   = "orders"
+	ExportUsers    = "users"
+	ExportServices = "services"
+)
+
+// Status of an export running in the background
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+)
+
+// What importing a row did to the existing data
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+)
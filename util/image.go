@@ -0,0 +1,152 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrMalformedImage = errors.New("malformed image")
+
+// StripImageMetadata removes EXIF, XMP and text metadata such as camera details and GPS positions
+// from JPEG, PNG and WebP images while leaving the pixels untouched. Other content types are returned as they are.
+func StripImageMetadata(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEGMetadata(data)
+	case "image/png":
+		return stripPNGMetadata(data)
+	case "image/webp":
+		return stripWebPMetadata(data)
+	default:
+		return data, nil
+	}
+}
+
+// JPEG markers holding metadata: APP1 (EXIF and XMP), APP13 (IPTC) and comments
+var jpegMetadataMarkers = map[byte]bool{0xE1: true, 0xED: true, 0xFE: true}
+
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrMalformedImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	for i := 2; i < len(data); {
+		if data[i] != 0xFF {
+			return nil, ErrMalformedImage
+		}
+		// Markers can be padded with any number of 0xFF bytes
+		for i < len(data) && data[i] == 0xFF {
+			i++
+		}
+		if i == len(data) {
+			return nil, ErrMalformedImage
+		}
+		marker := data[i]
+		i++
+
+		// Standalone markers carry no segment
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD8) {
+			out.Write([]byte{0xFF, marker})
+			continue
+		}
+		if marker == 0xD9 {
+			out.Write([]byte{0xFF, marker})
+			return out.Bytes(), nil
+		}
+
+		if i+2 > len(data) {
+			return nil, ErrMalformedImage
+		}
+		end := i + int(binary.BigEndian.Uint16(data[i:]))
+		if end > len(data) || end < i+2 {
+			return nil, ErrMalformedImage
+		}
+
+		// The compressed image data follows the start of scan, it has no metadata left
+		if marker == 0xDA {
+			out.Write([]byte{0xFF, marker})
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+
+		if !jpegMetadataMarkers[marker] {
+			out.Write([]byte{0xFF, marker})
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return out.Bytes(), nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// PNG chunks holding metadata
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrMalformedImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+	for i := len(pngSignature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrMalformedImage
+		}
+		// A chunk is its length, type, data and a CRC
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end > len(data) || end < i+12 {
+			return nil, ErrMalformedImage
+		}
+
+		if !pngMetadataChunks[string(data[i+4:i+8])] {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return out.Bytes(), nil
+}
+
+// VP8X flags telling readers an EXIF or XMP chunk follows
+const webpMetadataFlags = 0x08 | 0x04
+
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrMalformedImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrMalformedImage
+		}
+		// Chunks are padded to an even size
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2
+		if end > len(data) || end < i+8 {
+			return nil, ErrMalformedImage
+		}
+
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= webpMetadataFlags
+			}
+			out.Write(chunk)
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+
+	stripped := out.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+	return stripped, nil
+}
@@ -0,0 +1,102 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i)
+	}
+	return img
+}
+
+func TestStripJPEGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, testImage(), nil))
+	encoded := buf.Bytes()
+
+	exif := append([]byte("Exif\x00\x00"), []byte("GPS 12.97,77.59")...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(exif)+2))
+	data := append(append(append([]byte{}, encoded[:2]...), append(segment, exif...)...), encoded[2:]...)
+
+	stripped, err := StripImageMetadata(data, "image/jpeg")
+	require.NoError(t, err)
+	require.NotContains(t, string(stripped), "GPS")
+	require.Equal(t, encoded, stripped)
+
+	_, err = jpeg.Decode(bytes.NewReader(stripped))
+	require.NoError(t, err)
+
+	_, err = StripImageMetadata([]byte("not a jpeg"), "image/jpeg")
+	require.ErrorIs(t, err, ErrMalformedImage)
+}
+
+func TestStripPNGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage()))
+	encoded := buf.Bytes()
+
+	text := []byte("Comment\x00GPS 12.97,77.59")
+	chunk := make([]byte, 8, 12+len(text))
+	binary.BigEndian.PutUint32(chunk, uint32(len(text)))
+	copy(chunk[4:], "tEXt")
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	// The text chunk goes right before IEND
+	iend := len(encoded) - 12
+	data := append(append(append([]byte{}, encoded[:iend]...), chunk...), encoded[iend:]...)
+	_, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	stripped, err := StripImageMetadata(data, "image/png")
+	require.NoError(t, err)
+	require.Equal(t, encoded, stripped)
+}
+
+func TestStripWebPMetadata(t *testing.T) {
+	chunk := func(fourCC string, data []byte) []byte {
+		c := append([]byte(fourCC), 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(c[4:], uint32(len(data)))
+		c = append(c, data...)
+		if len(data)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	riff := func(chunks ...[]byte) []byte {
+		body := []byte("WEBP")
+		for _, c := range chunks {
+			body = append(body, c...)
+		}
+		data := append([]byte("RIFF"), 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(data[4:], uint32(len(body)))
+		return append(data, body...)
+	}
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpMetadataFlags
+	data := riff(chunk("VP8X", vp8x), chunk("VP8 ", []byte("frame")), chunk("EXIF", []byte("GPS 12.97,77.59")), chunk("XMP ", []byte("<x/>")))
+
+	stripped, err := StripImageMetadata(data, "image/webp")
+	require.NoError(t, err)
+	require.Equal(t, riff(chunk("VP8X", make([]byte, 10)), chunk("VP8 ", []byte("frame"))), stripped)
+}
+
+func TestStripImageMetadataOtherTypes(t *testing.T) {
+	data := []byte("%PDF-1.4")
+	stripped, err := StripImageMetadata(data, "application/pdf")
+	require.NoError(t, err)
+	require.Equal(t, data, stripped)
+}
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaxRate is a single tax component applied to an invoice, e.g. CGST at 9%
+type TaxRate struct {
+	Name        string
+	BasisPoints int32
+}
+
+// ParseTaxRates parses a comma separated list of NAME:PERCENT pairs such as "CGST:9,SGST:9"
+func ParseTaxRates(s string) ([]TaxRate, error) {
+	var rates []TaxRate
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, percent, found := strings.Cut(part, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid tax rate %q", part)
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid tax percentage in %q", part)
+		}
+
+		rates = append(rates, TaxRate{
+			Name:        strings.TrimSpace(name),
+			BasisPoints: int32(math.Round(value * 100)),
+		})
+	}
+	return rates, nil
+}
+
+// TaxAmount returns the tax due on amount at the given rate, rounded half up
+func TaxAmount(amount int64, basisPoints int32) int64 {
+	return (amount*int64(basisPoints) + 5000) / 10000
+}
+
+// InvoiceSeries returns the numbering series an invoice issued at t belongs to.
+// Numbers restart at 1 for every calendar year.
+func InvoiceSeries(t time.Time) string {
+	return strconv.Itoa(t.Year())
+}
+
+// FormatInvoiceNumber generates the invoice number in the format: INV-YYYY-NNNNNN
+func FormatInvoiceNumber(series string, number int64) string {
+	return fmt.Sprintf("INV-%s-%06d", series, number)
+}
@@ -0,0 +1,43 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTaxRates(t *testing.T) {
+	rates, err := ParseTaxRates("CGST:9, SGST:9,CESS:0.5")
+	require.NoError(t, err)
+	require.Equal(t, []TaxRate{
+		{Name: "CGST", BasisPoints: 900},
+		{Name: "SGST", BasisPoints: 900},
+		{Name: "CESS", BasisPoints: 50},
+	}, rates)
+
+	rates, err = ParseTaxRates("")
+	require.NoError(t, err)
+	require.Empty(t, rates)
+
+	_, err = ParseTaxRates("CGST")
+	require.Error(t, err)
+
+	_, err = ParseTaxRates("CGST:nine")
+	require.Error(t, err)
+}
+
+func TestTaxAmount(t *testing.T) {
+	require.Equal(t, int64(90), TaxAmount(1000, 900))
+	require.Equal(t, int64(14), TaxAmount(150, 900))
+	require.Equal(t, int64(13), TaxAmount(149, 900))
+	require.Equal(t, int64(0), TaxAmount(1000, 0))
+}
+
+func TestFormatInvoiceNumber(t *testing.T) {
+	issuedAt := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	series := InvoiceSeries(issuedAt)
+
+	require.Equal(t, "2026", series)
+	require.Equal(t, "INV-2026-000042", FormatInvoiceNumber(series, 42))
+}
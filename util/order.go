@@ -7,6 +7,80 @@ import (
 	"time"
 )
 
+// Statuses an order moves through
+const (
+	OrderStatusStarted        = "Started"
+	OrderStatusPending        = "Pending"
+	OrderStatusAccepted       = "Accepted"
+	OrderStatusWorkInProgress = "Work In Progress"
+	OrderStatusDone           = "Done"
+	OrderStatusDelivered      = "Delivered"
+	OrderStatusCancelled      = "Cancelled"
+)
+
+// orderTransitions lists the statuses an order can move to from each status.
+// Delivered and cancelled orders are final.
+var orderTransitions = map[string][]string{
+	OrderStatusStarted:        {OrderStatusPending, OrderStatusAccepted, OrderStatusCancelled},
+	OrderStatusPending:        {OrderStatusAccepted, OrderStatusCancelled},
+	OrderStatusAccepted:       {OrderStatusWorkInProgress, OrderStatusCancelled},
+	OrderStatusWorkInProgress: {OrderStatusDone, OrderStatusCancelled},
+	OrderStatusDone:           {OrderStatusDelivered},
+}
+
+// IsOrderStatus reports whether status is one of the known order statuses
+func IsOrderStatus(status string) bool {
+	if _, ok := orderTransitions[status]; ok {
+		return true
+	}
+	return status == OrderStatusDelivered || status == OrderStatusCancelled
+}
+
+// CanTransitionOrder reports whether an order in status from can be moved to status to
+func CanTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Events recorded in the order history
+const (
+	OrderEventStatusChanged = "status_changed"
+	OrderEventDelivered     = "delivered"
+	OrderEventCancelled     = "cancelled"
+	OrderEventRescheduled   = "rescheduled"
+	OrderEventAssigned      = "assigned"
+	OrderEventReassigned    = "reassigned"
+	OrderEventUnassigned    = "unassigned"
+)
+
+// Strategies for assigning an order to a staff member automatically
+const (
+	// AssignRoundRobin picks the staff member who was given an order longest ago
+	AssignRoundRobin = "round_robin"
+	// AssignLeastLoaded picks the staff member with the fewest open orders
+	AssignLeastLoaded = "least_loaded"
+)
+
+// Visibility of order notes and attachments
+const (
+	VisibilityInternal = "internal"
+	VisibilityCustomer = "customer"
+)
+
+// DefaultTurnaroundHours is the turnaround promised for a service unless another one is set
+const DefaultTurnaroundHours = 48
+
+// How an open order is tracking against its due time
+const (
+	SLAOnTrack = "on_track"
+	SLAAtRisk  = "at_risk"
+	SLAOverdue = "overdue"
+)
+
 var (
 	orderIDCounter int
 	mutex          sync.Mutex
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransitionOrder(t *testing.T) {
+	testCases := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{OrderStatusStarted, OrderStatusAccepted, true},
+		{OrderStatusAccepted, OrderStatusWorkInProgress, true},
+		{OrderStatusWorkInProgress, OrderStatusDone, true},
+		{OrderStatusDone, OrderStatusDelivered, true},
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusStarted, OrderStatusDone, false},
+		{OrderStatusDone, OrderStatusCancelled, false},
+		{OrderStatusDelivered, OrderStatusCancelled, false},
+		{OrderStatusCancelled, OrderStatusStarted, false},
+		{OrderStatusAccepted, OrderStatusAccepted, false},
+		{"unknown", OrderStatusAccepted, false},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.allowed, CanTransitionOrder(tc.from, tc.to), "%s -> %s", tc.from, tc.to)
+	}
+}
+
+func TestIsOrderStatus(t *testing.T) {
+	require.True(t, IsOrderStatus(OrderStatusWorkInProgress))
+	require.True(t, IsOrderStatus(OrderStatusCancelled))
+	require.False(t, IsOrderStatus("Lost"))
+}
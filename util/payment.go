@@ -0,0 +1,51 @@
+package util
+
+// Payment methods accepted by the payments ledger
+const (
+	PaymentMethodCash   = "cash"
+	PaymentMethodCard   = "card"
+	PaymentMethodUPI    = "upi"
+	PaymentMethodWallet = "wallet"
+	// PaymentMethodOnline is used for gateway payments until the gateway reports the actual method
+	PaymentMethodOnline = "online"
+)
+
+// Status of a single payment or refund entry
+const (
+	PaymentPending  = "pending"
+	PaymentCaptured = "captured"
+	PaymentFailed   = "failed"
+)
+
+// Payment status of an order derived from its ledger entries
+const (
+	OrderUnpaid   = "unpaid"
+	OrderPartial  = "partial"
+	OrderPaid     = "paid"
+	OrderRefunded = "refunded"
+)
+
+// OrderPaymentStatus derives the payment status of an order from the amount due and the
+// captured payments and refunds recorded against it
+func OrderPaymentStatus(amountDue, paid, refunded int64) string {
+	net := paid - refunded
+	switch {
+	case refunded > 0 && net <= 0:
+		return OrderRefunded
+	case net <= 0:
+		return OrderUnpaid
+	case net < amountDue:
+		return OrderPartial
+	default:
+		return OrderPaid
+	}
+}
+
+// IsPaymentMethod reports whether method is one of the methods the ledger can record
+func IsPaymentMethod(method string) bool {
+	switch method {
+	case PaymentMethodCash, PaymentMethodCard, PaymentMethodUPI, PaymentMethodWallet, PaymentMethodOnline:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderPaymentStatus(t *testing.T) {
+	testCases := []struct {
+		name      string
+		amountDue int64
+		paid      int64
+		refunded  int64
+		status    string
+	}{
+		{name: "NoPayments", amountDue: 1000, status: OrderUnpaid},
+		{name: "Partial", amountDue: 1000, paid: 400, status: OrderPartial},
+		{name: "Paid", amountDue: 1000, paid: 1000, status: OrderPaid},
+		{name: "Overpaid", amountDue: 1000, paid: 1200, status: OrderPaid},
+		{name: "PartlyRefunded", amountDue: 1000, paid: 1000, refunded: 300, status: OrderPartial},
+		{name: "FullyRefunded", amountDue: 1000, paid: 1000, refunded: 1000, status: OrderRefunded},
+		{name: "NothingDue", amountDue: 0, status: OrderUnpaid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.status, OrderPaymentStatus(tc.amountDue, tc.paid, tc.refunded))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// SearchDigits returns the digits of a query that looks like a phone or order number,
+// such as "+91 98765-43210", and an empty string for any other query
+func SearchDigits(query string) string {
+	var digits strings.Builder
+	for _, r := range query {
+		switch {
+		case unicode.IsDigit(r):
+			digits.WriteRune(r)
+		case r == ' ' || r == '+' || r == '-' || r == '(' || r == ')':
+		default:
+			return ""
+		}
+	}
+	return digits.String()
+}
+
+// Highlight escapes text for HTML and wraps the parts that match a word of the query in <mark> tags.
+// Matching ignores case and also finds words inside longer ones, like the trigram search does.
+func Highlight(text, query string) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(runes))
+	for _, term := range strings.Fields(query) {
+		word := []rune(strings.ToLower(term))
+		for i := 0; i+len(word) <= len(lower); i++ {
+			if string(lower[i:i+len(word)]) == string(word) {
+				for j := i; j < i+len(word); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		part := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			part = "<mark>" + part + "</mark>"
+		}
+		b.WriteString(part)
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchDigits(t *testing.T) {
+	require.Equal(t, "919876543210", SearchDigits("+91 98765-43210"))
+	require.Equal(t, "1234", SearchDigits("1234"))
+	require.Empty(t, SearchDigits("ram 98"))
+	require.Empty(t, SearchDigits("shirt"))
+}
+
+func TestHighlight(t *testing.T) {
+	testCases := []struct {
+		text, query, highlighted string
+	}{
+		{"Shriram Iyer", "ram", "Shri<mark>ram</mark> Iyer"},
+		{"Dry Clean Suit", "suit dry", "<mark>Dry</mark> Clean <mark>Suit</mark>"},
+		{"Banana", "an", "B<mark>anan</mark>a"},
+		{"<b>Silk</b>", "silk", "&lt;b&gt;<mark>Silk</mark>&lt;/b&gt;"},
+		{"Saree", "shirt", "Saree"},
+		{"Ünal", "ün", "<mark>Ün</mark>al"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.highlighted, Highlight(tc.text, tc.query))
+	}
+}
@@ -0,0 +1,75 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Types of time slots customers can choose from
+const (
+	SlotPickup   = "pickup"
+	SlotDelivery = "delivery"
+)
+
+// SlotWindow is the time of day a slot starts and ends, as offsets from midnight
+type SlotWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// SlotTime is a single slot generated from a window on a given day
+type SlotTime struct {
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// ParseSlotWindow parses a window written as "09:00-11:00"
+func ParseSlotWindow(s string) (SlotWindow, error) {
+	start, end, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return SlotWindow{}, fmt.Errorf("invalid slot window %q, expected HH:MM-HH:MM", s)
+	}
+
+	var window SlotWindow
+	var err error
+	window.Start, err = parseClock(start)
+	if err != nil {
+		return SlotWindow{}, err
+	}
+	window.End, err = parseClock(end)
+	if err != nil {
+		return SlotWindow{}, err
+	}
+	if window.End <= window.Start {
+		return SlotWindow{}, fmt.Errorf("slot window %q must end after it starts", s)
+	}
+	return window, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// SlotTimes returns the slots for every window on every day from the day of from up to
+// and including the day of to, with the days taken in loc
+func SlotTimes(from, to time.Time, windows []SlotWindow, loc *time.Location) []SlotTime {
+	from, to = from.In(loc), to.In(loc)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+
+	var slots []SlotTime
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		for _, window := range windows {
+			slots = append(slots, SlotTime{
+				StartsAt: day.Add(window.Start),
+				EndsAt:   day.Add(window.End),
+			})
+		}
+	}
+	return slots
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSlotWindow(t *testing.T) {
+	window, err := ParseSlotWindow("09:00-11:30")
+	require.NoError(t, err)
+	require.Equal(t, 9*time.Hour, window.Start)
+	require.Equal(t, 11*time.Hour+30*time.Minute, window.End)
+
+	for _, s := range []string{"", "09:00", "9-11", "11:00-09:00", "10:00-10:00", "25:00-26:00"} {
+		_, err := ParseSlotWindow(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestSlotTimes(t *testing.T) {
+	loc := time.FixedZone("IST", 5*60*60+30*60)
+	windows := []SlotWindow{
+		{Start: 9 * time.Hour, End: 11 * time.Hour},
+		{Start: 17 * time.Hour, End: 19 * time.Hour},
+	}
+
+	from := time.Date(2026, 10, 20, 23, 0, 0, 0, time.UTC) // already the 21st in loc
+	to := time.Date(2026, 10, 22, 12, 0, 0, 0, loc)
+
+	slots := SlotTimes(from, to, windows, loc)
+	require.Len(t, slots, 4)
+	require.True(t, slots[0].StartsAt.Equal(time.Date(2026, 10, 21, 9, 0, 0, 0, loc)))
+	require.True(t, slots[1].EndsAt.Equal(time.Date(2026, 10, 21, 19, 0, 0, 0, loc)))
+	require.True(t, slots[3].StartsAt.Equal(time.Date(2026, 10, 22, 17, 0, 0, 0, loc)))
+
+	require.Empty(t, SlotTimes(to, from, windows, loc))
+}
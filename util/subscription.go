@@ -0,0 +1,86 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// How often a subscription creates an order
+const (
+	FrequencyWeekly   = "weekly"
+	FrequencyBiweekly = "biweekly"
+	FrequencyMonthly  = "monthly"
+	FrequencyCron     = "cron"
+)
+
+// Status of a subscription
+const (
+	SubscriptionActive    = "active"
+	SubscriptionPaused    = "paused"
+	SubscriptionCancelled = "cancelled"
+)
+
+// Outcome of a single scheduled run of a subscription
+const (
+	SubscriptionRunCreated = "created"
+	SubscriptionRunFailed  = "failed"
+	SubscriptionRunSkipped = "skipped"
+)
+
+// ValidateSchedule checks the frequency and, for cron subscriptions, the cron expression
+func ValidateSchedule(frequency, cronExpr string) error {
+	switch frequency {
+	case FrequencyWeekly, FrequencyBiweekly, FrequencyMonthly:
+		return nil
+	case FrequencyCron:
+		schedule, err := ParseCron(cronExpr)
+		if err != nil {
+			return err
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("cron expression %q never runs", cronExpr)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown frequency %q", frequency)
+	}
+}
+
+// NextRun returns the run following last. Calendar based frequencies are counted in loc so a
+// weekly run keeps its time of day across daylight saving changes. Monthly runs fall on the day
+// of the month of anchor, or on the last day of shorter months.
+func NextRun(frequency, cronExpr string, last, anchor time.Time, loc *time.Location) (time.Time, error) {
+	last = last.In(loc)
+	switch frequency {
+	case FrequencyWeekly:
+		return last.AddDate(0, 0, 7), nil
+	case FrequencyBiweekly:
+		return last.AddDate(0, 0, 14), nil
+	case FrequencyMonthly:
+		return nextMonthDay(last, anchor.In(loc).Day()), nil
+	case FrequencyCron:
+		schedule, err := ParseCron(cronExpr)
+		if err != nil {
+			return time.Time{}, err
+		}
+		next := schedule.Next(last)
+		if next.IsZero() {
+			return time.Time{}, errors.New("cron expression never runs again")
+		}
+		return next, nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown frequency %q", frequency)
+	}
+}
+
+// nextMonthDay returns the given day of the month after t at the same time of day,
+// clamped to the last day of that month
+func nextMonthDay(t time.Time, day int) time.Time {
+	year, month, _ := t.Date()
+	first := time.Date(year, month+1, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if lastDay := first.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,8 @@
+package util
+
+// What a file uploaded straight to the storage is attached to once it is confirmed
+const (
+	UploadServiceImage    = "service_image"
+	UploadOrderAttachment = "order_attachment"
+	UploadProfileImage    = "profile_image"
+)